@@ -11,14 +11,28 @@ func (c *Client) GetTxClient() tx.ServiceClient {
 	return tx.NewServiceClient(c)
 }
 
-// BroadcastTx broadcasts transaction.
-func (c *Client) BroadcastTx(ctx context.Context, txBytes []byte) (*tx.BroadcastTxResponse, error) {
+// BroadcastTx broadcasts transaction in the broadcast mode, which is sync if unspecified.
+func (c *Client) BroadcastTx(ctx context.Context, txBytes []byte, mode tx.BroadcastMode) (*tx.BroadcastTxResponse, error) {
 	client := c.GetTxClient()
 
+	if mode == tx.BroadcastMode_BROADCAST_MODE_UNSPECIFIED {
+		mode = tx.BroadcastMode_BROADCAST_MODE_SYNC
+	}
 	req := &tx.BroadcastTxRequest{
 		TxBytes: txBytes,
-		Mode:    tx.BroadcastMode_BROADCAST_MODE_SYNC, // should use async mode for the stress testing
+		Mode:    mode,
 	}
 
 	return client.BroadcastTx(ctx, req)
 }
+
+// Simulate simulates the transaction, returning the gas it uses.
+func (c *Client) Simulate(ctx context.Context, txBytes []byte) (*tx.SimulateResponse, error) {
+	client := c.GetTxClient()
+
+	req := &tx.SimulateRequest{
+		TxBytes: txBytes,
+	}
+
+	return client.Simulate(ctx, req)
+}
@@ -3,15 +3,63 @@ package rpc
 import (
 	"context"
 	"fmt"
+	"sync"
 
+	"github.com/rs/zerolog/log"
 	rpcclient "github.com/tendermint/tendermint/rpc/client"
 	rpc "github.com/tendermint/tendermint/rpc/client/http"
 	tmctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
 )
 
+// txsSubscriber is the name of the single subscription of a client to the committed txs.
+const txsSubscriber = "tester"
+
+// droppedTxsLogInterval is the number of dropped tx events of a subscriber between the warnings, which are
+// logged from the first one.
+const droppedTxsLogInterval = 100
+
 // Client wraps RPC client connection.
 type Client struct {
 	rpcclient.Client
+
+	mu  sync.Mutex
+	txs map[string]*txSubscription // subscribers of the committed txs by name, nil before the subscription
+}
+
+// txSubscription is a subscriber of the committed txs.
+type txSubscription struct {
+	mu      sync.Mutex // guards out from being closed while an event is passed
+	out     chan tmctypes.ResultEvent
+	closed  bool
+	dropped int
+}
+
+// send passes the event to the subscriber unless its buffer is full, in which case the event is dropped
+// so that a slow subscriber does not hold back the others. It returns the number of dropped events if
+// the event is dropped, and 0 otherwise.
+func (s *txSubscription) send(ev tmctypes.ResultEvent) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0
+	}
+	select {
+	case s.out <- ev:
+		return 0
+	default:
+		s.dropped++
+		return s.dropped
+	}
+}
+
+func (s *txSubscription) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.out)
+	}
 }
 
 // NewClient creates RPC client.
@@ -21,7 +69,7 @@ func NewClient(rpcURL string, timeout int64) (*Client, error) {
 		return &Client{}, fmt.Errorf("failed to connect RPC client: %s", err)
 	}
 
-	return &Client{rpcClient}, nil
+	return &Client{Client: rpcClient}, nil
 }
 
 // GetNetworkChainID returns network chain id.
@@ -38,3 +86,72 @@ func (c *Client) GetNetworkChainID(ctx context.Context) (string, error) {
 func (c *Client) GetStatus(ctx context.Context) (*tmctypes.ResultStatus, error) {
 	return c.Status(ctx)
 }
+
+// SubscribeTxs subscribes to the events of the committed txs through the websocket, which is started if
+// it is not running yet. The node only accepts a subscription per query from a websocket, so the client
+// subscribes once and every subscriber gets all the events until its ctx is done. The events are dropped
+// for a subscriber whose buffer of the given capacity is full. The names of the subscribers must be unique.
+func (c *Client) SubscribeTxs(ctx context.Context, subscriber string, capacity int) (<-chan tmctypes.ResultEvent, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.txs[subscriber]; ok {
+		return nil, fmt.Errorf("already subscribed to txs: %s", subscriber)
+	}
+	if c.txs == nil {
+		if !c.IsRunning() {
+			if err := c.Start(); err != nil {
+				return nil, fmt.Errorf("failed to start websocket: %v", err)
+			}
+		}
+		events, err := c.Subscribe(ctx, txsSubscriber, tmtypes.EventQueryTx.String(), capacity)
+		if err != nil {
+			return nil, err
+		}
+		c.txs = make(map[string]*txSubscription)
+		go c.fanOutTxs(events)
+	}
+
+	sub := &txSubscription{out: make(chan tmctypes.ResultEvent, capacity)}
+	c.txs[subscriber] = sub
+	go func() {
+		<-ctx.Done()
+		c.unsubscribeTxs(subscriber, sub)
+	}()
+	return sub.out, nil
+}
+
+// fanOutTxs passes the events of the subscription to every subscriber without waiting for any of them,
+// and closes their channels when the subscription is closed.
+func (c *Client) fanOutTxs(events <-chan tmctypes.ResultEvent) {
+	for ev := range events {
+		c.mu.Lock()
+		subs := make(map[string]*txSubscription, len(c.txs))
+		for name, sub := range c.txs {
+			subs[name] = sub
+		}
+		c.mu.Unlock()
+
+		for name, sub := range subs {
+			if dropped := sub.send(ev); dropped == 1 || dropped%droppedTxsLogInterval == 0 {
+				log.Warn().Str("subscriber", name).Int("dropped", dropped).Msg("dropped tx events of a slow subscriber")
+			}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, sub := range c.txs {
+		sub.close()
+	}
+	c.txs = nil
+}
+
+func (c *Client) unsubscribeTxs(subscriber string, sub *txSubscription) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.txs[subscriber] != sub {
+		return // closed with the subscription
+	}
+	delete(c.txs, subscriber)
+	sub.close()
+}
@@ -2,9 +2,15 @@ package rpc_test
 
 import (
 	"context"
+	"errors"
 	"os"
+	"sync"
 	"testing"
 
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+
 	"github.com/b-harvest/modules-test-tool/client/rpc"
 	"github.com/b-harvest/modules-test-tool/codec"
 
@@ -31,3 +37,99 @@ func TestGetNetworkChainID(t *testing.T) {
 
 	t.Log(chainID)
 }
+
+// fakeEvents accepts a single subscription per query, as a node does for a websocket.
+type fakeEvents struct {
+	rpcclient.Client
+
+	mu      sync.Mutex
+	running bool
+	queries map[string]chan ctypes.ResultEvent
+}
+
+func (f *fakeEvents) IsRunning() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.running
+}
+
+func (f *fakeEvents) Start() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.running = true
+	return nil
+}
+
+func (f *fakeEvents) Subscribe(ctx context.Context, subscriber, query string, outCapacity ...int) (<-chan ctypes.ResultEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.queries[query]; ok {
+		return nil, errors.New("already subscribed")
+	}
+	out := make(chan ctypes.ResultEvent)
+	f.queries[query] = out
+	return out, nil
+}
+
+func TestSubscribeTxs(t *testing.T) {
+	events := &fakeEvents{queries: make(map[string]chan ctypes.ResultEvent)}
+	client := &rpc.Client{Client: events}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	first, err := client.SubscribeTxs(ctx, "first", 10)
+	require.NoError(t, err)
+	secondCtx, cancelSecond := context.WithCancel(context.Background())
+	second, err := client.SubscribeTxs(secondCtx, "second", 10)
+	require.NoError(t, err)
+	_, err = client.SubscribeTxs(ctx, "first", 10)
+	require.Error(t, err)
+	require.Len(t, events.queries, 1)
+
+	// every subscriber gets every event
+	upstream := events.queries[tmtypes.EventQueryTx.String()]
+	upstream <- ctypes.ResultEvent{Query: "1"}
+	require.Equal(t, "1", (<-first).Query)
+	require.Equal(t, "1", (<-second).Query)
+
+	// a subscriber whose ctx is done gets no more events
+	cancelSecond()
+	_, ok := <-second
+	require.False(t, ok)
+	upstream <- ctypes.ResultEvent{Query: "2"}
+	require.Equal(t, "2", (<-first).Query)
+
+	close(upstream)
+	_, ok = <-first
+	require.False(t, ok)
+}
+
+func TestSubscribeTxsStalledSubscriber(t *testing.T) {
+	events := &fakeEvents{queries: make(map[string]chan ctypes.ResultEvent)}
+	client := &rpc.Client{Client: events}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stalled, err := client.SubscribeTxs(ctx, "stalled", 1)
+	require.NoError(t, err)
+	active, err := client.SubscribeTxs(ctx, "active", 1)
+	require.NoError(t, err)
+
+	// the stalled subscriber never reads, which must hold back neither the others nor new subscriptions
+	upstream := events.queries[tmtypes.EventQueryTx.String()]
+	for _, q := range []string{"1", "2", "3"} {
+		upstream <- ctypes.ResultEvent{Query: q}
+		require.Equal(t, q, (<-active).Query)
+	}
+	late, err := client.SubscribeTxs(ctx, "late", 1)
+	require.NoError(t, err)
+	upstream <- ctypes.ResultEvent{Query: "4"}
+	require.Equal(t, "4", (<-active).Query)
+	require.Equal(t, "4", (<-late).Query)
+
+	// the stalled subscriber keeps the events which fitted its buffer
+	require.Equal(t, "1", (<-stalled).Query)
+	close(upstream)
+	_, ok := <-stalled
+	require.False(t, ok)
+}
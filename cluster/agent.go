@@ -0,0 +1,90 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Agent is the client of an agent to the coordinator.
+type Agent struct {
+	url    string
+	client *http.Client
+
+	ID int
+}
+
+// NewAgent creates a new Agent of the coordinator at the given url.
+func NewAgent(url string) *Agent {
+	return &Agent{
+		url:    strings.TrimSuffix(url, "/"),
+		client: &http.Client{},
+	}
+}
+
+// Register registers the agent and waits for every other agent to register.
+func (a *Agent) Register(ctx context.Context, name string) (Assignment, error) {
+	var asg Assignment
+	if err := a.post(ctx, PathRegister, RegisterRequest{Name: name}, &asg); err != nil {
+		return asg, err
+	}
+	a.ID = asg.AgentID
+	return asg, nil
+}
+
+// Start waits for every agent to be ready to run the given scenario and returns the height to start at.
+func (a *Agent) Start(ctx context.Context, scenario int) (int64, error) {
+	var resp StartResponse
+	if err := a.post(ctx, PathStart, StartRequest{AgentID: a.ID, Scenario: scenario}, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Height, nil
+}
+
+// Report reports the results of the agent.
+func (a *Agent) Report(ctx context.Context, results ...BlockResult) error {
+	return a.post(ctx, PathResults, ResultsRequest{AgentID: a.ID, Results: results}, nil)
+}
+
+// Done tells the coordinator that the agent is done, with the error it failed with if any.
+func (a *Agent) Done(ctx context.Context, runErr error) error {
+	req := DoneRequest{AgentID: a.ID}
+	if runErr != nil {
+		req.Error = runErr.Error()
+	}
+	return a.post(ctx, PathDone, req, nil)
+}
+
+func (a *Agent) post(ctx context.Context, path string, reqBody, respBody interface{}) error {
+	bz, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url+path, bytes.NewReader(bz))
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("post %s: %s: %s", path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if respBody == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(respBody); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
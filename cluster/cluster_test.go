@@ -0,0 +1,214 @@
+package cluster_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/cluster"
+	"github.com/b-harvest/modules-test-tool/scenario"
+)
+
+func testScenarios() []scenario.Scenario {
+	return []scenario.Scenario{
+		{Name: "first", Rounds: 3, NumTxsPerBlock: 100},
+		{Name: "second", Rounds: 2, NumTxsPerBlock: 10, Accounts: []int{1, 3, 5}},
+	}
+}
+
+func TestAssign(t *testing.T) {
+	assignments, err := cluster.Assign(testScenarios(), 7, 3, 42)
+	require.NoError(t, err)
+	require.Len(t, assignments, 3)
+
+	require.Equal(t, []int{0, 3, 6}, assignments[0].Scenarios[0].Accounts)
+	require.Equal(t, []int{1, 4}, assignments[1].Scenarios[0].Accounts)
+	require.Equal(t, []int{2, 5}, assignments[2].Scenarios[0].Accounts)
+	require.Equal(t, []int{1}, assignments[0].Scenarios[1].Accounts)
+	require.Equal(t, []int{3}, assignments[1].Scenarios[1].Accounts)
+	require.Equal(t, []int{5}, assignments[2].Scenarios[1].Accounts)
+
+	require.NotEqual(t, assignments[0].Seed, assignments[1].Seed)
+	for i, asg := range assignments {
+		require.Equal(t, i, asg.Share.Index)
+		require.Equal(t, 3, asg.Share.Of)
+	}
+
+	_, err = cluster.Assign(testScenarios(), 7, 4, 42)
+	require.Error(t, err)
+}
+
+func TestCoordinatorMergesAgents(t *testing.T) {
+	const numAgents = 3
+
+	var mu sync.Mutex
+	var merged []cluster.BlockResult
+	c, err := cluster.NewCoordinator(cluster.CoordinatorConfig{
+		NumAgents:   numAgents,
+		NumAccounts: 6,
+		Scenarios:   testScenarios(),
+		StartDelay:  2,
+		LatestHeight: func(ctx context.Context) (int64, error) {
+			return 100, nil
+		},
+		Sink: func(res cluster.BlockResult) error {
+			mu.Lock()
+			defer mu.Unlock()
+			merged = append(merged, res)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	errs := make(chan error, numAgents)
+	for i := 0; i < numAgents; i++ {
+		go func() {
+			errs <- runAgent(ctx, cluster.NewAgent(srv.URL))
+		}()
+	}
+	for i := 0; i < numAgents; i++ {
+		require.NoError(t, <-errs)
+	}
+	require.NoError(t, c.Wait(ctx))
+
+	require.Len(t, merged, 5)
+	for i, res := range merged {
+		require.Equal(t, int64(102+i), res.Height)
+	}
+	require.Equal(t, 100, merged[0].Planned)
+	require.Equal(t, 100, merged[0].Broadcast)
+	require.Equal(t, 100, merged[0].Committed)
+	require.Equal(t, 10, merged[4].Planned)
+	require.Equal(t, map[uint32]int{0: 100}, merged[0].Codes)
+	require.Equal(t, map[string]int{"ok": 100}, merged[0].Classes)
+}
+
+// runAgent runs every scenario of the assignment as if every planned tx had been committed.
+// Every agent runs a scenario from the height it is given, so the heights of the scenarios overlap.
+func runAgent(ctx context.Context, a *cluster.Agent) error {
+	asg, err := a.Register(ctx, "agent")
+	if err != nil {
+		return err
+	}
+	height := int64(0)
+	for no, s := range asg.Scenarios {
+		start, err := a.Start(ctx, no)
+		if err != nil {
+			return err
+		}
+		if start != 102 {
+			return errors.New("unexpected start height")
+		}
+		if height < start {
+			height = start
+		}
+		for i := 0; i < s.Rounds; i++ {
+			n := asg.Share.Part(s.NumTxsPerBlock)
+			if err := a.Report(ctx, cluster.BlockResult{
+				Height:    height,
+				Broadcast: n,
+				Committed: s.NumTxsPerBlock,
+				Planned:   n,
+				Codes:     map[uint32]int{0: n},
+				Classes:   map[string]int{"ok": n},
+			}); err != nil {
+				return err
+			}
+			height++
+		}
+	}
+	return a.Done(ctx, nil)
+}
+
+func TestCoordinatorReleasesDoneAgents(t *testing.T) {
+	c, err := cluster.NewCoordinator(cluster.CoordinatorConfig{
+		NumAgents:   2,
+		NumAccounts: 2,
+		Scenarios:   testScenarios()[:1],
+		LatestHeight: func(ctx context.Context) (int64, error) {
+			return 10, nil
+		},
+		Sink: func(cluster.BlockResult) error { return nil },
+	})
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	failing := cluster.NewAgent(srv.URL)
+	waiting := cluster.NewAgent(srv.URL)
+	registered := make(chan error, 1)
+	go func() {
+		_, err := waiting.Register(ctx, "waiting")
+		registered <- err
+	}()
+	_, err = failing.Register(ctx, "failing")
+	require.NoError(t, err)
+	require.NoError(t, <-registered)
+
+	started := make(chan int64, 1)
+	go func() {
+		height, _ := waiting.Start(ctx, 0)
+		started <- height
+	}()
+	require.NoError(t, failing.Done(ctx, errors.New("out of funds")))
+	require.Equal(t, int64(10), <-started)
+
+	require.NoError(t, waiting.Done(ctx, nil))
+	err = c.Wait(ctx)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "out of funds")
+}
+
+func TestCoordinatorFreesCancelledRegistration(t *testing.T) {
+	c, err := cluster.NewCoordinator(cluster.CoordinatorConfig{
+		NumAgents:   2,
+		NumAccounts: 2,
+		Scenarios:   testScenarios()[:1],
+		LatestHeight: func(ctx context.Context) (int64, error) {
+			return 10, nil
+		},
+		Sink: func(cluster.BlockResult) error { return nil },
+	})
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	// an agent gives up waiting for the others
+	cancelled, cancelRegister := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancelRegister()
+	_, err = cluster.NewAgent(srv.URL).Register(cancelled, "gone")
+	require.Error(t, err)
+	time.Sleep(100 * time.Millisecond) // for the coordinator to see the request cancelled
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ids := make(chan int, 2)
+	errs := make(chan error, 2)
+	for _, name := range []string{"first", "second"} {
+		name := name
+		go func() {
+			asg, err := cluster.NewAgent(srv.URL).Register(ctx, name)
+			ids <- asg.AgentID
+			errs <- err
+		}()
+	}
+	require.NoError(t, <-errs)
+	require.NoError(t, <-errs)
+	require.ElementsMatch(t, []int{0, 1}, []int{<-ids, <-ids})
+}
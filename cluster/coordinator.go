@@ -0,0 +1,376 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/b-harvest/modules-test-tool/load"
+	"github.com/b-harvest/modules-test-tool/scenario"
+)
+
+// CoordinatorConfig configures a Coordinator.
+type CoordinatorConfig struct {
+	NumAgents   int
+	NumAccounts int
+	Scenarios   []scenario.Scenario
+	Seed        int64
+
+	// StartDelay is the number of blocks after the latest one at which the agents start a scenario.
+	StartDelay int64
+	// LatestHeight returns the latest block height of the network.
+	LatestHeight func(ctx context.Context) (int64, error)
+	// Sink receives the merged results in the order of their heights.
+	Sink func(BlockResult) error
+}
+
+// barrier holds the agents back until all of them are ready to start a scenario.
+type barrier struct {
+	arrived   int
+	releasing bool // the start height is being queried
+	released  chan struct{}
+	height    int64
+	err       error
+}
+
+// Coordinator hands out the assignments to the agents, synchronizes their starts
+// and merges their results. It serves the agents over HTTP.
+type Coordinator struct {
+	cfg         CoordinatorConfig
+	assignments []Assignment
+	mux         *http.ServeMux
+
+	mu            sync.Mutex
+	names         []string // names of the agents by id
+	taken         []bool   // whether the id has been given to an agent
+	numRegistered int
+	registered    chan struct{}
+	barriers      map[int]*barrier
+	lastHeights   []int64
+	done          []bool
+	numDone       int
+	errs          []string
+	pending       map[int64]*BlockResult
+	sinkErr       error
+	finished      chan struct{}
+}
+
+// NewCoordinator creates a new Coordinator. Every scenario must have at least an account per agent.
+func NewCoordinator(cfg CoordinatorConfig) (*Coordinator, error) {
+	if cfg.NumAgents <= 0 {
+		return nil, fmt.Errorf("number of agents must be positive: %d", cfg.NumAgents)
+	}
+	assignments, err := Assign(cfg.Scenarios, cfg.NumAccounts, cfg.NumAgents, cfg.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Coordinator{
+		cfg:         cfg,
+		assignments: assignments,
+		mux:         http.NewServeMux(),
+		names:       make([]string, cfg.NumAgents),
+		taken:       make([]bool, cfg.NumAgents),
+		registered:  make(chan struct{}),
+		barriers:    make(map[int]*barrier),
+		lastHeights: make([]int64, cfg.NumAgents),
+		done:        make([]bool, cfg.NumAgents),
+		pending:     make(map[int64]*BlockResult),
+		finished:    make(chan struct{}),
+	}
+	c.mux.HandleFunc(PathRegister, c.handleRegister)
+	c.mux.HandleFunc(PathStart, c.handleStart)
+	c.mux.HandleFunc(PathResults, c.handleResults)
+	c.mux.HandleFunc(PathDone, c.handleDone)
+	return c, nil
+}
+
+// Assign splits the scenarios over the agents. Every agent takes an equal share of the load
+// and a disjoint set of the accounts of every scenario, and derives its own seed from the given one.
+func Assign(scenarios []scenario.Scenario, numAccounts, numAgents int, seed int64) ([]Assignment, error) {
+	assignments := make([]Assignment, numAgents)
+	for i := range assignments {
+		assignments[i] = Assignment{
+			AgentID: i,
+			Share:   load.Share{Index: i, Of: numAgents},
+			Seed:    load.NewRand(seed, i).Int63(),
+		}
+	}
+
+	for _, s := range scenarios {
+		indexes := s.Accounts
+		if len(indexes) == 0 {
+			for i := 0; i < numAccounts; i++ {
+				indexes = append(indexes, i)
+			}
+		}
+		if len(indexes) < numAgents {
+			return nil, fmt.Errorf("scenario %s has %d accounts for %d agents", s.Name, len(indexes), numAgents)
+		}
+		for i := range assignments {
+			sc := s
+			sc.Accounts = nil
+			for j := i; j < len(indexes); j += numAgents {
+				sc.Accounts = append(sc.Accounts, indexes[j])
+			}
+			assignments[i].Scenarios = append(assignments[i].Scenarios, sc)
+		}
+	}
+	return assignments, nil
+}
+
+func (c *Coordinator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mux.ServeHTTP(w, r)
+}
+
+// Wait waits for every agent to be done and returns the errors of the agents, if any.
+func (c *Coordinator) Wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.finished:
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sinkErr != nil {
+		return fmt.Errorf("write result: %w", c.sinkErr)
+	}
+	if len(c.errs) > 0 {
+		return errors.New(strings.Join(c.errs, "; "))
+	}
+	return nil
+}
+
+func (c *Coordinator) handleRegister(w http.ResponseWriter, r *http.Request) {
+	var req RegisterRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	c.mu.Lock()
+	id := -1
+	for i, taken := range c.taken {
+		if !taken {
+			id = i
+			break
+		}
+	}
+	if id < 0 {
+		c.mu.Unlock()
+		http.Error(w, "all agents have been registered", http.StatusConflict)
+		return
+	}
+	c.names[id], c.taken[id] = req.Name, true
+	c.numRegistered++
+	if c.numRegistered == c.cfg.NumAgents {
+		close(c.registered)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-r.Context().Done():
+		c.unregister(id)
+		return
+	case <-c.registered:
+	}
+	encode(w, c.assignments[id])
+}
+
+// unregister gives back the id of an agent which has given up waiting for the other agents,
+// unless every agent has registered in the meantime.
+func (c *Coordinator) unregister(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.registered:
+		return
+	default:
+	}
+	c.names[id], c.taken[id] = "", false
+	c.numRegistered--
+}
+
+func (c *Coordinator) handleStart(w http.ResponseWriter, r *http.Request) {
+	var req StartRequest
+	if !decode(w, r, &req) || !c.validAgent(w, req.AgentID) {
+		return
+	}
+
+	c.mu.Lock()
+	b, ok := c.barriers[req.Scenario]
+	if !ok {
+		b = &barrier{released: make(chan struct{})}
+		c.barriers[req.Scenario] = b
+	}
+	b.arrived++
+	ready := c.readyBarriers()
+	c.mu.Unlock()
+	c.releaseBarriers(r.Context(), ready)
+
+	select {
+	case <-r.Context().Done():
+		return
+	case <-b.released:
+	}
+	if b.err != nil {
+		http.Error(w, b.err.Error(), http.StatusInternalServerError)
+		return
+	}
+	encode(w, StartResponse{Height: b.height})
+}
+
+func (c *Coordinator) handleResults(w http.ResponseWriter, r *http.Request) {
+	var req ResultsRequest
+	if !decode(w, r, &req) || !c.validAgent(w, req.AgentID) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, res := range req.Results {
+		merged, ok := c.pending[res.Height]
+		if !ok {
+			res := res
+			c.pending[res.Height] = &res
+		} else {
+			merged.Broadcast += res.Broadcast
+			merged.Planned += res.Planned
+			if res.Committed > merged.Committed {
+				merged.Committed = res.Committed
+			}
+			if merged.BlockDuration == 0 {
+				merged.BlockDuration = res.BlockDuration
+			}
+			for code, n := range res.Codes {
+				if merged.Codes == nil {
+					merged.Codes = make(map[uint32]int)
+				}
+				merged.Codes[code] += n
+			}
+			for class, n := range res.Classes {
+				if merged.Classes == nil {
+					merged.Classes = make(map[string]int)
+				}
+				merged.Classes[class] += n
+			}
+		}
+		if res.Height > c.lastHeights[req.AgentID] {
+			c.lastHeights[req.AgentID] = res.Height
+		}
+	}
+	c.flush()
+	encode(w, struct{}{})
+}
+
+func (c *Coordinator) handleDone(w http.ResponseWriter, r *http.Request) {
+	var req DoneRequest
+	if !decode(w, r, &req) || !c.validAgent(w, req.AgentID) {
+		return
+	}
+
+	c.mu.Lock()
+	if c.done[req.AgentID] {
+		c.mu.Unlock()
+		encode(w, struct{}{})
+		return
+	}
+	c.done[req.AgentID] = true
+	c.numDone++
+	if req.Error != "" {
+		c.errs = append(c.errs, fmt.Sprintf("agent %d(%s): %s", req.AgentID, c.names[req.AgentID], req.Error))
+	}
+	c.flush()
+	ready := c.readyBarriers()
+	if c.numDone == c.cfg.NumAgents {
+		close(c.finished)
+	}
+	c.mu.Unlock()
+	c.releaseBarriers(r.Context(), ready)
+	encode(w, struct{}{})
+}
+
+// readyBarriers returns the barriers at which every agent which is not done has arrived,
+// marking them as being released. c.mu must be held.
+func (c *Coordinator) readyBarriers() []*barrier {
+	var ready []*barrier
+	for _, b := range c.barriers {
+		if b.releasing || b.arrived+c.numDone < c.cfg.NumAgents {
+			continue
+		}
+		b.releasing = true
+		ready = append(ready, b)
+	}
+	return ready
+}
+
+// releaseBarriers releases the given barriers at the height after the start delay. It queries the
+// latest height, so c.mu must not be held.
+func (c *Coordinator) releaseBarriers(ctx context.Context, barriers []*barrier) {
+	for _, b := range barriers {
+		height, err := c.cfg.LatestHeight(ctx)
+		if err != nil {
+			b.err = fmt.Errorf("get latest height: %w", err)
+		}
+		b.height = height + c.cfg.StartDelay
+		close(b.released)
+	}
+}
+
+// flush passes the merged results to the sink up to the lowest height every agent
+// which is not done has reported, since the agents report in the order of the heights.
+func (c *Coordinator) flush() {
+	upTo := int64(math.MaxInt64)
+	for id, h := range c.lastHeights {
+		if !c.done[id] && h < upTo {
+			upTo = h
+		}
+	}
+
+	var heights []int64
+	for h := range c.pending {
+		if h <= upTo {
+			heights = append(heights, h)
+		}
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	for _, h := range heights {
+		if c.sinkErr == nil {
+			c.sinkErr = c.cfg.Sink(*c.pending[h])
+		}
+		delete(c.pending, h)
+	}
+}
+
+func (c *Coordinator) validAgent(w http.ResponseWriter, id int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if id < 0 || id >= len(c.taken) || !c.taken[id] {
+		http.Error(w, fmt.Sprintf("unknown agent: %d", id), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		http.Error(w, fmt.Sprintf("decode request: %s", err), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func encode(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
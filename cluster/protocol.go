@@ -0,0 +1,73 @@
+// Package cluster distributes the scenarios of a stress test over several agents.
+//
+// Agents register with the coordinator over HTTP and receive the scenarios along with
+// their share of the load and a disjoint set of accounts. Before every scenario, the agents
+// wait for each other and start at the same block height. Every agent reports the result of
+// every block, and the coordinator merges them into one result per block.
+package cluster
+
+import (
+	"time"
+
+	"github.com/b-harvest/modules-test-tool/load"
+	"github.com/b-harvest/modules-test-tool/scenario"
+)
+
+const (
+	PathRegister = "/register"
+	PathStart    = "/start"
+	PathResults  = "/results"
+	PathDone     = "/done"
+)
+
+// RegisterRequest registers an agent with the coordinator.
+type RegisterRequest struct {
+	Name string `json:"name"`
+}
+
+// Assignment is what an agent runs. The accounts of its scenarios are replaced with its own.
+type Assignment struct {
+	AgentID   int                 `json:"agent_id"`
+	Share     load.Share          `json:"share"`
+	Seed      int64               `json:"seed"`
+	Scenarios []scenario.Scenario `json:"scenarios"`
+}
+
+// StartRequest is sent by an agent when it is ready to run a scenario.
+type StartRequest struct {
+	AgentID  int `json:"agent_id"`
+	Scenario int `json:"scenario"`
+}
+
+// StartResponse is returned once every agent is ready to run the scenario.
+type StartResponse struct {
+	Height int64 `json:"height"`
+}
+
+// BlockResult is the result of a single block. The coordinator sums up the broadcast
+// and planned txs and the codes of every agent.
+type BlockResult struct {
+	Scenario      string        `json:"scenario"`
+	Height        int64         `json:"height"`
+	BlockTime     time.Time     `json:"block_time"`
+	BlockDuration time.Duration `json:"block_duration"`
+	Broadcast     int           `json:"num_broadcast_txs"`
+	Committed     int           `json:"num_committed_txs"`
+	Planned       int           `json:"planned_num_broadcast_txs"`
+	// Codes is the number of broadcast responses by code.
+	Codes map[uint32]int `json:"codes,omitempty"`
+	// Classes is the number of broadcast responses by error class.
+	Classes map[string]int `json:"classes,omitempty"`
+}
+
+// ResultsRequest reports the results of an agent, in the order of their heights.
+type ResultsRequest struct {
+	AgentID int           `json:"agent_id"`
+	Results []BlockResult `json:"results"`
+}
+
+// DoneRequest is sent by an agent when it has run every scenario or has failed.
+type DoneRequest struct {
+	AgentID int    `json:"agent_id"`
+	Error   string `json:"error,omitempty"`
+}
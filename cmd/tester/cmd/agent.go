@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/b-harvest/modules-test-tool/client"
+	"github.com/b-harvest/modules-test-tool/cluster"
+	"github.com/b-harvest/modules-test-tool/config"
+	"github.com/b-harvest/modules-test-tool/errclass"
+	"github.com/b-harvest/modules-test-tool/load"
+	"github.com/b-harvest/modules-test-tool/sink"
+	"github.com/b-harvest/modules-test-tool/tx"
+)
+
+const flagName = "name"
+
+func AgentCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "agent [coordinator-url]",
+		Short: "run a share of the stress test assigned by a coordinator",
+		Args:  cobra.ExactArgs(1),
+		Long: `Register with a coordinator and run the share of the scenarios it assigns.
+
+Every agent sends its share of the planned txs of every round from its own accounts, starting every scenario
+at the block height given by the coordinator, and reports the result of every block to the coordinator.
+The agents must use the same mnemonics in their config.toml as the coordinator.
+
+Example: $ tester agent http://localhost:7070 --name agent-1
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			err := SetLogger(logLevel)
+			if err != nil {
+				return fmt.Errorf("set logger: %w", err)
+			}
+
+			interrupted, stopNotify := notifyInterrupt()
+			defer stopNotify()
+
+			name, err := cmd.Flags().GetString(flagName)
+			if err != nil {
+				return err
+			}
+			if name == "" {
+				hostname, err := os.Hostname()
+				if err != nil {
+					return fmt.Errorf("get hostname: %w", err)
+				}
+				name = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+			}
+
+			cfg, err := config.Read(config.DefaultConfigPath)
+			if err != nil {
+				return fmt.Errorf("read config: %w", err)
+			}
+
+			client, err := client.NewClient(cfg.RPC.Address, cfg.GRPC.Address)
+			if err != nil {
+				return fmt.Errorf("new client: %w", err)
+			}
+			defer client.Stop() // nolint: errcheck
+
+			chainID, err := client.RPC.GetNetworkChainID(ctx)
+			if err != nil {
+				return err
+			}
+
+			gasLimit := uint64(cfg.Custom.GasLimit)
+			fees := sdk.NewCoins(sdk.NewCoin(cfg.Custom.FeeDenom, sdk.NewInt(cfg.Custom.FeeAmount)))
+			memo := cfg.Custom.Memo
+			seqs := tx.NewSequences(client.GRPC)
+			tx := tx.NewTransaction(client, chainID, gasLimit, fees, memo)
+
+			m, stopMetrics, err := startMetrics(cmd)
+			if err != nil {
+				return err
+			}
+			defer stopMetrics()
+			stopTracing, err := startTracing(cmd)
+			if err != nil {
+				return err
+			}
+			defer stopTracing()
+			tx.Metrics = m
+
+			run := workloadRun{ID: sink.NewRunID(time.Now())}
+			sampler, err := startMempoolSampler(ctx, cmd, run, m)
+			if err != nil {
+				return err
+			}
+			defer sampler.Close()
+
+			gas, err := newGasTracker(cmd, client)
+			if err != nil {
+				return err
+			}
+			stopGas, err := startGasEstimation(cmd, run, tx, gas)
+			if err != nil {
+				return err
+			}
+			defer stopGas()
+			stopConfirmations, err := startConfirmations(ctx, cmd, run, tx, seqs)
+			if err != nil {
+				return err
+			}
+			defer stopConfirmations()
+			if err := setGasPrices(cmd, run, tx, cfg.Custom.GasPrices); err != nil {
+				return err
+			}
+
+			policies, err := errorPolicies(cmd, errclass.DefaultPolicies())
+			if err != nil {
+				return err
+			}
+
+			agent := cluster.NewAgent(args[0])
+			log.Info().Str("name", name).Str("coordinator", args[0]).Msg("waiting for the other agents to register")
+			asg, err := agent.Register(ctx, name)
+			if err != nil {
+				return fmt.Errorf("register: %w", err)
+			}
+			log.Info().Int("agent-id", asg.AgentID).Int("agents", asg.Share.Of).Int64("seed", asg.Seed).Msg("registered")
+
+			r := &stressRunner{
+				client: client,
+				cfg:    cfg,
+				tx:     tx,
+				seqs:   seqs,
+				bw:     newBlockWatcher(client, m, chainID),
+				seed:   asg.Seed,
+				report: func(ctx context.Context, res roundResult) error {
+					return agent.Report(ctx, cluster.BlockResult{
+						Scenario:      res.scenario,
+						Height:        res.height,
+						BlockTime:     res.blockTime,
+						BlockDuration: res.blockDuration,
+						Broadcast:     res.sent,
+						Committed:     res.committed,
+						Planned:       res.planned,
+						Codes:         res.codes,
+						Classes:       res.classes,
+					})
+				},
+				share: asg.Share,
+				start: agent.Start,
+
+				interrupted: interrupted,
+				summary:     load.NewSummary(),
+				gas:         gas,
+				mempool:     sampler.Watch(client, chainID),
+				policies:    policies,
+			}
+
+			runErr := r.runScenarios(ctx, asg.Scenarios, 0, 0)
+			if err := agent.Done(ctx, runErr); err != nil {
+				log.Err(err).Msg("failed to report being done")
+			}
+			if err := r.summary.Print(os.Stdout); err != nil {
+				log.Err(err).Msg("failed to print summary")
+			}
+			if gas != nil {
+				if err := reportGas(cmd, run.ID, gas); err != nil {
+					log.Err(err).Msg("failed to report gas")
+				}
+			}
+			return runErr
+		},
+	}
+	cmd.Flags().String(flagName, "", "name of the agent; defaults to the hostname and the process id")
+	addMetricsFlag(cmd)
+	addTracingFlags(cmd)
+	addMempoolFlags(cmd)
+	addGasFlags(cmd)
+	addGasEstimationFlags(cmd)
+	addBroadcastFlags(cmd)
+	addFeeFlags(cmd)
+	addErrorPolicyFlag(cmd, errclass.DefaultPolicies())
+	return cmd
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// DefaultCheckpointPath is the path of the checkpoint written by the stress test.
+const DefaultCheckpointPath = "./stress_checkpoint.json"
+
+// checkpoint is where a stress test continues when it is run with --resume.
+type checkpoint struct {
+	ScenarioFile string `json:"scenario_file"`
+	Scenario     int    `json:"scenario"`
+	ScenarioName string `json:"scenario_name"`
+	Round        int    `json:"round"`
+	Seed         int64  `json:"seed"`
+}
+
+// readCheckpoint reads the checkpoint of the given path.
+func readCheckpoint(path string) (checkpoint, error) {
+	var cp checkpoint
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		return cp, fmt.Errorf("read file: %w", err)
+	}
+	if err := json.Unmarshal(bz, &cp); err != nil {
+		return cp, fmt.Errorf("decode checkpoint: %w", err)
+	}
+	return cp, nil
+}
+
+// writeCheckpoint replaces the checkpoint of the given path, so that it is never left partially written.
+func writeCheckpoint(path string, cp checkpoint) error {
+	bz, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode checkpoint: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, bz, 0644); err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("rename file: %w", err)
+	}
+	return nil
+}
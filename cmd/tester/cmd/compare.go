@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/b-harvest/modules-test-tool/report"
+	"github.com/b-harvest/modules-test-tool/sink"
+)
+
+const (
+	flagBaselineRun        = "baseline-run"
+	flagCandidateRun       = "candidate-run"
+	flagBaselineInclusion  = "baseline-inclusion"
+	flagCandidateInclusion = "candidate-inclusion"
+	flagThreshold          = "threshold"
+	flagAlpha              = "alpha"
+)
+
+func CompareCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compare [baseline] [candidate]",
+		Short: "compare the results of a candidate run with a baseline run and fail on regressions",
+		Args:  cobra.ExactArgs(2),
+		Long: `Compare the results of a candidate run with the results of a baseline run, aligned by scenario.
+
+The baseline and the candidate are result.csv files written by the block-synchronized workloads;
+the result_codes.csv and result_classes.csv files next to each of them are read too if they exist. As the results of many runs
+are appended to the same file, the last run of each file is compared unless a run id is given.
+
+The throughput, the block time and the commit ratio of every scenario are compared, along with the
+p50/p90/p99 inclusion latencies of the compared runs if the inclusion.csv files of both are given. Every change is
+tested for significance: the per-block throughputs and block times by Welch's t-test, the commit
+ratios by the two-proportion z-test and the latencies by the Mann-Whitney U test.
+
+A change in the worse direction by more than the threshold is a regression, unless it is not
+significant at the given level. The command exits with a non-zero status if there is a regression.
+
+Example: $ tester compare baseline/result.csv result.csv --threshold 0.05
+Example: $ tester compare result.csv result.csv --baseline-run 20220101T000000Z-0a1b2c3d --candidate-run 20220102T000000Z-4e5f6a7b
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			err := SetLogger(logLevel)
+			if err != nil {
+				return fmt.Errorf("set logger: %w", err)
+			}
+
+			threshold, err := cmd.Flags().GetFloat64(flagThreshold)
+			if err != nil {
+				return err
+			}
+			alpha, err := cmd.Flags().GetFloat64(flagAlpha)
+			if err != nil {
+				return err
+			}
+			baselineRun, err := cmd.Flags().GetString(flagBaselineRun)
+			if err != nil {
+				return err
+			}
+			candidateRun, err := cmd.Flags().GetString(flagCandidateRun)
+			if err != nil {
+				return err
+			}
+			baselineInclusion, err := cmd.Flags().GetString(flagBaselineInclusion)
+			if err != nil {
+				return err
+			}
+			candidateInclusion, err := cmd.Flags().GetString(flagCandidateInclusion)
+			if err != nil {
+				return err
+			}
+			if (baselineInclusion == "") != (candidateInclusion == "") {
+				return fmt.Errorf("both --%s and --%s must be given to compare the latencies", flagBaselineInclusion, flagCandidateInclusion)
+			}
+
+			baseline, baselineRun, err := readRun(args[0], baselineRun)
+			if err != nil {
+				return fmt.Errorf("read baseline: %w", err)
+			}
+			candidate, candidateRun, err := readRun(args[1], candidateRun)
+			if err != nil {
+				return fmt.Errorf("read candidate: %w", err)
+			}
+
+			c := report.Compare(baseline, candidate, report.CompareOptions{Threshold: threshold, Alpha: alpha})
+			if baselineInclusion != "" {
+				b, err := readLatencies(baselineInclusion, baselineRun)
+				if err != nil {
+					return err
+				}
+				s, err := readLatencies(candidateInclusion, candidateRun)
+				if err != nil {
+					return err
+				}
+				c.CompareLatencies(b, s)
+			}
+			if len(c.Deltas) == 0 {
+				return fmt.Errorf("the baseline and the candidate have no scenario in common")
+			}
+			if err := c.Print(os.Stdout); err != nil {
+				return fmt.Errorf("print comparison: %w", err)
+			}
+
+			if regressions := c.Regressions(); len(regressions) > 0 {
+				return fmt.Errorf("%d regressions beyond the %.1f%% threshold", len(regressions), threshold*100)
+			}
+			log.Info().Msg("no regression")
+			return nil
+		},
+	}
+	cmd.Flags().String(flagBaselineRun, "", "id of the baseline run in its result file; the last run if empty")
+	cmd.Flags().String(flagCandidateRun, "", "id of the candidate run in its result file; the last run if empty")
+	cmd.Flags().String(flagBaselineInclusion, "", "inclusion.csv of the baseline run to compare the latencies")
+	cmd.Flags().String(flagCandidateInclusion, "", "inclusion.csv of the candidate run to compare the latencies")
+	cmd.Flags().Float64(flagThreshold, 0.05, "relative change in the worse direction from which a significant change is a regression")
+	cmd.Flags().Float64(flagAlpha, 0.05, "significance level of the tests")
+	return cmd
+}
+
+// readRun reads the results of a run from a result file and the codes and classes files next to it,
+// and returns them along with the id of the run, which is the last one of the file if none is given.
+func readRun(path, runID string) (*report.Report, string, error) {
+	var res report.Results
+	if err := readResultFile(&res, path); err != nil {
+		return nil, "", err
+	}
+	for _, sidePath := range []string{sink.CodesPath(path), sink.ClassesPath(path)} {
+		if sidePath == path {
+			continue
+		}
+		if _, err := os.Stat(sidePath); err == nil {
+			if err := readResultFile(&res, sidePath); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	if len(res.Blocks) == 0 {
+		return nil, "", fmt.Errorf("%s has no results", path)
+	}
+	if runID == "" {
+		runID = res.Blocks[len(res.Blocks)-1].RunID
+	}
+	run := res.Run(runID)
+	if len(run.Blocks) == 0 {
+		return nil, "", fmt.Errorf("%s has no results of run %s", path, runID)
+	}
+	log.Info().Str("run-id", runID).Int("blocks", len(run.Blocks)).Msgf("read %s", path)
+	return report.Build(run), runID, nil
+}
+
+// readLatencies reads the inclusion latencies of the run of the given id from an inclusion file.
+func readLatencies(path, runID string) ([]time.Duration, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open inclusion file: %w", err)
+	}
+	defer f.Close()
+	latencies, err := report.ReadLatencies(f, runID)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	if len(latencies) == 0 {
+		return nil, fmt.Errorf("%s has no committed txs of run %s", path, runID)
+	}
+	return latencies, nil
+}
@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	sdktx "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	tmtypes "github.com/tendermint/tendermint/types"
+
+	"github.com/b-harvest/modules-test-tool/client"
+	"github.com/b-harvest/modules-test-tool/load"
+	"github.com/b-harvest/modules-test-tool/sink"
+	"github.com/b-harvest/modules-test-tool/tx"
+)
+
+const (
+	flagBroadcastMode  = "broadcast-mode"
+	flagConfirmTimeout = "confirm-timeout"
+)
+
+// The broadcast modes of the txs.
+const (
+	broadcastModeSync  = "sync"
+	broadcastModeAsync = "async"
+	broadcastModeBlock = "block"
+)
+
+// confirmInterval is the interval the pending txs are checked for their timeout.
+const confirmInterval = time.Second
+
+// confirmSubscribers is the number of the confirmation trackers, which names their subscriptions apart.
+var confirmSubscribers uint32
+
+// confirmHeader is the header of confirm.csv, which records the confirmation of every async broadcast tx.
+var confirmHeader = []string{
+	"run_id",
+	"chain_id",
+	"tx_hash",
+	"broadcast_time",
+	"status",
+	"height",
+	"code",
+	"codespace",
+	"confirm_time",
+	"latency",
+}
+
+// addBroadcastFlags adds the flags of the broadcast mode of the txs. The commands with the tx flags of the SDK
+// keep its broadcast mode flag.
+func addBroadcastFlags(cmd *cobra.Command) {
+	if cmd.Flags().Lookup(flagBroadcastMode) == nil {
+		cmd.Flags().String(flagBroadcastMode, broadcastModeSync, "broadcast mode of the txs: sync to wait for CheckTx, async to follow them until committed, or block to wait for the commit")
+	}
+	cmd.Flags().Duration(flagConfirmTimeout, time.Minute, "time an async broadcast tx is waited for to be committed before it times out")
+}
+
+// broadcastMode returns the broadcast mode of the flag.
+func broadcastMode(cmd *cobra.Command) (sdktx.BroadcastMode, error) {
+	mode, err := cmd.Flags().GetString(flagBroadcastMode)
+	if err != nil {
+		return sdktx.BroadcastMode_BROADCAST_MODE_UNSPECIFIED, err
+	}
+	switch mode {
+	case broadcastModeSync:
+		return sdktx.BroadcastMode_BROADCAST_MODE_SYNC, nil
+	case broadcastModeAsync:
+		return sdktx.BroadcastMode_BROADCAST_MODE_ASYNC, nil
+	case broadcastModeBlock:
+		return sdktx.BroadcastMode_BROADCAST_MODE_BLOCK, nil
+	default:
+		return sdktx.BroadcastMode_BROADCAST_MODE_UNSPECIFIED, fmt.Errorf("unknown broadcast mode %q; must be one of %s, %s or %s",
+			mode, broadcastModeSync, broadcastModeAsync, broadcastModeBlock)
+	}
+}
+
+// startConfirmations sets the broadcast mode of the tx, and when the txs are broadcast asynchronously,
+// follows them until they are committed or time out. The sequences of the accounts of the txs which time out
+// are queried again from seqs. It returns the function waiting for the pending txs and reporting their
+// confirmations.
+func startConfirmations(ctx context.Context, cmd *cobra.Command, run workloadRun, t *tx.Transaction, seqs *tx.Sequences) (func(), error) {
+	mode, tracker, stop, err := startConfirmationTracker(ctx, cmd, run, t.Client, t.ChainID, seqs)
+	if err != nil {
+		return nil, err
+	}
+	t.BroadcastMode, t.Confirmations = mode, tracker
+	return stop, nil
+}
+
+// startConfirmationTracker returns the broadcast mode of the flag and, in async mode, a tracker following the
+// broadcast txs through the committed txs of the chain until ctx is done. The tracker is nil otherwise.
+// The txs which time out have the sequences of their accounts queried again from seqs, if any.
+func startConfirmationTracker(ctx context.Context, cmd *cobra.Command, run workloadRun, c *client.Client, chainID string, seqs *tx.Sequences) (sdktx.BroadcastMode, *load.ConfirmationTracker, func(), error) {
+	mode, err := broadcastMode(cmd)
+	if err != nil {
+		return mode, nil, nil, err
+	}
+	timeout, err := cmd.Flags().GetDuration(flagConfirmTimeout)
+	if err != nil {
+		return mode, nil, nil, err
+	}
+	if mode != sdktx.BroadcastMode_BROADCAST_MODE_ASYNC {
+		return mode, nil, func() {}, nil
+	}
+	if timeout <= 0 {
+		return mode, nil, nil, fmt.Errorf("confirm timeout must be positive, got %s", timeout)
+	}
+
+	// The client shares its subscription to the committed txs with the inclusion tracker and, when the
+	// destinations of a source chain are run concurrently, with the tracker of every destination.
+	subscriber := fmt.Sprintf("tester-confirm-%d", atomic.AddUint32(&confirmSubscribers, 1))
+	events, err := c.RPC.SubscribeTxs(ctx, subscriber, 10000)
+	if err != nil {
+		return mode, nil, nil, fmt.Errorf("subscribe txs: %w", err)
+	}
+
+	tracker := load.NewConfirmationTracker(timeout)
+	go func() {
+		ticker := time.NewTicker(confirmInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-events:
+				if !ok {
+					log.Warn().Msg("tx subscription has been closed; the pending txs are looked up when they time out")
+					events = nil
+					continue
+				}
+				data, ok := ev.Data.(tmtypes.EventDataTx)
+				if !ok {
+					continue
+				}
+				tracker.CommitResult(fmt.Sprintf("%X", tmtypes.Tx(data.Tx).Hash()), data.Height, data.Result.Code, data.Result.Codespace, time.Now())
+			case now := <-ticker.C:
+				expireConfirmations(ctx, c, tracker, seqs, tracker.Expired(now), now)
+				tracker.PruneEarly(now.Add(-earlyCommitTTL))
+			}
+		}
+	}()
+
+	log.Info().Str("confirm-timeout", timeout.String()).Msg("broadcasting the txs asynchronously, following them until committed")
+	return mode, tracker, func() {
+		if err := reportConfirmations(c, run.ID, chainID, tracker, seqs, timeout); err != nil {
+			log.Err(err).Msg("failed to report confirmations")
+		}
+	}, nil
+}
+
+// expireConfirmations looks up the expired txs which have not been seen committed, in case their events
+// have been missed, and times out the ones not found. A tx which times out has most likely been rejected by
+// CheckTx, leaving a gap in the sequences of its account, so the account is queried again.
+func expireConfirmations(ctx context.Context, c *client.Client, tracker *load.ConfirmationTracker, seqs *tx.Sequences, expired []load.Inclusion, now time.Time) {
+	timeOut := func(in load.Inclusion) {
+		tracker.TimeOut(in.Hash, now)
+		if seqs != nil && in.Account != "" {
+			seqs.Invalidate(in.Account)
+			log.Debug().Str("hash", in.Hash).Str("addr", in.Account).Msg("tx timed out; querying the account sequence again")
+		}
+	}
+	for _, in := range expired {
+		if ctx.Err() != nil {
+			return
+		}
+		b, err := hex.DecodeString(in.Hash)
+		if err != nil {
+			timeOut(in)
+			continue
+		}
+		res, err := c.RPC.Tx(ctx, b, false)
+		if err != nil {
+			if !strings.Contains(err.Error(), "not found") {
+				log.Debug().Err(err).Str("hash", in.Hash).Msg("failed to look up tx")
+			}
+			timeOut(in)
+			continue
+		}
+		tracker.CommitResult(in.Hash, res.Height, res.TxResult.Code, res.TxResult.Codespace, now)
+	}
+}
+
+// reportConfirmations waits for the pending txs to be committed or time out, appends the confirmation
+// of every tx to confirm.csv and prints their counts.
+func reportConfirmations(c *client.Client, runID, chainID string, tracker *load.ConfirmationTracker, seqs *tx.Sequences, timeout time.Duration) error {
+	if pending := tracker.Pending(); pending > 0 {
+		log.Info().Int("pending-txs", pending).Str("timeout", timeout.String()).Msg("waiting for the async broadcast txs to be committed")
+		deadline := time.Now().Add(timeout + confirmInterval)
+		for tracker.Pending() > 0 && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+		}
+		// The txs are left pending only if the tracker has stopped early; look them up once more.
+		now := time.Now()
+		expireConfirmations(context.Background(), c, tracker, seqs, tracker.Expired(now.Add(timeout)), now)
+	}
+
+	f, w, err := sink.OpenCSV("confirm.csv", confirmHeader)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for _, in := range tracker.Inclusions() {
+		row := []string{
+			runID,
+			chainID,
+			in.Hash,
+			in.BroadcastAt.Format(time.RFC3339Nano),
+			in.ConfirmStatus(),
+			"",
+			"",
+			in.Codespace,
+			"",
+			"",
+		}
+		switch {
+		case in.Committed():
+			row[5] = strconv.FormatInt(in.Height, 10)
+			row[6] = strconv.FormatUint(uint64(in.Code), 10)
+			row[8] = in.CommittedAt.Format(time.RFC3339Nano)
+			row[9] = in.Latency().String()
+		case in.TimedOut():
+			row[8] = in.TimedOutAt.Format(time.RFC3339Nano)
+		}
+		if err := sink.WriteCSVRow(w, row); err != nil {
+			return err
+		}
+	}
+	return tracker.Counts().Print(os.Stdout)
+}
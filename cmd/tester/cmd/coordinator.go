@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/b-harvest/modules-test-tool/client"
+	"github.com/b-harvest/modules-test-tool/cluster"
+	"github.com/b-harvest/modules-test-tool/config"
+	"github.com/b-harvest/modules-test-tool/scenario"
+)
+
+const (
+	flagListen     = "listen"
+	flagAgents     = "agents"
+	flagStartDelay = "start-delay"
+)
+
+func CoordinatorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "coordinator",
+		Short: "distribute the stress test over agents and merge their results",
+		Args:  cobra.NoArgs,
+		Long: `Distribute the scenarios of the scenario file over a number of agents and merge their results into result.csv.
+
+Once every agent has registered, each of them is assigned an equal share of the planned txs of every round and a disjoint
+set of the accounts of every scenario. The agents start every scenario together, a few blocks after the latest one.
+
+Example: $ tester coordinator --agents 3 --listen :7070 --scenario ./scenario.toml
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			err := SetLogger(logLevel)
+			if err != nil {
+				return fmt.Errorf("set logger: %w", err)
+			}
+
+			interrupted, stopNotify := notifyInterrupt()
+			defer stopNotify()
+			go func() {
+				select {
+				case <-interrupted:
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+
+			run, err := startRun(cmd, args)
+			if err != nil {
+				return fmt.Errorf("start run: %w", err)
+			}
+
+			cfg, err := config.Read(config.DefaultConfigPath)
+			if err != nil {
+				return fmt.Errorf("read config: %w", err)
+			}
+
+			client, err := client.NewClient(cfg.RPC.Address, cfg.GRPC.Address)
+			if err != nil {
+				return fmt.Errorf("new client: %w", err)
+			}
+			defer client.Stop() // nolint: errcheck
+
+			scenarioPath, err := cmd.Flags().GetString(flagScenario)
+			if err != nil {
+				return err
+			}
+			scenarioFile, err := scenario.Read(scenarioPath)
+			if err != nil {
+				return fmt.Errorf("read scenario: %w", err)
+			}
+			if err := scenarioFile.Validate(numAccounts(cfg.Custom)); err != nil {
+				return fmt.Errorf("invalid scenario: %w", err)
+			}
+
+			listen, err := cmd.Flags().GetString(flagListen)
+			if err != nil {
+				return err
+			}
+			numAgents, err := cmd.Flags().GetInt(flagAgents)
+			if err != nil {
+				return err
+			}
+			startDelay, err := cmd.Flags().GetInt64(flagStartDelay)
+			if err != nil {
+				return err
+			}
+
+			chainID, err := client.RPC.GetNetworkChainID(ctx)
+			if err != nil {
+				return err
+			}
+			results, err := openResultSink(cmd, run, chainID)
+			if err != nil {
+				return err
+			}
+			defer results.Close()
+			report := sinkReporter(results)
+
+			c, err := cluster.NewCoordinator(cluster.CoordinatorConfig{
+				NumAgents:   numAgents,
+				NumAccounts: numAccounts(cfg.Custom),
+				Scenarios:   scenarioFile.Scenarios,
+				Seed:        run.Seed,
+				StartDelay:  startDelay,
+				LatestHeight: func(ctx context.Context) (int64, error) {
+					st, err := client.RPC.Status(ctx)
+					if err != nil {
+						return 0, err
+					}
+					return st.SyncInfo.LatestBlockHeight, nil
+				},
+				Sink: func(res cluster.BlockResult) error {
+					log.Info().
+						Int64("height", res.Height).
+						Str("block-duration", res.BlockDuration.String()).
+						Int("broadcast-txs", res.Broadcast).
+						Int("committed-txs", res.Committed).
+						Int("planned-txs", res.Planned).
+						Msg("block committed")
+					return report(context.Background(), roundResult{
+						scenario:      res.Scenario,
+						height:        res.Height,
+						blockTime:     res.BlockTime,
+						planned:       res.Planned,
+						sent:          res.Broadcast,
+						committed:     res.Committed,
+						blockDuration: res.BlockDuration,
+						codes:         res.Codes,
+						classes:       res.Classes,
+					})
+				},
+			})
+			if err != nil {
+				return fmt.Errorf("new coordinator: %w", err)
+			}
+
+			ln, err := net.Listen("tcp", listen)
+			if err != nil {
+				return fmt.Errorf("listen: %w", err)
+			}
+			srv := &http.Server{Handler: c}
+			go func() {
+				if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					log.Err(err).Msg("failed to serve")
+				}
+			}()
+			defer srv.Close()
+			log.Info().Str("addr", ln.Addr().String()).Int("agents", numAgents).Msg("waiting for the agents")
+
+			if err := c.Wait(ctx); err != nil {
+				return fmt.Errorf("run agents: %w", err)
+			}
+			log.Info().Msg("every agent is done")
+			return nil
+		},
+	}
+	cmd.Flags().String(flagScenario, scenario.DefaultScenarioPath, "path to the scenario file")
+	cmd.Flags().String(flagListen, ":7070", "address to serve the agents on")
+	cmd.Flags().Int(flagAgents, 1, "number of agents to wait for")
+	cmd.Flags().Int64(flagStartDelay, 2, "number of blocks after the latest one at which the agents start a scenario")
+	addSeedFlag(cmd)
+	addOutputFlags(cmd)
+	return cmd
+}
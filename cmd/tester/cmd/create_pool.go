@@ -7,7 +7,6 @@ import (
 	"github.com/b-harvest/modules-test-tool/client"
 	"github.com/b-harvest/modules-test-tool/config"
 	"github.com/b-harvest/modules-test-tool/tx"
-	"github.com/b-harvest/modules-test-tool/wallet"
 
 	sdktypes "github.com/cosmos/cosmos-sdk/types"
 
@@ -58,11 +57,17 @@ func CreatePoolsCmd() *cobra.Command {
 				return err
 			}
 
-			accAddr, privKey, err := wallet.RecoverAccountFromMnemonic(cfg.Custom.Mnemonics[0], "")
+			accAddr, accSigner, err := accountSigner(cfg.Custom, 0)
 			if err != nil {
 				return err
 			}
 
+			mode, err := broadcastMode(cmd)
+			if err != nil {
+				return err
+			}
+			seqs := tx.NewSequences(client.GRPC)
+
 			pools := []struct {
 				poolTypeId   uint32
 				denomPairs   []string
@@ -106,29 +111,23 @@ func CreatePoolsCmd() *cobra.Command {
 					}
 				}
 
-				account, err := client.GRPC.GetBaseAccountInfo(ctx, accAddr)
-				if err != nil {
-					return fmt.Errorf("failed to get account information: %s", err)
-				}
-
-				accSeq := account.GetSequence()
-				accNum := account.GetAccountNumber()
-
 				gasLimit := uint64(cfg.Custom.GasLimit)
 				fees := sdktypes.NewCoins(sdktypes.NewCoin(cfg.Custom.FeeDenom, sdktypes.NewInt(cfg.Custom.FeeAmount)))
 				memo := cfg.Custom.Memo
 
 				tx := tx.NewTransaction(client, chainID, gasLimit, fees, memo)
+				tx.BroadcastMode = mode
+				acc := newAccountTxs(tx, seqs, accAddr, accSigner)
 
 				ctx, cancel := context.WithCancel(ctx)
 				defer cancel()
 
-				txBytes, err := tx.Sign(ctx, accSeq, accNum, privKey, msgs...)
+				stx, err := acc.sign(ctx, msgs...)
 				if err != nil {
 					return fmt.Errorf("failed to sign and broadcast: %s", err)
 				}
 
-				resp, err := client.GRPC.BroadcastTx(ctx, txBytes)
+				resp, err := acc.broadcast(ctx, stx, msgs...)
 				if err != nil {
 					return fmt.Errorf("failed to broadcast transaction: %s", err)
 				}
@@ -146,5 +145,6 @@ func CreatePoolsCmd() *cobra.Command {
 			return nil
 		},
 	}
+	cmd.Flags().String(flagBroadcastMode, broadcastModeSync, "broadcast mode of the txs: sync to wait for CheckTx, async not to wait, or block to wait for the commit")
 	return cmd
 }
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/b-harvest/modules-test-tool/dashboard"
+)
+
+const (
+	flagTUI    = "tui"
+	flagTUILog = "tui-log"
+
+	dashboardLogLines = 12
+	dashboardInterval = 500 * time.Millisecond
+	dashboardWidth    = 100
+)
+
+// addDashboardFlags adds the flags of the live terminal dashboard to a workload command.
+func addDashboardFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool(flagTUI, false, "show a live dashboard of the run on the terminal instead of the logs")
+	cmd.Flags().String(flagTUILog, "", "file to write the logs to while the dashboard is shown; a pane of the dashboard if empty")
+}
+
+// startDashboard shows the live dashboard of the run on stdout when enabled, sending the logs to its pane or
+// to the log file, and returns the function stopping it. The dashboard is nil if it is not enabled.
+func startDashboard(cmd *cobra.Command) (*dashboard.Dashboard, func(), error) {
+	enabled, err := cmd.Flags().GetBool(flagTUI)
+	if err != nil {
+		return nil, nil, err
+	}
+	logPath, err := cmd.Flags().GetString(flagTUILog)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !enabled {
+		return nil, func() {}, nil
+	}
+
+	logLines := dashboardLogLines
+	var logFile *os.File
+	if logPath != "" {
+		logFile, err = os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open log file: %w", err)
+		}
+		logLines = 0
+	}
+
+	d := dashboard.New("tester "+cmd.Name(), logLines)
+	logger := log.Logger
+	if logFile != nil {
+		log.Logger = log.Output(zerolog.ConsoleWriter{Out: logFile, NoColor: true})
+	} else {
+		log.Logger = log.Output(zerolog.ConsoleWriter{Out: d, NoColor: true})
+	}
+
+	width := dashboardWidth
+	if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
+		width = w
+	}
+	stop := d.Start(os.Stdout, width, dashboardInterval)
+	return d, func() {
+		stop()
+		log.Logger = logger
+		if logFile != nil {
+			logFile.Close()
+		}
+	}, nil
+}
@@ -7,8 +7,8 @@ import (
 
 	"github.com/b-harvest/modules-test-tool/client"
 	"github.com/b-harvest/modules-test-tool/config"
+	"github.com/b-harvest/modules-test-tool/scenario"
 	"github.com/b-harvest/modules-test-tool/tx"
-	"github.com/b-harvest/modules-test-tool/wallet"
 
 	sdktypes "github.com/cosmos/cosmos-sdk/types"
 
@@ -38,6 +38,17 @@ Example: $ tester d 1 100000000uatom,5000000000uusd 10 10
 				return err
 			}
 
+			run, err := startRun(cmd, args)
+			if err != nil {
+				return fmt.Errorf("start run: %w", err)
+			}
+			dash, stopDashboard, err := startDashboard(cmd)
+			if err != nil {
+				return err
+			}
+			defer stopDashboard()
+			run.Dashboard = dash
+
 			cfg, err := config.Read(config.DefaultConfigPath)
 			if err != nil {
 				return err
@@ -83,7 +94,7 @@ Example: $ tester d 1 100000000uatom,5000000000uusd 10 10
 				return err
 			}
 
-			accAddr, privKey, err := wallet.RecoverAccountFromMnemonic(cfg.Custom.Mnemonics[0], "")
+			accAddr, accSigner, err := accountSigner(cfg.Custom, 0)
 			if err != nil {
 				return err
 			}
@@ -99,44 +110,94 @@ Example: $ tester d 1 100000000uatom,5000000000uusd 10 10
 			fees := sdktypes.NewCoins(sdktypes.NewCoin(cfg.Custom.FeeDenom, sdktypes.NewInt(cfg.Custom.FeeAmount)))
 			memo := cfg.Custom.Memo
 
+			seqs := tx.NewSequences(client.GRPC)
 			tx := tx.NewTransaction(client, chainID, gasLimit, fees, memo)
 
-			for i := 0; i < round; i++ {
-				var txBytes [][]byte
+			m, stopMetrics, err := startMetrics(cmd)
+			if err != nil {
+				return err
+			}
+			defer stopMetrics()
+			stopTracing, err := startTracing(cmd)
+			if err != nil {
+				return err
+			}
+			defer stopTracing()
+			sampler, err := startMempoolSampler(ctx, cmd, run, m)
+			if err != nil {
+				return err
+			}
+			defer sampler.Close()
+			mempool := sampler.Watch(client, chainID)
+			tx.Metrics = m
+			stopGas, err := startGasEstimation(cmd, run, tx, nil)
+			if err != nil {
+				return err
+			}
+			defer stopGas()
+			stopConfirmations, err := startConfirmations(ctx, cmd, run, tx, seqs)
+			if err != nil {
+				return err
+			}
+			defer stopConfirmations()
+			if err := setGasPrices(cmd, run, tx, cfg.Custom.GasPrices); err != nil {
+				return err
+			}
 
-				account, err := client.GRPC.GetBaseAccountInfo(ctx, accAddr)
-				if err != nil {
-					return fmt.Errorf("failed to get account information: %s", err)
-				}
+			results, err := openResultSink(cmd, run, chainID)
+			if err != nil {
+				return err
+			}
+			defer results.Close()
 
-				accSeq := account.GetSequence()
-				accNum := account.GetAccountNumber()
+			policies, err := errorPolicies(cmd, singleAccountPolicies())
+			if err != nil {
+				return err
+			}
+
+			acc := newAccountTxs(tx, seqs, accAddr, accSigner)
+			for i := 0; i < round; i++ {
+				var txs []sequencedTx
 
 				for j := 0; j < txNum; j++ {
-					txByte, err := tx.Sign(ctx, accSeq, accNum, privKey, msgs...)
+					stx, err := acc.sign(ctx, msgs...)
 					if err != nil {
 						return fmt.Errorf("failed to sign and broadcast: %s", err)
 					}
-
-					accSeq = accSeq + 1
-
-					txBytes = append(txBytes, txByte)
+					txs = append(txs, stx)
 				}
 
 				log.Info().Msgf("round:%d; txNum:%d; accAddr:%s", i+1, txNum, accAddr)
 
-				for _, txByte := range txBytes {
-					resp, err := client.GRPC.BroadcastTx(ctx, txByte)
+				sr := newSingleRound(acc, policies, mempool)
+				for k, stx := range txs {
+					resp, stopRound, err := sr.broadcast(ctx, stx, msgs...)
 					if err != nil {
-						return fmt.Errorf("failed to broadcast transaction: %s", err)
+						return err
 					}
-
 					log.Info().Msgf("%s/cosmos/tx/v1beta1/txs/%s", cfg.LCD.Address, resp.TxResponse.TxHash)
+					if stopRound {
+						acc.release(txs[k+1:]...)
+						break
+					}
+				}
+				if err := recordRound(ctx, client, results, scenario.MsgTypeDeposit, txNum, sr.codes, sr.classes); err != nil {
+					return fmt.Errorf("record round: %w", err)
 				}
 			}
 
 			return nil
 		},
 	}
+	addSeedFlag(cmd)
+	addMetricsFlag(cmd)
+	addGasEstimationFlags(cmd)
+	addBroadcastFlags(cmd)
+	addFeeFlags(cmd)
+	addTracingFlags(cmd)
+	addMempoolFlags(cmd)
+	addDashboardFlags(cmd)
+	addErrorPolicyFlag(cmd, singleAccountPolicies())
+	addOutputFlags(cmd)
 	return cmd
 }
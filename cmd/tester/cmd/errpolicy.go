@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdktx "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/b-harvest/modules-test-tool/errclass"
+	"github.com/b-harvest/modules-test-tool/load"
+)
+
+const flagErrorPolicy = "error-policy"
+
+// retryBackoff is how long a tx rejected with the retry policy waits before it is broadcast again.
+const retryBackoff = 500 * time.Millisecond
+
+// addErrorPolicyFlag adds the flag overriding the given policies of the rejected txs to a workload command.
+func addErrorPolicyFlag(cmd *cobra.Command, defaults errclass.Policies) {
+	cmd.Flags().String(flagErrorPolicy, "", fmt.Sprintf(
+		"comma-separated class=policy overriding how rejected txs are handled; the policies are retry, switch_account, skip, stop_round and abort (default %s)",
+		defaults))
+}
+
+// errorPolicies returns the given policies overridden by the error policy flag.
+func errorPolicies(cmd *cobra.Command, defaults errclass.Policies) (errclass.Policies, error) {
+	s, err := cmd.Flags().GetString(flagErrorPolicy)
+	if err != nil {
+		return nil, err
+	}
+	p, err := errclass.ParsePolicies(s)
+	if err != nil {
+		return nil, fmt.Errorf("parse error policies: %w", err)
+	}
+	return defaults.Override(p), nil
+}
+
+// ratePolicies are the default policies of the rate command, which has no rounds to stop and stops sending
+// from an account whose sequence is out of sync.
+func ratePolicies() errclass.Policies {
+	return errclass.DefaultPolicies().Override(errclass.Policies{
+		errclass.ClassSequence:    errclass.PolicySwitchAccount,
+		errclass.ClassMempoolFull: errclass.PolicyRetry,
+	})
+}
+
+// singleAccountPolicies are the default policies of the workloads sending from a single account, which abort
+// when the account runs out of funds since there is no other account to switch to.
+func singleAccountPolicies() errclass.Policies {
+	return errclass.DefaultPolicies().Override(errclass.Policies{
+		errclass.ClassFunds: errclass.PolicyAbort,
+	})
+}
+
+// singleRound broadcasts the txs of a round of a single-account workload, tallies their responses and handles
+// the rejected ones by the policies of their classes.
+type singleRound struct {
+	acc      *accountTxs
+	policies errclass.Policies
+	mempool  *load.MempoolMonitor
+	codes    map[uint32]int
+	classes  errclass.Histogram
+}
+
+func newSingleRound(acc *accountTxs, policies errclass.Policies, mempool *load.MempoolMonitor) *singleRound {
+	return &singleRound{
+		acc:      acc,
+		policies: policies,
+		mempool:  mempool,
+		codes:    make(map[uint32]int),
+		classes:  make(errclass.Histogram),
+	}
+}
+
+// broadcast broadcasts a signed tx of the messages, and returns the last response and whether to stop sending
+// txs until the next round. A tx to be retried is signed again with the next sequence and broadcast once more
+// after a back-off, up to load.MaxConsecutiveFailures times. Switching the account aborts the run, as there is
+// no other account to switch to.
+func (r *singleRound) broadcast(ctx context.Context, stx sequencedTx, msgs ...sdk.Msg) (resp *sdktx.BroadcastTxResponse, stopRound bool, err error) {
+	resp, err = r.acc.broadcast(ctx, stx, msgs...)
+	for attempt := 1; ; attempt++ {
+		if err != nil {
+			return nil, false, fmt.Errorf("broadcast tx: %w", err)
+		}
+		r.codes[resp.TxResponse.Code]++
+		e := r.classes.Add(resp.TxResponse.Codespace, resp.TxResponse.Code)
+		r.mempool.RecordCode(resp.TxResponse.Code)
+		if e.Class == errclass.ClassOK {
+			return resp, false, nil
+		}
+
+		policy := r.policies.Of(e.Class)
+		log.Warn().Str("addr", r.acc.addr).Str("error", e.String()).Str("policy", string(policy)).Str("log", resp.TxResponse.RawLog).Msg("tx rejected")
+		switch policy {
+		case errclass.PolicyRetry:
+			if attempt >= load.MaxConsecutiveFailures {
+				log.Warn().Str("addr", r.acc.addr).Int("attempts", attempt).Msg("dropping the tx rejected on every attempt")
+				return resp, false, nil
+			}
+			select {
+			case <-ctx.Done():
+				return nil, false, ctx.Err()
+			case <-time.After(retryBackoff):
+			}
+			stx, err = r.acc.sign(ctx, msgs...)
+			if err != nil {
+				return nil, false, fmt.Errorf("sign tx: %w", err)
+			}
+			resp, err = r.acc.broadcast(ctx, stx, msgs...)
+		case errclass.PolicySkip:
+			return resp, false, nil
+		case errclass.PolicyStopRound:
+			return resp, true, nil
+		default:
+			return nil, false, fmt.Errorf("tx rejected with %s: %s", e, resp.TxResponse.RawLog)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/b-harvest/modules-test-tool/tx"
+)
+
+const (
+	flagGasPrices   = "gas-prices"
+	flagGasPricePer = "gas-price-per"
+)
+
+// addFeeFlags adds the flags of the gas prices of the txs to a command signing txs. The commands with
+// the tx flags of the SDK keep its gas prices flag.
+func addFeeFlags(cmd *cobra.Command) {
+	if cmd.Flags().Lookup(flagGasPrices) == nil {
+		cmd.Flags().String(flagGasPrices, "", "gas prices the fees of the txs are derived from, e.g. 0.025uatom or 0.01uatom,0.1uatom; gas_prices of the config if empty")
+	}
+	cmd.Flags().String(flagGasPricePer, tx.GasPricePerTx, "how the gas prices are picked: account to give every account one of them, or tx to price every tx")
+}
+
+// setGasPrices derives the fees of the txs from the gas prices of the flag, or the configured ones if not set.
+// The txs keep their flat fees if there are none.
+func setGasPrices(cmd *cobra.Command, run workloadRun, t *tx.Transaction, configured string) error {
+	prices, err := cmd.Flags().GetString(flagGasPrices)
+	if err != nil {
+		return err
+	}
+	per, err := cmd.Flags().GetString(flagGasPricePer)
+	if err != nil {
+		return err
+	}
+	if prices == "" {
+		prices = configured
+	}
+	if prices == "" {
+		return nil
+	}
+
+	levels, err := tx.ParseGasPrices(prices)
+	if err != nil {
+		return err
+	}
+	t.GasPrices, err = tx.NewGasPrices(levels, per, run.Seed)
+	if err != nil {
+		return err
+	}
+	log.Info().Str("gas-prices", prices).Str("per", per).Msg("deriving the fees from the gas prices")
+	return nil
+}
@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	tmtypes "github.com/tendermint/tendermint/types"
+
+	"github.com/b-harvest/modules-test-tool/client"
+	"github.com/b-harvest/modules-test-tool/load"
+	"github.com/b-harvest/modules-test-tool/sink"
+	"github.com/b-harvest/modules-test-tool/tx"
+)
+
+const (
+	flagTrackGas  = "track-gas"
+	flagGasMargin = "gas-margin"
+)
+
+// gasHeader is the header of gas.csv, which records the gas distributions of every run.
+var gasHeader = []string{
+	"run_id",
+	"msg_type",
+	"msg_num",
+	"txs",
+	"failed_txs",
+	"max_gas_wanted",
+	"min_gas_used",
+	"avg_gas_used",
+	"p50_gas_used",
+	"p90_gas_used",
+	"p99_gas_used",
+	"max_gas_used",
+	"recommended_gas_limit",
+}
+
+// addGasFlags adds the flags of gas tracking to a workload command.
+func addGasFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool(flagTrackGas, true, "collect the gas wanted and used by the committed txs from the block results")
+	cmd.Flags().Float64(flagGasMargin, 0.2, "safety margin of the recommended gas limits over the highest gas used")
+}
+
+// gasTracker collects the gas of the committed txs of the accounts of a run.
+type gasTracker struct {
+	c       *client.Client
+	decoder sdk.TxDecoder
+	stats   *load.GasStats
+
+	mu       sync.RWMutex
+	accounts map[string]bool
+}
+
+// newGasTracker creates a new gasTracker, or returns nil if gas tracking is disabled.
+func newGasTracker(cmd *cobra.Command, c *client.Client) (*gasTracker, error) {
+	track, err := cmd.Flags().GetBool(flagTrackGas)
+	if err != nil {
+		return nil, err
+	}
+	if !track {
+		return nil, nil
+	}
+	return &gasTracker{
+		c:        c,
+		decoder:  c.GetCLIContext().TxConfig.TxDecoder(),
+		stats:    load.NewGasStats(),
+		accounts: make(map[string]bool),
+	}, nil
+}
+
+// AddAccounts adds the accounts whose txs are collected.
+func (g *gasTracker) AddAccounts(addrs ...string) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, addr := range addrs {
+		g.accounts[addr] = true
+	}
+}
+
+// Collect fetches the results of the block of the given height and collects the gas of its txs
+// signed by the accounts, grouped by the type of their first message and their number of messages.
+// Their results are also counted by error class in the summary, if any.
+func (g *gasTracker) Collect(ctx context.Context, height int64, txs tmtypes.Txs, summary *load.Summary) error {
+	if g == nil || len(txs) == 0 {
+		return nil
+	}
+	res, err := g.c.RPC.BlockResults(ctx, &height)
+	if err != nil {
+		return fmt.Errorf("get block results: %w", err)
+	}
+	if len(res.TxsResults) != len(txs) {
+		return fmt.Errorf("block %d has %d txs but %d results", height, len(txs), len(res.TxsResults))
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	for i, txBytes := range txs {
+		tx, err := g.decoder(txBytes)
+		if err != nil {
+			log.Debug().Err(err).Msg("failed to decode committed tx")
+			continue
+		}
+		msgs := tx.GetMsgs()
+		if len(msgs) == 0 {
+			continue
+		}
+		signers := msgs[0].GetSigners()
+		if len(signers) == 0 || !g.accounts[signers[0].String()] {
+			continue
+		}
+		r := res.TxsResults[i]
+		if summary != nil {
+			summary.AddDelivered(r.Codespace, r.Code)
+		}
+		g.stats.Add(load.GasKey{MsgType: msgTypeOf(msgs[0]), MsgNum: len(msgs)}, r.GasWanted, r.GasUsed, r.Code != 0)
+	}
+	return nil
+}
+
+// reportGas prints the gas distributions with the recommended gas limits and appends them to gas.csv.
+func reportGas(cmd *cobra.Command, runID string, g *gasTracker) error {
+	margin, err := cmd.Flags().GetFloat64(flagGasMargin)
+	if err != nil {
+		return err
+	}
+	dists := g.stats.Distributions(margin)
+	if len(dists) == 0 {
+		log.Info().Msg("no committed txs to report the gas of")
+		return nil
+	}
+
+	f, w, err := sink.OpenCSV("gas.csv", gasHeader)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for _, d := range dists {
+		if err := sink.WriteCSVRow(w, []string{
+			runID,
+			d.MsgType,
+			strconv.Itoa(d.MsgNum),
+			strconv.Itoa(d.Txs),
+			strconv.Itoa(d.Failed),
+			strconv.FormatInt(d.MaxWanted, 10),
+			strconv.FormatInt(d.Min, 10),
+			strconv.FormatInt(d.Avg, 10),
+			strconv.FormatInt(d.P50, 10),
+			strconv.FormatInt(d.P90, 10),
+			strconv.FormatInt(d.P99, 10),
+			strconv.FormatInt(d.Max, 10),
+			strconv.FormatInt(d.Recommended, 10),
+		}); err != nil {
+			return err
+		}
+	}
+
+	log.Info().Msgf("gas used by the committed txs, with gas limits recommended at a %.0f%% margin", margin*100)
+	return load.PrintGasDistributions(os.Stdout, dists)
+}
+
+const (
+	flagGasMode                = "gas-mode"
+	flagSimulatedGasAdjustment = "simulated-gas-adjustment"
+
+	gasModeStatic   = "static"
+	gasModeSimulate = "simulate"
+
+	defaultGasAdjustment = 1.3
+)
+
+// gasEstimatesHeader is the header of gas_estimates.csv, which records the simulated gas against the gas used.
+var gasEstimatesHeader = []string{
+	"run_id",
+	"chain_id",
+	"msg_type",
+	"msg_num",
+	"simulated_gas",
+	"gas_limit",
+	"failed_simulations",
+	"committed_txs",
+	"avg_gas_used",
+	"max_gas_used",
+}
+
+// addGasEstimationFlags adds the flags of the gas limits of the txs to a command signing txs. The adjustment
+// is named apart from the gas adjustment flag of the tx flags of the SDK, which the transfer commands take.
+func addGasEstimationFlags(cmd *cobra.Command) {
+	cmd.Flags().String(flagGasMode, gasModeStatic, "gas limits of the txs: static for gas_limit of the config, or simulate to estimate them by simulating the txs")
+	cmd.Flags().Float64(flagSimulatedGasAdjustment, defaultGasAdjustment, "factor the simulated gas is multiplied by to get the gas limits")
+}
+
+// startGasEstimation sets the gas estimator of the tx when the gas is simulated, and returns the function
+// reporting the estimates against the gas used by the committed txs collected by g, if any.
+func startGasEstimation(cmd *cobra.Command, run workloadRun, t *tx.Transaction, g *gasTracker) (func(), error) {
+	mode, err := cmd.Flags().GetString(flagGasMode)
+	if err != nil {
+		return nil, err
+	}
+	adjustment, err := cmd.Flags().GetFloat64(flagSimulatedGasAdjustment)
+	if err != nil {
+		return nil, err
+	}
+	switch mode {
+	case gasModeStatic:
+		return func() {}, nil
+	case gasModeSimulate:
+	default:
+		return nil, fmt.Errorf("unknown gas mode %q", mode)
+	}
+	if adjustment <= 0 {
+		return nil, fmt.Errorf("gas adjustment must be positive, got %v", adjustment)
+	}
+
+	t.Gas = tx.NewGasEstimator(t.Client.GRPC, adjustment, msgTypeOf)
+	return func() {
+		if err := reportGasEstimates(run.ID, t.ChainID, t.Gas, g); err != nil {
+			log.Err(err).Msg("failed to report gas estimates")
+		}
+	}, nil
+}
+
+// reportGasEstimates prints the estimated gas of the txs next to the gas used by the committed ones,
+// and appends them to gas_estimates.csv. The columns of the gas used are left empty unless g collects it.
+func reportGasEstimates(runID, chainID string, est *tx.GasEstimator, g *gasTracker) error {
+	estimates := est.Estimates()
+	if len(estimates) == 0 {
+		return nil
+	}
+	used := make(map[load.GasKey]load.GasDistribution)
+	if g != nil {
+		for _, d := range g.stats.Distributions(0) {
+			used[d.GasKey] = d
+		}
+	}
+
+	f, w, err := sink.OpenCSV("gas_estimates.csv", gasEstimatesHeader)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "msg_type\tmsg_num\tsimulated\tgas_limit\tfailed_simulations\tcommitted_txs\tavg_used\tmax_used")
+	for _, e := range estimates {
+		// the gas used is left out when the committed txs are not collected, or none of the key is committed
+		var committed, avgUsed, maxUsed string
+		if g != nil {
+			d := used[e.GasKey]
+			committed = strconv.Itoa(d.Txs)
+			if d.Txs > 0 {
+				avgUsed = strconv.FormatInt(d.Avg, 10)
+				maxUsed = strconv.FormatInt(d.Max, 10)
+			}
+		}
+		if err := sink.WriteCSVRow(w, []string{
+			runID,
+			chainID,
+			e.MsgType,
+			strconv.Itoa(e.MsgNum),
+			strconv.FormatUint(e.Simulated, 10),
+			strconv.FormatUint(e.Limit, 10),
+			strconv.Itoa(e.Failures),
+			committed,
+			avgUsed,
+			maxUsed,
+		}); err != nil {
+			return err
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%s\t%s\t%s\n",
+			e.MsgType, e.MsgNum, e.Simulated, e.Limit, e.Failures, orDash(committed), orDash(avgUsed), orDash(maxUsed))
+	}
+
+	log.Info().Str("chain_id", chainID).Msg("estimated gas of the txs, and the gas used by the committed ones if collected")
+	return tw.Flush()
+}
+
+// orDash returns s, or a dash if it is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
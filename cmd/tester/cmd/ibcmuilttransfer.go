@@ -11,10 +11,13 @@ import (
 	"github.com/b-harvest/modules-test-tool/client"
 	"github.com/b-harvest/modules-test-tool/client/grpc"
 	"github.com/b-harvest/modules-test-tool/config"
+	"github.com/b-harvest/modules-test-tool/errclass"
+	"github.com/b-harvest/modules-test-tool/load"
+	"github.com/b-harvest/modules-test-tool/metrics"
+	"github.com/b-harvest/modules-test-tool/scenario"
+	"github.com/b-harvest/modules-test-tool/sink"
 
 	"github.com/b-harvest/modules-test-tool/tx"
-	"github.com/b-harvest/modules-test-tool/wallet"
-	rpcclient "github.com/tendermint/tendermint/rpc/client"
 
 	"github.com/cosmos/cosmos-sdk/client/flags"
 	sdktypes "github.com/cosmos/cosmos-sdk/types"
@@ -52,6 +55,17 @@ msg-num: how many transaction messages to be included in a transaction
 				return err
 			}
 
+			run, err := startRun(cmd, args)
+			if err != nil {
+				return fmt.Errorf("start run: %w", err)
+			}
+			dash, stopDashboard, err := startDashboard(cmd)
+			if err != nil {
+				return err
+			}
+			defer stopDashboard()
+			run.Dashboard = dash
+
 			cfg, err := config.Read(config.DefaultConfigPath)
 			if err != nil {
 				return fmt.Errorf("failed to read config file: %s", err)
@@ -86,6 +100,36 @@ msg-num: how many transaction messages to be included in a transaction
 					return fmt.Errorf("the entered dst chains does not exist in config")
 				}
 			}
+			m, stopMetrics, err := startMetrics(cmd)
+			if err != nil {
+				return err
+			}
+			defer stopMetrics()
+			stopTracing, err := startTracing(cmd)
+			if err != nil {
+				return err
+			}
+			defer stopTracing()
+
+			// every source chain samples its own mempool
+			sampler, err := startMempoolSampler(ctx, cmd, run, m)
+			if err != nil {
+				return err
+			}
+			defer sampler.Close()
+
+			policies, err := errorPolicies(cmd, singleAccountPolicies())
+			if err != nil {
+				return err
+			}
+
+			// every source chain records its own chain id
+			results, err := openResultSink(cmd, run, "")
+			if err != nil {
+				return err
+			}
+			defer results.Close()
+
 			DstchainsSize := len(dstchains)
 			MnemonicsSize := len(cfg.Custom.Mnemonics)
 			if DstchainsSize > MnemonicsSize {
@@ -96,7 +140,7 @@ msg-num: how many transaction messages to be included in a transaction
 				wait.Add(1)
 				go func(chainname string) {
 					defer wait.Done()
-					SrcChainsend(ctx, cmd, cfg, dstchains, chainname, args)
+					SrcChainsend(ctx, cmd, run, m, sampler, results, policies, cfg, dstchains, chainname, args)
 				}(chainname)
 			}
 			wait.Wait()
@@ -107,10 +151,20 @@ msg-num: how many transaction messages to be included in a transaction
 	cmd.Flags().Uint64(flagPacketTimeoutTimestamp, ibctypes.DefaultRelativePacketTimeoutTimestamp, "Packet timeout timestamp in nanoseconds. Default is 10 minutes. The timeout is disabled when set to 0.")
 	cmd.Flags().Bool(flagAbsoluteTimeouts, false, "Timeout flags are used as absolute timeouts.")
 	flags.AddTxFlagsToCmd(cmd)
+	addSeedFlag(cmd)
+	addMetricsFlag(cmd)
+	addGasEstimationFlags(cmd)
+	addBroadcastFlags(cmd)
+	addFeeFlags(cmd)
+	addTracingFlags(cmd)
+	addMempoolFlags(cmd)
+	addDashboardFlags(cmd)
+	addErrorPolicyFlag(cmd, singleAccountPolicies())
+	addOutputFlags(cmd)
 	return cmd
 }
 
-func SrcChainsend(ctx context.Context, cmd *cobra.Command, cfg *config.Config, dstchains []string, chainname string, args []string) error {
+func SrcChainsend(ctx context.Context, cmd *cobra.Command, run workloadRun, m *metrics.Metrics, sampler *mempoolSampler, results sink.ResultSink, policies errclass.Policies, cfg *config.Config, dstchains []string, chainname string, args []string) error {
 	var mainchain config.IBCchain
 	var subchains []config.IBCchain
 	for _, ibcconfigchain := range cfg.IBCconfig.Chains {
@@ -139,24 +193,29 @@ func SrcChainsend(ctx context.Context, cmd *cobra.Command, cfg *config.Config, d
 
 	defer MainChainClient.Stop() // nolint: errcheck
 	defer MainChainClient.GRPC.Close()
+	mempool := sampler.Watch(MainChainClient, mainchain.ChainId)
+	// the destinations share the sequences of the accounts of the source chain
+	seqs := tx.NewSequences(MainChainClient.GRPC)
 	grpcclient := MainChainClient.GRPC
 	mainchainibcinfo, err := grpcclient.AllChainsTrace(ctx)
 	if err != nil {
 		return err
 	}
+	// every destination watches the same blocks of the source chain, which are recorded once
+	var observed sync.Map
 	var wait sync.WaitGroup
 	for index, dstchaininfo := range subchains {
 		wait.Add(1)
 		go func(index int, dstchaininfo config.IBCchain) {
 			defer wait.Done()
-			DstChainsend(ctx, cmd, MainChainClient, index, dstchaininfo, mainchainibcinfo, mainchain, cfg, args)
+			DstChainsend(ctx, cmd, run, m, mempool, results, policies, &observed, seqs, MainChainClient, index, dstchaininfo, mainchainibcinfo, mainchain, cfg, args)
 		}(index, dstchaininfo)
 	}
 	wait.Wait()
 	return nil
 }
 
-func DstChainsend(ctx context.Context, cmd *cobra.Command, MainChainClient *client.Client, accountindex int, dstchaininfo config.IBCchain, mainchainibcinfo []grpc.OpenChannel, mainchain config.IBCchain, cfg *config.Config, args []string) error {
+func DstChainsend(ctx context.Context, cmd *cobra.Command, run workloadRun, m *metrics.Metrics, mempool *load.MempoolMonitor, results sink.ResultSink, policies errclass.Policies, observed *sync.Map, seqs *tx.Sequences, MainChainClient *client.Client, accountindex int, dstchaininfo config.IBCchain, mainchainibcinfo []grpc.OpenChannel, mainchain config.IBCchain, cfg *config.Config, args []string) error {
 	ibcclientCtx := MainChainClient.GetCLIContext()
 	chainID, err := MainChainClient.RPC.GetNetworkChainID(ctx)
 	if err != nil {
@@ -179,9 +238,9 @@ func DstChainsend(ctx context.Context, cmd *cobra.Command, MainChainClient *clie
 	if err != nil {
 		return err
 	}
-	accAddr, privKey, err := wallet.IBCRecoverAccountFromMnemonic(cfg.Custom.Mnemonics[accountindex], "", mainchain.AccountHD, mainchain.AccountaddrPrefix)
+	accAddr, accSigner, err := chainSigner(mainchain, cfg.Custom.Mnemonics[accountindex])
 	if err != nil {
-		return fmt.Errorf("failed to retrieve account from mnemonic: %s", err)
+		return fmt.Errorf("failed to retrieve account signer: %s", err)
 	}
 	if !strings.HasPrefix(coin.Denom, "ibc/") {
 		denomTrace := ibctypes.ParseDenomTrace(coin.Denom)
@@ -207,12 +266,21 @@ func DstChainsend(ctx context.Context, cmd *cobra.Command, MainChainClient *clie
 	fees := sdktypes.NewCoins(sdktypes.NewCoin(mainchain.TokenDenom, sdktypes.NewInt(cfg.Custom.FeeAmount)))
 	memo := cfg.Custom.Memo
 	tx := tx.IbcNewtransaction(MainChainClient, chainID, gasLimit, fees, memo)
-	account, err := MainChainClient.GRPC.GetBaseAccountInfo(ctx, accAddr)
+	tx.Metrics = m
+	stopGas, err := startGasEstimation(cmd, run, tx, nil)
+	if err != nil {
+		return err
+	}
+	defer stopGas()
+	stopConfirmations, err := startConfirmations(ctx, cmd, run, tx, seqs)
 	if err != nil {
-		return fmt.Errorf("failed to get account information: %s", err)
+		return err
 	}
-	accSeq := account.GetSequence()
-	accNum := account.GetAccountNumber()
+	defer stopConfirmations()
+	if err := setGasPrices(cmd, run, tx, ""); err != nil {
+		return err
+	}
+	acc := newAccountTxs(tx, seqs, accAddr, accSigner)
 	blockTimes := make(map[int64]time.Time)
 	st, err := MainChainClient.RPC.Status(ctx)
 	if err != nil {
@@ -221,7 +289,7 @@ func DstChainsend(ctx context.Context, cmd *cobra.Command, MainChainClient *clie
 	startingHeight := st.SyncInfo.LatestBlockHeight + 2
 	log.Info().Msgf("current block height is %d, waiting for the next block to be committed <%s>", st.SyncInfo.LatestBlockHeight, mainchain.ChainId)
 
-	if err := rpcclient.WaitForHeight(MainChainClient.RPC, startingHeight-1, nil); err != nil {
+	if err := waitForHeight(ctx, MainChainClient, startingHeight-1); err != nil {
 		return fmt.Errorf("wait for height: %w", err)
 	}
 	log.Info().Msgf("starting simulation #%d, blocks = %d, num txs per block = %d <%s>", blocks+1, blocks, txNum, mainchain.ChainId)
@@ -239,6 +307,7 @@ func DstChainsend(ctx context.Context, cmd *cobra.Command, MainChainClient *clie
 
 		//started := time.Now()
 		sent := 0
+		sr := newSingleRound(acc, policies, mempool)
 	loop:
 		for sent < txNum {
 			msgs, err := tx.CreateTransferBot(cmd, ibcclientCtx, srcPort, srcChannel, coin, accAddr, receiver, msgNum)
@@ -246,32 +315,27 @@ func DstChainsend(ctx context.Context, cmd *cobra.Command, MainChainClient *clie
 				return fmt.Errorf("failed to create msg: %s", err)
 			}
 			for sent < txNum {
-				txByte, err := tx.IbcSign(ctx, accSeq, accNum, privKey, msgs...)
+				stx, err := acc.sign(ctx, msgs...)
 				if err != nil {
 					return fmt.Errorf("failed to sign and broadcast: %s", err)
 				}
-				resp, err := MainChainClient.GRPC.BroadcastTx(ctx, txByte)
+				_, stopRound, err := sr.broadcast(ctx, stx, msgs...)
 				//log.Info().Msgf("took %s broadcasting txs", resp)
+				sent++
 				if err != nil {
-					return fmt.Errorf("broadcast tx: %w", err)
+					return err
 				}
-				accSeq = accSeq + 1
-				if resp.TxResponse.Code != 0 {
-					if resp.TxResponse.Code == 0x14 {
-						log.Warn().Msg("mempool is full, stopping")
-						accSeq = accSeq - 1
-						break loop
-					}
+				if stopRound {
+					break loop
 				}
-				sent++
 			}
 		}
 		//log.Debug().Msgf("took %s broadcasting txs", time.Since(started))
 
-		if err := rpcclient.WaitForHeight(MainChainClient.RPC, targetHeight, nil); err != nil {
+		if err := waitForHeight(ctx, MainChainClient, targetHeight); err != nil {
 			return fmt.Errorf("wait for height: %w", err)
 		}
-		r, err := MainChainClient.RPC.Block(ctx, &targetHeight)
+		r, err := fetchBlock(ctx, MainChainClient, targetHeight)
 		if err != nil {
 			return err
 		}
@@ -284,6 +348,9 @@ func DstChainsend(ctx context.Context, cmd *cobra.Command, MainChainClient *clie
 			delete(blockTimes, targetHeight-1)
 		}
 		blockTimes[targetHeight] = r.Block.Time
+		if _, ok := observed.LoadOrStore(targetHeight, struct{}{}); !ok {
+			m.ObserveBlock(chainID, len(r.Block.Txs), blockDuration)
+		}
 		log.Info().
 			Int64("height", targetHeight).
 			Str("srcchain", mainchain.ChainId).
@@ -293,6 +360,21 @@ func DstChainsend(ctx context.Context, cmd *cobra.Command, MainChainClient *clie
 			Int("broadcast-txs", sent).
 			Int("committed-txs", len(r.Block.Txs)).
 			Msg("block committed")
+		if err := writeSingleMsgType(results, sink.Record{
+			ChainID:       chainID,
+			Scenario:      fmt.Sprintf("%s->%s", mainchain.ChainId, dstchaininfo.ChainId),
+			Height:        targetHeight,
+			BlockTime:     r.Block.Time,
+			BlockDuration: blockDuration,
+			Broadcast:     sr.codes[0],
+			Failed:        countFailed(sr.codes),
+			Committed:     len(r.Block.Txs),
+			Planned:       txNum,
+			Codes:         sr.codes,
+			Classes:       sr.classes.Counts(),
+		}, scenario.MsgTypeTransfer); err != nil {
+			return fmt.Errorf("record block: %w", err)
+		}
 		targetHeight++
 	}
 	return nil
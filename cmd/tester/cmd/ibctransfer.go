@@ -9,9 +9,9 @@ import (
 
 	"github.com/b-harvest/modules-test-tool/client"
 	"github.com/b-harvest/modules-test-tool/config"
+	"github.com/b-harvest/modules-test-tool/scenario"
+	"github.com/b-harvest/modules-test-tool/sink"
 	"github.com/b-harvest/modules-test-tool/tx"
-	"github.com/b-harvest/modules-test-tool/wallet"
-	rpcclient "github.com/tendermint/tendermint/rpc/client"
 
 	"github.com/cosmos/cosmos-sdk/client/flags"
 	sdktypes "github.com/cosmos/cosmos-sdk/types"
@@ -48,6 +48,17 @@ msg-num: how many transaction messages to be included in a transaction
 				return err
 			}
 
+			run, err := startRun(cmd, args)
+			if err != nil {
+				return fmt.Errorf("start run: %w", err)
+			}
+			dash, stopDashboard, err := startDashboard(cmd)
+			if err != nil {
+				return err
+			}
+			defer stopDashboard()
+			run.Dashboard = dash
+
 			cfg, err := config.Read(config.DefaultConfigPath)
 			if err != nil {
 				return fmt.Errorf("failed to read config file: %s", err)
@@ -103,9 +114,9 @@ msg-num: how many transaction messages to be included in a transaction
 				return fmt.Errorf("msgNum must be integer: %s", args[7])
 			}
 
-			accAddr, privKey, err := wallet.IBCRecoverAccountFromMnemonic(cfg.Custom.Mnemonics[0], "", mainchain.AccountHD, mainchain.AccountaddrPrefix)
+			accAddr, accSigner, err := chainSigner(mainchain, cfg.Custom.Mnemonics[0])
 			if err != nil {
-				return fmt.Errorf("failed to retrieve account from mnemonic: %s", err)
+				return fmt.Errorf("failed to retrieve account signer: %s", err)
 			}
 
 			gasLimit := uint64(cfg.Custom.GasLimit)
@@ -113,14 +124,53 @@ msg-num: how many transaction messages to be included in a transaction
 			fees := sdktypes.NewCoins(sdktypes.NewCoin(mainchain.TokenDenom, sdktypes.NewInt(cfg.Custom.FeeAmount)))
 			memo := cfg.Custom.Memo
 
+			seqs := tx.NewSequences(client.GRPC)
 			tx := tx.IbcNewtransaction(client, chainID, gasLimit, fees, memo)
 
-			account, err := client.GRPC.GetBaseAccountInfo(ctx, accAddr)
+			m, stopMetrics, err := startMetrics(cmd)
+			if err != nil {
+				return err
+			}
+			defer stopMetrics()
+			stopTracing, err := startTracing(cmd)
+			if err != nil {
+				return err
+			}
+			defer stopTracing()
+			tx.Metrics = m
+			stopGas, err := startGasEstimation(cmd, run, tx, nil)
+			if err != nil {
+				return err
+			}
+			defer stopGas()
+			stopConfirmations, err := startConfirmations(ctx, cmd, run, tx, seqs)
+			if err != nil {
+				return err
+			}
+			defer stopConfirmations()
+			if err := setGasPrices(cmd, run, tx, ""); err != nil {
+				return err
+			}
+
+			sampler, err := startMempoolSampler(ctx, cmd, run, m)
 			if err != nil {
-				return fmt.Errorf("failed to get account information: %s", err)
+				return err
+			}
+			defer sampler.Close()
+			mempool := sampler.Watch(client, chainID)
+
+			results, err := openResultSink(cmd, run, chainID)
+			if err != nil {
+				return err
 			}
-			accSeq := account.GetSequence()
-			accNum := account.GetAccountNumber()
+			defer results.Close()
+
+			policies, err := errorPolicies(cmd, singleAccountPolicies())
+			if err != nil {
+				return err
+			}
+
+			acc := newAccountTxs(tx, seqs, accAddr, accSigner)
 			blockTimes := make(map[int64]time.Time)
 			st, err := client.RPC.Status(ctx)
 			if err != nil {
@@ -129,7 +179,7 @@ msg-num: how many transaction messages to be included in a transaction
 			startingHeight := st.SyncInfo.LatestBlockHeight + 2
 			log.Info().Msgf("current block height is %d, waiting for the next block to be committed", st.SyncInfo.LatestBlockHeight)
 
-			if err := rpcclient.WaitForHeight(client.RPC, startingHeight-1, nil); err != nil {
+			if err := waitForHeight(ctx, client, startingHeight-1); err != nil {
 				return fmt.Errorf("wait for height: %w", err)
 			}
 			log.Info().Msgf("starting simulation #%d, blocks = %d, num txs per block = %d", blocks+1, blocks, txNum)
@@ -147,6 +197,7 @@ msg-num: how many transaction messages to be included in a transaction
 
 				started := time.Now()
 				sent := 0
+				sr := newSingleRound(acc, policies, mempool)
 			loop:
 				for sent < txNum {
 					msgs, err := tx.CreateTransferBot(cmd, ibcclientCtx, srcPort, srcChannel, coin, accAddr, receiver, msgNum)
@@ -154,32 +205,27 @@ msg-num: how many transaction messages to be included in a transaction
 						return fmt.Errorf("failed to create msg: %s", err)
 					}
 					for sent < txNum {
-						txByte, err := tx.IbcSign(ctx, accSeq, accNum, privKey, msgs...)
+						stx, err := acc.sign(ctx, msgs...)
 						if err != nil {
 							return fmt.Errorf("failed to sign and broadcast: %s", err)
 						}
-						resp, err := client.GRPC.BroadcastTx(ctx, txByte)
+						_, stopRound, err := sr.broadcast(ctx, stx, msgs...)
 						//log.Info().Msgf("took %s broadcasting txs", resp)
+						sent++
 						if err != nil {
-							return fmt.Errorf("broadcast tx: %w", err)
+							return err
 						}
-						accSeq = accSeq + 1
-						if resp.TxResponse.Code != 0 {
-							if resp.TxResponse.Code == 0x14 {
-								log.Warn().Msg("mempool is full, stopping")
-								accSeq = accSeq - 1
-								break loop
-							}
+						if stopRound {
+							break loop
 						}
-						sent++
 					}
 				}
 				log.Debug().Msgf("took %s broadcasting txs", time.Since(started))
 
-				if err := rpcclient.WaitForHeight(client.RPC, targetHeight, nil); err != nil {
+				if err := waitForHeight(ctx, client, targetHeight); err != nil {
 					return fmt.Errorf("wait for height: %w", err)
 				}
-				r, err := client.RPC.Block(ctx, &targetHeight)
+				r, err := fetchBlock(ctx, client, targetHeight)
 				if err != nil {
 					return err
 				}
@@ -192,6 +238,7 @@ msg-num: how many transaction messages to be included in a transaction
 					delete(blockTimes, targetHeight-1)
 				}
 				blockTimes[targetHeight] = r.Block.Time
+				m.ObserveBlock(chainID, len(r.Block.Txs), blockDuration)
 				log.Info().
 					Int64("height", targetHeight).
 					Str("block-time", r.Block.Time.Format(time.RFC3339Nano)).
@@ -199,6 +246,20 @@ msg-num: how many transaction messages to be included in a transaction
 					Int("broadcast-txs", sent).
 					Int("committed-txs", len(r.Block.Txs)).
 					Msg("block committed")
+				if err := writeSingleMsgType(results, sink.Record{
+					Scenario:      scenario.MsgTypeTransfer,
+					Height:        targetHeight,
+					BlockTime:     r.Block.Time,
+					BlockDuration: blockDuration,
+					Broadcast:     sr.codes[0],
+					Failed:        countFailed(sr.codes),
+					Committed:     len(r.Block.Txs),
+					Planned:       txNum,
+					Codes:         sr.codes,
+					Classes:       sr.classes.Counts(),
+				}, scenario.MsgTypeTransfer); err != nil {
+					return fmt.Errorf("record block: %w", err)
+				}
 				targetHeight++
 			}
 
@@ -209,5 +270,15 @@ msg-num: how many transaction messages to be included in a transaction
 	cmd.Flags().Uint64(flagPacketTimeoutTimestamp, ibctypes.DefaultRelativePacketTimeoutTimestamp, "Packet timeout timestamp in nanoseconds. Default is 10 minutes. The timeout is disabled when set to 0.")
 	cmd.Flags().Bool(flagAbsoluteTimeouts, false, "Timeout flags are used as absolute timeouts.")
 	flags.AddTxFlagsToCmd(cmd)
+	addSeedFlag(cmd)
+	addMetricsFlag(cmd)
+	addGasEstimationFlags(cmd)
+	addBroadcastFlags(cmd)
+	addFeeFlags(cmd)
+	addTracingFlags(cmd)
+	addMempoolFlags(cmd)
+	addDashboardFlags(cmd)
+	addErrorPolicyFlag(cmd, singleAccountPolicies())
+	addOutputFlags(cmd)
 	return cmd
 }
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	tmtypes "github.com/tendermint/tendermint/types"
+
+	"github.com/b-harvest/modules-test-tool/client"
+	"github.com/b-harvest/modules-test-tool/load"
+	"github.com/b-harvest/modules-test-tool/sink"
+)
+
+const (
+	flagTrackInclusion = "track-inclusion"
+	flagInclusionGrace = "inclusion-grace"
+)
+
+// inclusionHeader is the header of inclusion.csv, which records the inclusion of every broadcast tx.
+var inclusionHeader = []string{
+	"run_id",
+	"chain_id",
+	"tx_hash",
+	"account",
+	"broadcast_time",
+	"height",
+	"commit_time",
+	"latency",
+	"committed",
+	"gas_price",
+}
+
+// earlyCommitTTL is how long the commit of a tx is kept for its broadcast to be recorded. The commits of
+// the txs of other accounts are dropped after it.
+const earlyCommitTTL = time.Minute
+
+// maxListedUncommittedTxs is the number of never committed txs listed in the output; all of them are in inclusion.csv.
+const maxListedUncommittedTxs = 20
+
+// addInclusionFlags adds the flags to track the inclusion of every broadcast tx.
+func addInclusionFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool(flagTrackInclusion, true, "track the time from the broadcast to the commit of every tx through the websocket")
+	cmd.Flags().Duration(flagInclusionGrace, 30*time.Second, "time to wait for the pending txs to be committed at the end of the run")
+}
+
+// startInclusionTracker subscribes to the committed txs of the chain when inclusion tracking is enabled,
+// and returns a tracker recording them until ctx is done. It returns nil otherwise.
+func startInclusionTracker(ctx context.Context, cmd *cobra.Command, c *client.Client) (*load.InclusionTracker, error) {
+	enabled, err := cmd.Flags().GetBool(flagTrackInclusion)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	events, err := c.RPC.SubscribeTxs(ctx, "tester-inclusion", 10000)
+	if err != nil {
+		return nil, fmt.Errorf("subscribe txs: %w", err)
+	}
+
+	tracker := load.NewInclusionTracker()
+	go func() {
+		ticker := time.NewTicker(earlyCommitTTL)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				tracker.PruneEarly(now.Add(-earlyCommitTTL))
+			case ev, ok := <-events:
+				if !ok {
+					log.Warn().Msg("tx subscription has been closed; the inclusion latencies are incomplete")
+					return
+				}
+				data, ok := ev.Data.(tmtypes.EventDataTx)
+				if !ok {
+					continue
+				}
+				tracker.Commit(fmt.Sprintf("%X", tmtypes.Tx(data.Tx).Hash()), data.Height, time.Now())
+			}
+		}
+	}()
+	return tracker, nil
+}
+
+// reportInclusion waits for the pending txs to be committed up to the grace period,
+// writes the inclusion of every tx to inclusion.csv and prints the latency stats
+// along with the txs which have never been committed. The rows are tagged with the run and chain ids.
+func reportInclusion(cmd *cobra.Command, tracker *load.InclusionTracker, runID, chainID string) error {
+	grace, err := cmd.Flags().GetDuration(flagInclusionGrace)
+	if err != nil {
+		return err
+	}
+	if pending := tracker.Pending(); pending > 0 {
+		log.Info().Int("pending-txs", pending).Str("grace", grace.String()).Msg("waiting for the pending txs to be committed")
+		deadline := time.Now().Add(grace)
+		for tracker.Pending() > 0 && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+
+	f, w, err := sink.OpenCSV("inclusion.csv", inclusionHeader)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var uncommitted []string
+	for _, in := range tracker.Inclusions() {
+		row := []string{
+			runID,
+			chainID,
+			in.Hash,
+			in.Account,
+			in.BroadcastAt.Format(time.RFC3339Nano),
+			"",
+			"",
+			"",
+			strconv.FormatBool(in.Committed()),
+			in.GasPrice,
+		}
+		if in.Committed() {
+			row[5] = strconv.FormatInt(in.Height, 10)
+			row[6] = in.CommittedAt.Format(time.RFC3339Nano)
+			row[7] = in.Latency().String()
+		} else {
+			uncommitted = append(uncommitted, in.Hash)
+		}
+		if err := sink.WriteCSVRow(w, row); err != nil {
+			return err
+		}
+	}
+
+	if err := tracker.Stats().Print(os.Stdout); err != nil {
+		return err
+	}
+	if byPrice := load.LatenciesByGasPrice(tracker.Inclusions()); len(byPrice) > 0 {
+		log.Info().Msg("inclusion latencies by gas price")
+		if err := load.PrintGasPriceLatencies(os.Stdout, byPrice); err != nil {
+			return err
+		}
+	}
+	for i, hash := range uncommitted {
+		if i == maxListedUncommittedTxs {
+			fmt.Printf("... and %d more never committed txs in inclusion.csv\n", len(uncommitted)-i)
+			break
+		}
+		fmt.Printf("never committed: %s\n", hash)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+)
+
+// notifyInterrupt returns a channel which is closed on the first interrupt or termination signal,
+// and a function to stop listening. The signal handling is reset once the channel is closed,
+// so that a second signal terminates the process right away.
+func notifyInterrupt() (<-chan struct{}, func()) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+
+	interrupted := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer signal.Stop(sigs)
+		select {
+		case sig := <-sigs:
+			log.Warn().Str("signal", sig.String()).Msg("interrupted, finishing the transactions in flight; interrupt again to quit immediately")
+			close(interrupted)
+		case <-done:
+		}
+	}()
+	return interrupted, func() { close(done) }
+}
+
+// isInterrupted returns whether the channel returned by notifyInterrupt has been closed.
+func isInterrupted(interrupted <-chan struct{}) bool {
+	select {
+	case <-interrupted:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/b-harvest/modules-test-tool/client"
+	"github.com/b-harvest/modules-test-tool/dashboard"
+	"github.com/b-harvest/modules-test-tool/load"
+	"github.com/b-harvest/modules-test-tool/metrics"
+	"github.com/b-harvest/modules-test-tool/sink"
+)
+
+const (
+	flagMempoolInterval   = "mempool-interval"
+	flagMempoolSaturation = "mempool-saturation"
+)
+
+// mempoolHeader is the header of the csv file of the mempool samples, written next to the results.
+var mempoolHeader = []string{
+	"run_id",
+	"chain_id",
+	"time",
+	"num_txs",
+	"total_bytes",
+	"full_rejections",
+	"saturated",
+}
+
+// addMempoolFlags adds the flags of mempool sampling to a workload command.
+func addMempoolFlags(cmd *cobra.Command) {
+	cmd.Flags().Duration(flagMempoolInterval, time.Second, "interval at which the mempool size is sampled; disabled if zero")
+	cmd.Flags().Int(flagMempoolSaturation, 0, "number of unconfirmed txs from which the mempool is flagged as saturated; if zero, only by rejected txs of code 0x14")
+}
+
+// mempoolSampler samples the mempools of the chains of a run into a csv file next to the results.
+type mempoolSampler struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	m         *metrics.Metrics
+	dash      *dashboard.Dashboard
+	runID     string
+	interval  time.Duration
+	threshold int
+
+	mu       sync.Mutex
+	f        *os.File
+	w        *csv.Writer
+	chainIDs []string
+	monitors []*load.MempoolMonitor
+}
+
+// startMempoolSampler opens the csv file of the mempool samples of the run, or returns nil if sampling is disabled.
+func startMempoolSampler(ctx context.Context, cmd *cobra.Command, run workloadRun, m *metrics.Metrics) (*mempoolSampler, error) {
+	interval, err := cmd.Flags().GetDuration(flagMempoolInterval)
+	if err != nil {
+		return nil, err
+	}
+	threshold, err := cmd.Flags().GetInt(flagMempoolSaturation)
+	if err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		return nil, nil
+	}
+	_, path, err := resultOutput(cmd)
+	if err != nil {
+		return nil, err
+	}
+	f, w, err := sink.OpenCSV(sink.SidePath(path, "mempool"), mempoolHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	return &mempoolSampler{
+		ctx:       ctx,
+		cancel:    cancel,
+		m:         m,
+		dash:      run.Dashboard,
+		runID:     run.ID,
+		interval:  interval,
+		threshold: threshold,
+		f:         f,
+		w:         w,
+	}, nil
+}
+
+// Watch samples the mempool of the chain until the sampler is closed. The codes of the broadcast responses
+// are to be recorded to the returned monitor, which is nil if sampling is disabled.
+func (s *mempoolSampler) Watch(c *client.Client, chainID string) *load.MempoolMonitor {
+	if s == nil {
+		return nil
+	}
+	mon := load.NewMempoolMonitor(s.threshold)
+	s.mu.Lock()
+	s.chainIDs = append(s.chainIDs, chainID)
+	s.monitors = append(s.monitors, mon)
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			st, err := c.RPC.NumUnconfirmedTxs(s.ctx)
+			if err != nil {
+				if s.ctx.Err() == nil {
+					log.Debug().Err(err).Msg("failed to get mempool size")
+				}
+				continue
+			}
+			s.m.SetMempool(chainID, st.Total, st.TotalBytes)
+			s.dash.SetMempool(chainID, st.Total, st.TotalBytes)
+			if err := s.write(chainID, mon.Sample(time.Now().UTC(), st.Total, st.TotalBytes)); err != nil {
+				log.Warn().Err(err).Msg("failed to write mempool sample")
+			}
+		}
+	}()
+	return mon
+}
+
+func (s *mempoolSampler) write(chainID string, sample load.MempoolSample) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sink.WriteCSVRow(s.w, []string{
+		s.runID,
+		chainID,
+		sample.At.Format(time.RFC3339Nano),
+		strconv.Itoa(sample.Txs),
+		strconv.FormatInt(sample.Bytes, 10),
+		strconv.Itoa(sample.FullRejections),
+		strconv.FormatBool(sample.Saturated),
+	})
+}
+
+// Close stops the sampling, prints the saturation periods of every chain and closes the csv file.
+func (s *mempoolSampler) Close() {
+	if s == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+
+	for i, mon := range s.monitors {
+		periods := mon.SaturationPeriods()
+		if len(periods) == 0 {
+			continue
+		}
+		log.Info().Msgf("mempool of %s was saturated in %d periods", s.chainIDs[i], len(periods))
+		if err := load.PrintSaturationPeriods(os.Stdout, periods); err != nil {
+			log.Warn().Err(err).Msg("failed to print saturation periods")
+		}
+	}
+	if err := s.f.Close(); err != nil {
+		log.Warn().Err(err).Msg("failed to close mempool samples")
+	}
+}
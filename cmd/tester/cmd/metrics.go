@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/b-harvest/modules-test-tool/metrics"
+)
+
+const flagMetricsAddr = "metrics-addr"
+
+// addMetricsFlag adds the metrics address flag to a workload command.
+func addMetricsFlag(cmd *cobra.Command) {
+	cmd.Flags().String(flagMetricsAddr, "", "address to serve Prometheus metrics on, e.g. :2112; disabled if empty")
+}
+
+// startMetrics serves the metrics of the command when the metrics address is set.
+// Otherwise it returns nil metrics, on which recording is a no-op.
+func startMetrics(cmd *cobra.Command) (*metrics.Metrics, func(), error) {
+	addr, err := cmd.Flags().GetString(flagMetricsAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if addr == "" {
+		return nil, func() {}, nil
+	}
+
+	m := metrics.New(cmd.Name())
+	srv, err := m.Serve(addr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("serve metrics: %w", err)
+	}
+	return m, func() { srv.Close() }, nil
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/b-harvest/modules-test-tool/client"
+	"github.com/b-harvest/modules-test-tool/errclass"
+	"github.com/b-harvest/modules-test-tool/sink"
+)
+
+const (
+	flagOutputFormat = "output-format"
+	flagOutputPath   = "output-path"
+)
+
+// addOutputFlags adds the flags of the result sink to a workload command. They are named apart from the
+// output flag of the tx flags of the SDK, which the transfer commands take.
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().String(flagOutputFormat, sink.FormatCSV, "format of the results; must be one of csv, jsonl or sqlite")
+	cmd.Flags().String(flagOutputPath, "", "path of the results; result.csv, result.jsonl or result.db by default")
+}
+
+// resultOutput returns the format and the path of the results selected by the output flags,
+// or the defaults if the command has no output flags.
+func resultOutput(cmd *cobra.Command) (format, path string, err error) {
+	format = sink.FormatCSV
+	if cmd.Flags().Lookup(flagOutputFormat) != nil {
+		format, err = cmd.Flags().GetString(flagOutputFormat)
+		if err != nil {
+			return "", "", err
+		}
+		path, err = cmd.Flags().GetString(flagOutputPath)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	if err := sink.CheckFormat(format); err != nil {
+		return "", "", err
+	}
+	if path == "" {
+		path = sink.DefaultPath(format)
+	}
+	return format, path, nil
+}
+
+// openResultSink opens the result sink selected by the output flags. The records written to it
+// are tagged with the run, the command and the given chain id, and shown on the dashboard of the run, if any.
+func openResultSink(cmd *cobra.Command, run workloadRun, chainID string) (sink.ResultSink, error) {
+	format, path, err := resultOutput(cmd)
+	if err != nil {
+		return nil, err
+	}
+	s, err := sink.Open(format, path)
+	if err != nil {
+		return nil, fmt.Errorf("open result sink: %w", err)
+	}
+	return sink.WithRun(run.Dashboard.Wrap(s), run.ID, cmd.Name(), chainID), nil
+}
+
+// recordRound records a round of a command which does not wait for its txs to be committed.
+// The round is recorded at the latest block, without committed txs.
+func recordRound(ctx context.Context, c *client.Client, results sink.ResultSink, msgType string, planned int, codes map[uint32]int, classes errclass.Histogram) error {
+	st, err := c.RPC.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("get status: %w", err)
+	}
+	rec := sink.Record{
+		Scenario:  msgType,
+		Height:    st.SyncInfo.LatestBlockHeight,
+		BlockTime: st.SyncInfo.LatestBlockTime,
+		Planned:   planned,
+		Codes:     codes,
+		Classes:   classes.Counts(),
+	}
+	rec.Failed = countFailed(codes)
+	rec.Broadcast = codes[0]
+	return writeSingleMsgType(results, rec, msgType)
+}
+
+// countFailed returns the number of broadcast responses of non-zero codes.
+func countFailed(codes map[uint32]int) int {
+	failed := 0
+	for code, n := range codes {
+		if code != 0 {
+			failed += n
+		}
+	}
+	return failed
+}
+
+// writeSingleMsgType writes the record of txs of a single message type, followed by the same
+// record of that message type, as the stress test breaks down its blocks by message type.
+func writeSingleMsgType(results sink.ResultSink, rec sink.Record, msgType string) error {
+	if err := results.Write(rec); err != nil {
+		return err
+	}
+	rec.MsgType = msgType
+	rec.Codes = nil
+	rec.Classes = nil
+	return results.Write(rec)
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/b-harvest/modules-test-tool/client"
+	"github.com/b-harvest/modules-test-tool/config"
+	"github.com/b-harvest/modules-test-tool/corpus"
+	"github.com/b-harvest/modules-test-tool/scenario"
+	"github.com/b-harvest/modules-test-tool/tx"
+)
+
+func PresignCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "presign [output]",
+		Short: "sign the transactions planned by the scenario file ahead of time",
+		Args:  cobra.ExactArgs(1),
+		Long: `Sign the transactions planned by the scenario file ahead of time and write them to the output file, one JSON object per line.
+Each transaction records its account, sequence, message type and planned block index, so that it can be broadcast later with the replay command.
+
+The transactions are signed with the current account sequences. The accounts must not send any other transaction before the corpus is replayed.
+
+Example: $ tester presign corpus.jsonl --scenario ./scenario.toml
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			err := SetLogger(logLevel)
+			if err != nil {
+				return fmt.Errorf("set logger: %w", err)
+			}
+
+			run, err := startRun(cmd, args)
+			if err != nil {
+				return fmt.Errorf("start run: %w", err)
+			}
+
+			cfg, err := config.Read(config.DefaultConfigPath)
+			if err != nil {
+				return fmt.Errorf("read config: %w", err)
+			}
+
+			client, err := client.NewClient(cfg.RPC.Address, cfg.GRPC.Address)
+			if err != nil {
+				return fmt.Errorf("new client: %w", err)
+			}
+			defer client.Stop() // nolint: errcheck
+
+			scenarioPath, err := cmd.Flags().GetString(flagScenario)
+			if err != nil {
+				return err
+			}
+
+			scenarioFile, err := scenario.Read(scenarioPath)
+			if err != nil {
+				return fmt.Errorf("read scenario: %w", err)
+			}
+
+			if err := scenarioFile.Validate(numAccounts(cfg.Custom)); err != nil {
+				return fmt.Errorf("invalid scenario: %w", err)
+			}
+
+			chainID, err := client.RPC.GetNetworkChainID(ctx)
+			if err != nil {
+				return err
+			}
+
+			gasLimit := uint64(cfg.Custom.GasLimit)
+			fees := sdk.NewCoins(sdk.NewCoin(cfg.Custom.FeeDenom, sdk.NewInt(cfg.Custom.FeeAmount)))
+			memo := cfg.Custom.Memo
+			seqs := tx.NewSequences(client.GRPC)
+			tx := tx.NewTransaction(client, chainID, gasLimit, fees, memo)
+			stopGas, err := startGasEstimation(cmd, run, tx, nil)
+			if err != nil {
+				return err
+			}
+			defer stopGas()
+			if err := setGasPrices(cmd, run, tx, cfg.Custom.GasPrices); err != nil {
+				return err
+			}
+
+			// accounts keep their sequences across the scenarios
+			accounts, err := NewWorkerPool(ctx, seqs, cfg.Custom, allAccounts(cfg.Custom))
+			if err != nil {
+				return fmt.Errorf("load accounts: %w", err)
+			}
+
+			f, err := os.Create(args[0])
+			if err != nil {
+				return fmt.Errorf("create file: %w", err)
+			}
+			defer f.Close()
+			cw := corpus.NewWriter(f)
+
+			block := 0
+			total := 0
+			for no, scenario := range scenarioFile.Scenarios {
+				p, err := newPhase(ctx, client, scenario, accounts, run.Seed+int64(no))
+				if err != nil {
+					return err
+				}
+
+				profile, err := scenario.LoadProfile()
+				if err != nil {
+					return err
+				}
+
+				indexes := scenario.Accounts
+				if len(indexes) == 0 {
+					for i := range accounts.Workers {
+						indexes = append(indexes, i)
+					}
+				}
+
+				log.Info().Msgf("signing scenario #%d(%s), rounds = %d, load profile = %s, msg mix = %s", no+1, scenario.Name, scenario.Rounds, profile, p.mix)
+
+				for i := 0; i < scenario.Rounds; i++ {
+					msgs := make(map[int]map[string][]sdk.Msg)
+					planned := profile.Count(i)
+					for j := 0; j < planned; j++ {
+						acc := accounts.Workers[indexes[j%len(indexes)]]
+						msgType := p.mix.Pick()
+						if _, ok := msgs[acc.ID]; !ok {
+							msgs[acc.ID] = make(map[string][]sdk.Msg)
+						}
+						if _, ok := msgs[acc.ID][msgType]; !ok {
+							m, err := p.createMsgs(ctx, tx, acc, msgType)
+							if err != nil {
+								return fmt.Errorf("generate %s msgs: %s", msgType, err)
+							}
+							msgs[acc.ID][msgType] = m
+						}
+
+						accNum, accSeq, err := seqs.Reserve(ctx, acc.Addr)
+						if err != nil {
+							return fmt.Errorf("reserve sequence: %w", err)
+						}
+						txByte, err := tx.SignWith(ctx, acc.Signer, accSeq, accNum, msgs[acc.ID][msgType]...)
+						if err != nil {
+							return fmt.Errorf("sign tx: %w", err)
+						}
+						if err := cw.Write(corpus.SignedTx{
+							Account:  acc.Addr,
+							Sequence: accSeq,
+							MsgType:  msgType,
+							Block:    block,
+							TxBytes:  txByte,
+						}); err != nil {
+							return fmt.Errorf("write tx: %w", err)
+						}
+						total++
+					}
+					block++
+				}
+			}
+
+			if err := cw.Flush(); err != nil {
+				return fmt.Errorf("flush corpus: %w", err)
+			}
+			log.Info().Int("blocks", block).Int("txs", total).Str("output", args[0]).Msg("done signing")
+
+			return nil
+		},
+	}
+	cmd.Flags().String(flagScenario, scenario.DefaultScenarioPath, "path to the scenario file")
+	addSeedFlag(cmd)
+	addGasEstimationFlags(cmd)
+	addFeeFlags(cmd)
+	return cmd
+}
@@ -0,0 +1,479 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/b-harvest/modules-test-tool/client"
+	"github.com/b-harvest/modules-test-tool/config"
+	"github.com/b-harvest/modules-test-tool/errclass"
+	"github.com/b-harvest/modules-test-tool/load"
+	"github.com/b-harvest/modules-test-tool/scenario"
+	"github.com/b-harvest/modules-test-tool/sink"
+	"github.com/b-harvest/modules-test-tool/tx"
+)
+
+const (
+	flagBurst       = "burst"
+	flagRateProfile = "profile"
+)
+
+// rateHeader is the header of rate_result.csv, which records the target and achieved txs of every second
+// of the rate test.
+var rateHeader = []string{
+	"run_id",
+	"chain_id",
+	"second",
+	"time",
+	"target_txs",
+	"achieved_txs",
+	"rejected_txs",
+	"lag",
+}
+
+func RateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rate [pool-id] [offer-coin] [tps] [duration]",
+		Short:   "broadcast swap transactions at a constant rate",
+		Aliases: []string{"r"},
+		Args:    cobra.ExactArgs(4),
+		Long: `Broadcast swap transactions at a target rate independent of the block production.
+
+Example: $ tester r 1 1000000uatom 50 5m
+
+tps: how many transactions to be broadcast per second
+duration: how long to keep the test going
+
+The rate can be shaped over the seconds of the test with --profile, e.g. --profile ramp:from=10,to=100
+or --profile spike:peak=200,at=60,length=10. tps is the base of the step, spike and sine profiles.
+
+Every account of the config sends its txs concurrently with the others, so the reachable rate grows
+with the number of accounts.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			err := SetLogger(logLevel)
+			if err != nil {
+				return fmt.Errorf("set logger: %w", err)
+			}
+
+			run, err := startRun(cmd, args)
+			if err != nil {
+				return fmt.Errorf("start run: %w", err)
+			}
+			dash, stopDashboard, err := startDashboard(cmd)
+			if err != nil {
+				return err
+			}
+			defer stopDashboard()
+			run.Dashboard = dash
+
+			cfg, err := config.Read(config.DefaultConfigPath)
+			if err != nil {
+				return fmt.Errorf("read config: %w", err)
+			}
+
+			client, err := client.NewClient(cfg.RPC.Address, cfg.GRPC.Address)
+			if err != nil {
+				return fmt.Errorf("new client: %w", err)
+			}
+			defer client.Stop() // nolint: errcheck
+
+			poolID, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid pool id: %w", err)
+			}
+
+			offerCoin, err := sdk.ParseCoinNormalized(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid offer coin: %w", err)
+			}
+
+			if err := offerCoin.Validate(); err != nil {
+				return fmt.Errorf("invalid offer coin: %w", err)
+			}
+
+			tps, err := strconv.ParseFloat(args[2], 64)
+			if err != nil {
+				return fmt.Errorf("tps must be a number: %s", args[2])
+			}
+
+			duration, err := time.ParseDuration(args[3])
+			if err != nil {
+				return fmt.Errorf("invalid duration: %w", err)
+			}
+
+			profile, err := rateProfile(cmd, tps, duration)
+			if err != nil {
+				return err
+			}
+
+			burst, err := cmd.Flags().GetInt(flagBurst)
+			if err != nil {
+				return err
+			}
+			if burst == 0 {
+				burst = int(tps) + 1
+				if profile != nil {
+					burst = peakOf(profile, duration) + 1
+				}
+			}
+
+			bucket, err := load.NewTokenBucket(tps, burst)
+			if err != nil {
+				return fmt.Errorf("new token bucket: %w", err)
+			}
+
+			pool, err := client.GRPC.GetPool(ctx, poolID)
+			if err != nil {
+				return fmt.Errorf("get pool: %w", err)
+			}
+
+			var demandCoinDenom string
+			if pool.ReserveCoinDenoms[0] == offerCoin.Denom {
+				demandCoinDenom = pool.ReserveCoinDenoms[1]
+			} else {
+				demandCoinDenom = pool.ReserveCoinDenoms[0]
+			}
+
+			chainID, err := client.RPC.GetNetworkChainID(ctx)
+			if err != nil {
+				return err
+			}
+
+			gasLimit := uint64(cfg.Custom.GasLimit)
+			fees := sdk.NewCoins(sdk.NewCoin(cfg.Custom.FeeDenom, sdk.NewInt(cfg.Custom.FeeAmount)))
+			memo := cfg.Custom.Memo
+			seqs := tx.NewSequences(client.GRPC)
+			tx := tx.NewTransaction(client, chainID, gasLimit, fees, memo)
+
+			m, stopMetrics, err := startMetrics(cmd)
+			if err != nil {
+				return err
+			}
+			defer stopMetrics()
+			stopTracing, err := startTracing(cmd)
+			if err != nil {
+				return err
+			}
+			defer stopTracing()
+			tx.Metrics = m
+			stopGas, err := startGasEstimation(cmd, run, tx, nil)
+			if err != nil {
+				return err
+			}
+			defer stopGas()
+			stopConfirmations, err := startConfirmations(ctx, cmd, run, tx, seqs)
+			if err != nil {
+				return err
+			}
+			defer stopConfirmations()
+			if err := setGasPrices(cmd, run, tx, cfg.Custom.GasPrices); err != nil {
+				return err
+			}
+
+			sampler, err := startMempoolSampler(ctx, cmd, run, m)
+			if err != nil {
+				return err
+			}
+			defer sampler.Close()
+			mempool := sampler.Watch(client, chainID)
+
+			policies, err := errorPolicies(cmd, ratePolicies())
+			if err != nil {
+				return err
+			}
+			classes := make(errclass.Histogram)
+
+			inclusion, err := startInclusionTracker(ctx, cmd, client)
+			if err != nil {
+				return err
+			}
+
+			results, err := openResultSink(cmd, run, chainID)
+			if err != nil {
+				return err
+			}
+			defer results.Close()
+
+			f, w, err := sink.OpenCSV("rate_result.csv", rateHeader)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			// every account is driven by its own sender, so a slow broadcast only holds back its account
+			senders, err := NewWorkerPool(ctx, seqs, cfg.Custom, allAccounts(cfg.Custom))
+			if err != nil {
+				return fmt.Errorf("load accounts: %w", err)
+			}
+			rands := make([]*rand.Rand, len(senders.Workers))
+			for i := range rands {
+				rands[i] = load.NewRand(run.Seed, i)
+			}
+
+			started := time.Now()
+			recorder := load.NewRateRecorder(tps, started)
+			if profile != nil {
+				bucket.Shape(profile, started)
+				recorder.Shape(profile)
+			}
+			recordCtx := ctx // outlives the run to record its last second
+			runCtx, cancelRun := context.WithDeadline(ctx, started.Add(duration))
+			defer cancelRun()
+
+			writeSamples := func(now time.Time) error {
+				for _, s := range recorder.Flush(now) {
+					log.Info().
+						Int("second", s.Second).
+						Int("target-txs", s.Target).
+						Int("achieved-txs", s.Achieved).
+						Int("rejected-txs", s.Rejected).
+						Str("lag", s.Lag.String()).
+						Msg("second elapsed")
+					if err := w.Write([]string{
+						run.ID,
+						chainID,
+						strconv.Itoa(s.Second),
+						started.Add(time.Duration(s.Second) * time.Second).Format(time.RFC3339Nano),
+						strconv.Itoa(s.Target),
+						strconv.Itoa(s.Achieved),
+						strconv.Itoa(s.Rejected),
+						s.Lag.String(),
+					}); err != nil {
+						return fmt.Errorf("emit row: %w", err)
+					}
+					if err := recordSecond(recordCtx, client, results, s); err != nil {
+						return fmt.Errorf("record second: %w", err)
+					}
+				}
+				w.Flush()
+				if err := w.Error(); err != nil {
+					return fmt.Errorf("write row: %w", err)
+				}
+				return nil
+			}
+
+			// the elapsed seconds are recorded aside, so that querying the latest block never delays a send
+			recordErr := make(chan error, 1)
+			go func() {
+				ticker := time.NewTicker(time.Second)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-runCtx.Done():
+						recordErr <- nil
+						return
+					case now := <-ticker.C:
+						if err := writeSamples(now); err != nil {
+							recordErr <- err
+							cancelRun()
+							return
+						}
+					}
+				}
+			}()
+
+			// the bucket hands out the send times to the senders without waiting for their broadcasts
+			sends := make(chan time.Time, burst)
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for bucket.Wait(runCtx) == nil {
+					select {
+					case sends <- time.Now():
+					case <-runCtx.Done():
+						return
+					}
+				}
+			}()
+
+			if profile != nil {
+				log.Info().Msgf("starting rate test, profile = %s, duration = %s, senders = %d", profile, duration, len(senders.Workers))
+			} else {
+				log.Info().Msgf("starting rate test, tps = %.2f, duration = %s, senders = %d", tps, duration, len(senders.Workers))
+			}
+
+			var classesMu sync.Mutex
+			msgs := make([][]sdk.Msg, len(senders.Workers))
+			msgsCreated := make([]time.Time, len(senders.Workers))
+			_, err = senders.RunUntil(ctx, done, math.MaxInt32, func(ctx context.Context, w *load.Worker) (load.Outcome, error) {
+				select {
+				case <-sends:
+				case <-done:
+					return load.Skipped, nil // the test is over
+				}
+				now := time.Now()
+
+				// refresh the order price once per second to keep up with the pool
+				if now.Sub(msgsCreated[w.ID]) >= time.Second {
+					m, err := tx.CreateSwapBot(ctx, rands[w.ID], w.Addr, poolID, offerCoin, demandCoinDenom, 1)
+					if err != nil {
+						return 0, fmt.Errorf("generate msgs: %s", err)
+					}
+					msgs[w.ID] = m
+					msgsCreated[w.ID] = now
+				}
+
+				accNum, accSeq, err := seqs.Reserve(ctx, w.Addr)
+				if err != nil {
+					return 0, fmt.Errorf("reserve sequence: %w", err)
+				}
+				txByte, err := tx.SignWith(ctx, w.Signer, accSeq, accNum, msgs[w.ID]...)
+				if err != nil {
+					seqs.Release(w.Addr, accSeq)
+					return 0, fmt.Errorf("sign tx: %w", err)
+				}
+				broadcastAt := time.Now()
+				resp, err := tx.Broadcast(ctx, w.Addr, txByte)
+				if err != nil {
+					seqs.Invalidate(w.Addr)
+					return 0, fmt.Errorf("broadcast tx: %w", err)
+				}
+				mempool.RecordCode(resp.TxResponse.Code)
+				classesMu.Lock()
+				e := classes.Add(resp.TxResponse.Codespace, resp.TxResponse.Code)
+				classesMu.Unlock()
+				if e.Class == errclass.ClassOK {
+					recorder.RecordSent(time.Now())
+					if inclusion != nil {
+						inclusion.Broadcast(resp.TxResponse.TxHash, w.Addr, tx.GasPrice(w.Addr, accSeq), broadcastAt)
+					}
+					return load.Sent, nil
+				}
+				recorder.RecordRejected(time.Now())
+				seqs.Reject(w.Addr, accSeq, resp.TxResponse.Codespace, resp.TxResponse.Code, resp.TxResponse.RawLog)
+				return handleRateRejected(policies, w, e, resp.TxResponse.RawLog)
+			})
+			if err == nil {
+				select {
+				case <-done:
+				default:
+					log.Warn().Msg("every account has stopped sending before the end of the test")
+				}
+			}
+			cancelRun()
+			<-done
+			if recErr := <-recordErr; err == nil {
+				err = recErr
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := writeSamples(started.Add(duration)); err != nil {
+				return err
+			}
+			log.Info().Str("elapsed", time.Since(started).String()).Msg("done rate test")
+			if err := classes.Print(os.Stdout); err != nil {
+				log.Err(err).Msg("failed to print error classes")
+			}
+
+			if inclusion != nil {
+				if err := reportInclusion(cmd, inclusion, run.ID, chainID); err != nil {
+					return fmt.Errorf("report inclusion: %w", err)
+				}
+			}
+
+			return nil
+		},
+	}
+	cmd.Flags().Int(flagBurst, 0, "maximum number of transactions sent at once to catch up with the schedule; defaults to tps + 1, or the peak of the profile + 1")
+	cmd.Flags().String(flagRateProfile, "", "profile shaping the txs per second over the test, as its type followed by its parameters, e.g. ramp:from=10,to=100")
+	addSeedFlag(cmd)
+	addOutputFlags(cmd)
+	addMetricsFlag(cmd)
+	addGasEstimationFlags(cmd)
+	addBroadcastFlags(cmd)
+	addFeeFlags(cmd)
+	addTracingFlags(cmd)
+	addMempoolFlags(cmd)
+	addDashboardFlags(cmd)
+	addErrorPolicyFlag(cmd, ratePolicies())
+	addInclusionFlags(cmd)
+	return cmd
+}
+
+// rateProfile returns the profile of the --profile flag over the seconds of the test, with tps as its base,
+// or nil if the rate is constant.
+func rateProfile(cmd *cobra.Command, tps float64, duration time.Duration) (load.Profile, error) {
+	spec, err := cmd.Flags().GetString(flagRateProfile)
+	if err != nil {
+		return nil, err
+	}
+	if spec == "" {
+		return nil, nil
+	}
+	cfg, err := scenario.ParseProfile(spec)
+	if err != nil {
+		return nil, err
+	}
+	s := scenario.Scenario{
+		Rounds:         int((duration + time.Second - 1) / time.Second),
+		NumTxsPerBlock: int(math.Round(tps)),
+		Profile:        cfg,
+	}
+	profile, err := s.LoadProfile()
+	if err != nil {
+		return nil, fmt.Errorf("invalid profile: %w", err)
+	}
+	return profile, nil
+}
+
+// peakOf returns the highest number of txs planned by the profile in a second of the test.
+func peakOf(p load.Profile, duration time.Duration) int {
+	peak := 0
+	for s := 0; time.Duration(s)*time.Second < duration; s++ {
+		if n := p.Count(s); n > peak {
+			peak = n
+		}
+	}
+	return peak
+}
+
+// recordSecond records a second of the rate test at the latest block. The txs of the second are not
+// followed up to their blocks, so the record has no committed txs.
+func recordSecond(ctx context.Context, c *client.Client, results sink.ResultSink, s load.RateSample) error {
+	st, err := c.RPC.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("get status: %w", err)
+	}
+	return writeSingleMsgType(results, sink.Record{
+		Scenario:  "rate",
+		Height:    st.SyncInfo.LatestBlockHeight,
+		BlockTime: st.SyncInfo.LatestBlockTime,
+		Broadcast: s.Achieved,
+		Failed:    s.Rejected,
+		Planned:   s.Target,
+	}, scenario.MsgTypeSwap)
+}
+
+// handleRateRejected handles a tx of the rate test rejected with the given error by the policy of its class.
+// The sequence of the tx has already been given back or resynced, and the rate test has no rounds to stop,
+// so any policy but switching the account and aborting drops the tx.
+func handleRateRejected(policies errclass.Policies, w *load.Worker, e errclass.Error, rawLog string) (load.Outcome, error) {
+	policy := policies.Of(e.Class)
+	log.Warn().Int("worker", w.ID).Str("addr", w.Addr).Str("error", e.String()).Str("policy", string(policy)).Str("log", rawLog).Msg("tx rejected")
+	switch policy {
+	case errclass.PolicySwitchAccount:
+		return load.StopWorker, nil
+	case errclass.PolicyAbort:
+		return 0, fmt.Errorf("tx rejected with %s: %s", e, rawLog)
+	default:
+		return load.Skipped, nil
+	}
+}
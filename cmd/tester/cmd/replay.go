@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/b-harvest/modules-test-tool/client"
+	"github.com/b-harvest/modules-test-tool/config"
+	"github.com/b-harvest/modules-test-tool/corpus"
+	"github.com/b-harvest/modules-test-tool/errclass"
+	"github.com/b-harvest/modules-test-tool/load"
+	"github.com/b-harvest/modules-test-tool/sink"
+)
+
+func ReplayCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replay [corpus]",
+		Short: "broadcast pre-signed transactions at their planned blocks",
+		Args:  cobra.ExactArgs(1),
+		Long: `Broadcast the transactions of a corpus made by the presign command, block by block at the planned cadence.
+
+Example: $ tester replay corpus.jsonl
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			err := SetLogger(logLevel)
+			if err != nil {
+				return fmt.Errorf("set logger: %w", err)
+			}
+
+			cfg, err := config.Read(config.DefaultConfigPath)
+			if err != nil {
+				return fmt.Errorf("read config: %w", err)
+			}
+
+			client, err := client.NewClient(cfg.RPC.Address, cfg.GRPC.Address)
+			if err != nil {
+				return fmt.Errorf("new client: %w", err)
+			}
+			defer client.Stop() // nolint: errcheck
+
+			cf, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("open corpus: %w", err)
+			}
+			defer cf.Close()
+			cr := corpus.NewReader(cf)
+
+			chainID, err := client.RPC.GetNetworkChainID(ctx)
+			if err != nil {
+				return err
+			}
+			runID := sink.NewRunID(time.Now())
+			results, err := openResultSink(cmd, workloadRun{ID: runID}, chainID)
+			if err != nil {
+				return err
+			}
+			defer results.Close()
+			report := sinkReporter(results)
+
+			// the presigned txs keep their sequences, so no account is queried again when they time out
+			mode, confirmations, stopConfirmations, err := startConfirmationTracker(ctx, cmd, workloadRun{ID: runID}, client, chainID, nil)
+			if err != nil {
+				return err
+			}
+			defer stopConfirmations()
+
+			bw := newBlockWatcher(client, nil, "")
+
+			gas, err := newGasTracker(cmd, client)
+			if err != nil {
+				return err
+			}
+			summary := load.NewSummary()
+			decoder := client.GetCLIContext().TxConfig.TxDecoder()
+			accounts := make(map[string]bool) // signers of the corpus, whose committed txs are counted
+
+			st, err := client.RPC.Status(ctx)
+			if err != nil {
+				return fmt.Errorf("get status: %w", err)
+			}
+			startingHeight := st.SyncInfo.LatestBlockHeight + 2
+			log.Info().Msgf("current block height is %d, waiting for the next block to be committed", st.SyncInfo.LatestBlockHeight)
+
+			firstBlock := -1
+			for {
+				block, txs, err := cr.NextBlock()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return fmt.Errorf("read corpus: %w", err)
+				}
+				if firstBlock < 0 {
+					firstBlock = block
+				}
+
+				targetHeight := startingHeight + int64(block-firstBlock)
+				if err := waitForHeight(ctx, client, targetHeight-1); err != nil {
+					return fmt.Errorf("wait for height: %w", err)
+				}
+				st, err := client.RPC.Status(ctx)
+				if err != nil {
+					return fmt.Errorf("get status: %w", err)
+				}
+				if st.SyncInfo.LatestBlockHeight != targetHeight-1 {
+					log.Warn().Int64("expected", targetHeight-1).Int64("got", st.SyncInfo.LatestBlockHeight).Msg("falling behind the planned cadence")
+					startingHeight += st.SyncInfo.LatestBlockHeight + 1 - targetHeight
+					targetHeight = st.SyncInfo.LatestBlockHeight + 1
+				}
+
+				started := time.Now()
+				sent := 0
+				codes := make(map[uint32]int)
+				classes := make(errclass.Histogram)
+				for _, stx := range txs {
+					gas.AddAccounts(stx.Account)
+					accounts[stx.Account] = true
+					broadcastAt := time.Now()
+					resp, err := client.GRPC.BroadcastTx(ctx, stx.TxBytes, mode)
+					if err != nil {
+						return fmt.Errorf("broadcast tx: %w", err)
+					}
+					codes[resp.TxResponse.Code]++
+					e := classes.Add(resp.TxResponse.Codespace, resp.TxResponse.Code)
+					summary.AddResponse(resp.TxResponse.Codespace, resp.TxResponse.Code)
+					if e.Class != errclass.ClassOK {
+						log.Warn().
+							Str("addr", stx.Account).
+							Uint64("seq", stx.Sequence).
+							Str("error", e.String()).
+							Str("log", resp.TxResponse.RawLog).
+							Msg("tx rejected")
+						continue
+					}
+					confirmations.Track(resp.TxResponse.TxHash, stx.Account, broadcastAt)
+					sent++
+				}
+				log.Debug().Msgf("took %s broadcasting txs", time.Since(started))
+
+				b, blockDuration, err := bw.Wait(ctx, targetHeight)
+				if err != nil {
+					return err
+				}
+				if err := gas.Collect(ctx, targetHeight, b.Txs, summary); err != nil {
+					log.Warn().Err(err).Msg("failed to collect gas")
+				}
+				committed := totalCommitted(countCommittedByMsgType(decoder, b.Txs, accounts))
+				summary.AddBlock(targetHeight, sent, committed)
+				log.Info().
+					Int("block", block).
+					Int64("height", targetHeight).
+					Str("block-time", b.Time.Format(time.RFC3339Nano)).
+					Str("block-duration", blockDuration.String()).
+					Int("broadcast-txs", sent).
+					Int("committed-txs", committed).
+					Int("block-txs", len(b.Txs)).
+					Int("planned-txs", len(txs)).
+					Msg("block committed")
+
+				if err := report(ctx, roundResult{
+					scenario:      "replay",
+					height:        targetHeight,
+					blockTime:     b.Time,
+					planned:       len(txs),
+					sent:          sent,
+					committed:     committed,
+					blockDuration: blockDuration,
+					codes:         codes,
+					classes:       classes.Counts(),
+				}); err != nil {
+					return err
+				}
+			}
+
+			if err := summary.Print(os.Stdout); err != nil {
+				log.Err(err).Msg("failed to print summary")
+			}
+			if gas != nil {
+				if err := reportGas(cmd, runID, gas); err != nil {
+					return fmt.Errorf("report gas: %w", err)
+				}
+			}
+			return nil
+		},
+	}
+	addOutputFlags(cmd)
+	addGasFlags(cmd)
+	addBroadcastFlags(cmd)
+	return cmd
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/b-harvest/modules-test-tool/report"
+)
+
+const (
+	flagFormat = "format"
+	flagOutput = "output"
+)
+
+func ReportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report [result-files...]",
+		Short: "render a report of the results of runs",
+		Args:  cobra.MinimumNArgs(1),
+		Long: `Render a self-contained Markdown or HTML report of one or more result files.
+
+The files are result.csv, result_codes.csv and result_classes.csv written by the block-synchronized
+workloads. The report has a table per scenario with the throughput, the block durations, the commit
+ratio, the breakdown of the broadcast responses by code and by error class and charts of every block.
+When inclusion.csv is given too, the inclusion latencies of the txs are bucketed by their gas prices.
+
+Example: $ tester report result.csv result_codes.csv result_classes.csv --format html --output report.html
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			err := SetLogger(logLevel)
+			if err != nil {
+				return fmt.Errorf("set logger: %w", err)
+			}
+
+			format, err := cmd.Flags().GetString(flagFormat)
+			if err != nil {
+				return err
+			}
+			output, err := cmd.Flags().GetString(flagOutput)
+			if err != nil {
+				return err
+			}
+
+			var res report.Results
+			for _, path := range args {
+				if err := readResultFile(&res, path); err != nil {
+					return err
+				}
+			}
+			r := report.Build(res)
+
+			w := os.Stdout
+			if output != "" {
+				f, err := os.Create(output)
+				if err != nil {
+					return fmt.Errorf("create output: %w", err)
+				}
+				defer f.Close()
+				w = f
+			}
+			if err := r.Write(w, format); err != nil {
+				return fmt.Errorf("write report: %w", err)
+			}
+			if output != "" {
+				log.Info().Int("scenarios", len(r.Scenarios)).Msgf("wrote report to %s", output)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().String(flagFormat, report.FormatMarkdown, "format of the report; must be either markdown or html")
+	cmd.Flags().StringP(flagOutput, "o", "", "path of the report; the standard output if empty")
+	return cmd
+}
+
+func readResultFile(res *report.Results, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open result file: %w", err)
+	}
+	defer f.Close()
+	if err := res.Read(f, path); err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	return nil
+}
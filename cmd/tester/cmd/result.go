@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	ibctypes "github.com/cosmos/ibc-go/v2/modules/apps/transfer/types"
+	liquiditytypes "github.com/gravity-devs/liquidity/x/liquidity/types"
+	"github.com/rs/zerolog/log"
+	tmtypes "github.com/tendermint/tendermint/types"
+
+	"github.com/b-harvest/modules-test-tool/client"
+	"github.com/b-harvest/modules-test-tool/metrics"
+	"github.com/b-harvest/modules-test-tool/scenario"
+	"github.com/b-harvest/modules-test-tool/sink"
+)
+
+// blockWatcher waits for blocks to be committed and measures their durations.
+type blockWatcher struct {
+	c          *client.Client
+	metrics    *metrics.Metrics
+	chainID    string
+	blockTimes map[int64]time.Time
+}
+
+// newBlockWatcher creates a new blockWatcher which records the watched blocks of the chain to the metrics, if any.
+func newBlockWatcher(c *client.Client, m *metrics.Metrics, chainID string) *blockWatcher {
+	return &blockWatcher{
+		c:          c,
+		metrics:    m,
+		chainID:    chainID,
+		blockTimes: make(map[int64]time.Time),
+	}
+}
+
+// Wait waits for the block of the given height to be committed and returns the block
+// along with its duration, which is zero when the previous block has not been watched.
+func (bw *blockWatcher) Wait(ctx context.Context, height int64) (*tmtypes.Block, time.Duration, error) {
+	if err := waitForHeight(ctx, bw.c, height); err != nil {
+		return nil, 0, fmt.Errorf("wait for height: %w", err)
+	}
+
+	r, err := fetchBlock(ctx, bw.c, height)
+	if err != nil {
+		return nil, 0, err
+	}
+	var blockDuration time.Duration
+	bt, ok := bw.blockTimes[height-1]
+	if !ok {
+		log.Warn().Msg("past block time not found")
+	} else {
+		blockDuration = r.Block.Time.Sub(bt)
+		delete(bw.blockTimes, height-1)
+	}
+	bw.blockTimes[height] = r.Block.Time
+	bw.metrics.ObserveBlock(bw.chainID, len(r.Block.Txs), blockDuration)
+	return r.Block, blockDuration, nil
+}
+
+// msgTypeCount counts the txs of a single message type.
+type msgTypeCount struct {
+	broadcast int
+	failed    int
+	committed int
+}
+
+// msgTypeStats counts the txs per message type of the current round and of the whole phase.
+type msgTypeStats struct {
+	mu     sync.Mutex
+	round  map[string]*msgTypeCount
+	totals map[string]*msgTypeCount
+}
+
+func newMsgTypeStats() *msgTypeStats {
+	return &msgTypeStats{
+		round:  make(map[string]*msgTypeCount),
+		totals: make(map[string]*msgTypeCount),
+	}
+}
+
+func (s *msgTypeStats) count(m map[string]*msgTypeCount, msgType string) *msgTypeCount {
+	c, ok := m[msgType]
+	if !ok {
+		c = &msgTypeCount{}
+		m[msgType] = c
+	}
+	return c
+}
+
+// addBroadcast counts a broadcast tx of the given type, which failed unless accepted.
+func (s *msgTypeStats) addBroadcast(msgType string, accepted bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.count(s.round, msgType)
+	if accepted {
+		c.broadcast++
+	} else {
+		c.failed++
+	}
+}
+
+// addCommitted counts the committed txs per type.
+func (s *msgTypeStats) addCommitted(committed map[string]int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for msgType, n := range committed {
+		s.count(s.round, msgType).committed += n
+	}
+}
+
+// flushRound adds the counts of the round to the totals and returns them as records of the given types.
+func (s *msgTypeStats) flushRound(msgTypes []string) []sink.Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var recs []sink.Record
+	for _, msgType := range msgTypes {
+		c := s.count(s.round, msgType)
+		total := s.count(s.totals, msgType)
+		total.broadcast += c.broadcast
+		total.failed += c.failed
+		total.committed += c.committed
+		recs = append(recs, sink.Record{
+			MsgType:   msgType,
+			Broadcast: c.broadcast,
+			Failed:    c.failed,
+			Committed: c.committed,
+		})
+	}
+	s.round = make(map[string]*msgTypeCount)
+	return recs
+}
+
+// msgTypeOf returns the scenario message type of the given message, or its type url if it is not one of them.
+func msgTypeOf(msg sdk.Msg) string {
+	switch msg.(type) {
+	case *liquiditytypes.MsgSwapWithinBatch:
+		return scenario.MsgTypeSwap
+	case *liquiditytypes.MsgDepositWithinBatch:
+		return scenario.MsgTypeDeposit
+	case *liquiditytypes.MsgWithdrawWithinBatch:
+		return scenario.MsgTypeWithdraw
+	case *ibctypes.MsgTransfer:
+		return scenario.MsgTypeTransfer
+	default:
+		return sdk.MsgTypeURL(msg)
+	}
+}
+
+// countCommittedByMsgType counts the committed txs signed by the accounts by the type of their first message.
+// The txs of other users of the chain, and the txs which cannot be decoded, are skipped.
+func countCommittedByMsgType(decoder sdk.TxDecoder, txs tmtypes.Txs, accounts map[string]bool) map[string]int {
+	counts := make(map[string]int)
+	for _, txBytes := range txs {
+		tx, err := decoder(txBytes)
+		if err != nil {
+			log.Debug().Err(err).Msg("failed to decode committed tx")
+			continue
+		}
+		msgs := tx.GetMsgs()
+		if len(msgs) == 0 {
+			continue
+		}
+		signers := msgs[0].GetSigners()
+		if len(signers) == 0 || !accounts[signers[0].String()] {
+			continue
+		}
+		counts[msgTypeOf(msgs[0])]++
+	}
+	return counts
+}
+
+// totalCommitted returns the number of committed txs of the counts by message type.
+func totalCommitted(counts map[string]int) int {
+	n := 0
+	for _, c := range counts {
+		n += c
+	}
+	return n
+}
@@ -35,6 +35,13 @@ func RootCmd() *cobra.Command {
 	cmd.AddCommand(SwapCmd())
 	cmd.AddCommand(IBCtransferCmd())
 	cmd.AddCommand(StressTestCmd())
+	cmd.AddCommand(RateCmd())
+	cmd.AddCommand(PresignCmd())
+	cmd.AddCommand(ReplayCmd())
+	cmd.AddCommand(ReportCmd())
+	cmd.AddCommand(CompareCmd())
+	cmd.AddCommand(CoordinatorCmd())
+	cmd.AddCommand(AgentCmd())
 	cmd.AddCommand(IBCtraceCmd())
 	cmd.AddCommand(IBCMuiltTransferCmd())
 	cmd.AddCommand(IBCBalances())
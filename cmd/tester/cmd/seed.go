@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/b-harvest/modules-test-tool/dashboard"
+	"github.com/b-harvest/modules-test-tool/sink"
+)
+
+const flagSeed = "seed"
+
+// runsHeader is the header of runs.csv, which records the id and the seed of every workload run.
+var runsHeader = []string{
+	"run_id",
+	"started_at",
+	"command",
+	"args",
+	"seed",
+}
+
+// addSeedFlag adds the seed flag to a workload command.
+func addSeedFlag(cmd *cobra.Command) {
+	cmd.Flags().Int64(flagSeed, 0, "seed of the random source of the generated messages; chosen from the current time if not set")
+}
+
+// workloadRun identifies a run of a workload command.
+type workloadRun struct {
+	ID   string
+	Seed int64
+	// Dashboard is updated with the results and the mempool samples of the run, if set.
+	Dashboard *dashboard.Dashboard
+}
+
+// startRun starts a new run of the command. The seed of the run is recorded in runs.csv along with its id,
+// so that a run can be regenerated exactly by passing the same seed again.
+func startRun(cmd *cobra.Command, args []string) (workloadRun, error) {
+	seed, err := cmd.Flags().GetInt64(flagSeed)
+	if err != nil {
+		return workloadRun{}, err
+	}
+	now := time.Now()
+	if !cmd.Flags().Changed(flagSeed) {
+		seed = now.UnixNano()
+	}
+	run := workloadRun{ID: sink.NewRunID(now), Seed: seed}
+	log.Info().Str("run-id", run.ID).Int64("seed", seed).Msgf("pass --seed %d to regenerate this run", seed)
+
+	f, w, err := sink.OpenCSV("runs.csv", runsHeader)
+	if err != nil {
+		return workloadRun{}, err
+	}
+	defer f.Close()
+	if err := sink.WriteCSVRow(w, []string{
+		run.ID,
+		now.Format(time.RFC3339Nano),
+		cmd.Name(),
+		strings.Join(args, " "),
+		strconv.FormatInt(seed, 10),
+	}); err != nil {
+		return workloadRun{}, err
+	}
+	return run, nil
+}
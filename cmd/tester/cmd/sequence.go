@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdktx "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/rs/zerolog/log"
+
+	"github.com/b-harvest/modules-test-tool/signer"
+	"github.com/b-harvest/modules-test-tool/tx"
+)
+
+// sequencedTx is a tx signed with a sequence reserved for its account.
+type sequencedTx struct {
+	seq   uint64
+	bytes []byte
+}
+
+// accountTxs signs and broadcasts the txs of an account with the sequences reserved from the shared
+// sequence manager.
+type accountTxs struct {
+	t      *tx.Transaction
+	seqs   *tx.Sequences
+	addr   string
+	signer signer.Signer
+}
+
+func newAccountTxs(t *tx.Transaction, seqs *tx.Sequences, addr string, s signer.Signer) *accountTxs {
+	return &accountTxs{t: t, seqs: seqs, addr: addr, signer: s}
+}
+
+// sign signs a tx of the messages with the next sequence of the account, which is released if signing fails.
+func (a *accountTxs) sign(ctx context.Context, msgs ...sdk.Msg) (sequencedTx, error) {
+	accNum, seq, err := a.seqs.Reserve(ctx, a.addr)
+	if err != nil {
+		return sequencedTx{}, err
+	}
+	txByte, err := a.t.SignWith(ctx, a.signer, seq, accNum, msgs...)
+	if err != nil {
+		a.seqs.Release(a.addr, seq)
+		return sequencedTx{}, err
+	}
+	return sequencedTx{seq: seq, bytes: txByte}, nil
+}
+
+// release gives back the sequences of signed txs which are not broadcast.
+func (a *accountTxs) release(stxs ...sequencedTx) {
+	for _, stx := range stxs {
+		a.seqs.Release(a.addr, stx.seq)
+	}
+}
+
+// broadcast broadcasts a signed tx of the messages. The sequence of a tx rejected by CheckTx is given back,
+// and a tx rejected for a sequence mismatch, e.g. after an earlier tx of the account has been rejected,
+// is signed again with the resynced sequence and broadcast once more.
+func (a *accountTxs) broadcast(ctx context.Context, stx sequencedTx, msgs ...sdk.Msg) (*sdktx.BroadcastTxResponse, error) {
+	resp, err := a.t.Broadcast(ctx, a.addr, stx.bytes)
+	if err != nil {
+		// the tx may have been accepted, so the account is queried again
+		a.seqs.Invalidate(a.addr)
+		return nil, err
+	}
+	if resp.TxResponse.Code == 0 {
+		return resp, nil
+	}
+	if !a.seqs.Reject(a.addr, stx.seq, resp.TxResponse.Codespace, resp.TxResponse.Code, resp.TxResponse.RawLog) {
+		return resp, nil
+	}
+
+	stx, err = a.sign(ctx, msgs...)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug().Str("addr", a.addr).Uint64("seq", stx.seq).Msg("broadcasting the tx again with the resynced sequence")
+	resp, err = a.t.Broadcast(ctx, a.addr, stx.bytes)
+	if err != nil {
+		a.seqs.Invalidate(a.addr)
+		return nil, err
+	}
+	if resp.TxResponse.Code != 0 {
+		a.seqs.Reject(a.addr, stx.seq, resp.TxResponse.Codespace, resp.TxResponse.Code, resp.TxResponse.RawLog)
+	}
+	return resp, nil
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+
+	"github.com/b-harvest/modules-test-tool/config"
+	"github.com/b-harvest/modules-test-tool/signer"
+	"github.com/b-harvest/modules-test-tool/wallet"
+)
+
+// chainSigner returns the signer of the account of an IBC chain and its address with the prefix of the chain.
+// The key of the keyring of the chain is used if it is named, and the key of the mnemonic otherwise.
+func chainSigner(chain config.IBCchain, mnemonic string) (string, signer.Signer, error) {
+	if chain.KeyName == "" {
+		return wallet.RecoverSigner(mnemonic, "", chain.AccountHD, chain.KeyType, chain.AccountaddrPrefix)
+	}
+
+	backend := chain.KeyringBackend
+	if backend == "" {
+		backend = keyring.BackendTest
+	}
+	s, err := signer.OpenKeyring(backend, chain.KeyringDir, chain.KeyName)
+	if err != nil {
+		return "", nil, err
+	}
+	accAddr, err := bech32.ConvertAndEncode(chain.AccountaddrPrefix, s.Address())
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to convert and encode address: %w", err)
+	}
+	return accAddr, s, nil
+}
+
+// accountSigner returns the signer of the i-th account of the workloads and its address. The i-th key of the
+// keyring is used if keys are named, and the key of the i-th mnemonic otherwise.
+func accountSigner(cfg *config.CustomConfig, i int) (string, signer.Signer, error) {
+	chain := config.IBCchain{
+		AccountHD:         cfg.AccountHD,
+		AccountaddrPrefix: sdktypes.GetConfig().GetBech32AccountAddrPrefix(),
+		KeyType:           cfg.KeyType,
+		KeyringBackend:    cfg.KeyringBackend,
+		KeyringDir:        cfg.KeyringDir,
+	}
+	if chain.AccountHD == "" {
+		chain.AccountHD = sdktypes.GetConfig().GetFullFundraiserPath()
+	}
+	if len(cfg.KeyNames) > 0 {
+		chain.KeyName = cfg.KeyNames[i]
+		return chainSigner(chain, "")
+	}
+	return chainSigner(chain, cfg.Mnemonics[i])
+}
+
+// numAccounts returns the number of the accounts of the workloads, those of the named keys if any.
+func numAccounts(cfg *config.CustomConfig) int {
+	if len(cfg.KeyNames) > 0 {
+		return len(cfg.KeyNames)
+	}
+	return len(cfg.Mnemonics)
+}
+
+// allAccounts returns the indexes of all the accounts of the workloads.
+func allAccounts(cfg *config.CustomConfig) []int {
+	indexes := make([]int, numAccounts(cfg))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	return indexes
+}
@@ -2,127 +2,694 @@ package cmd
 
 import (
 	"context"
-	"encoding/csv"
 	"fmt"
+	"math/rand"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
 	"time"
 
-	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	ibctypes "github.com/cosmos/ibc-go/v2/modules/apps/transfer/types"
+	clienttypes "github.com/cosmos/ibc-go/v2/modules/core/02-client/types"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
-	rpcclient "github.com/tendermint/tendermint/rpc/client"
 
 	"github.com/b-harvest/modules-test-tool/client"
 	"github.com/b-harvest/modules-test-tool/config"
+	"github.com/b-harvest/modules-test-tool/errclass"
+	"github.com/b-harvest/modules-test-tool/load"
+	"github.com/b-harvest/modules-test-tool/scenario"
+	"github.com/b-harvest/modules-test-tool/sink"
+	"github.com/b-harvest/modules-test-tool/tracing"
 	"github.com/b-harvest/modules-test-tool/tx"
-	"github.com/b-harvest/modules-test-tool/wallet"
 )
 
-type AccountDispenser struct {
-	c         *client.Client
-	mnemonics []string
-	i         int
-	addr      string
-	privKey   *secp256k1.PrivKey
-	accSeq    uint64
-	accNum    uint64
+// NewWorkerPool creates a worker pool with a worker for each of the accounts of the given indexes,
+// whose sequences are reserved from seqs.
+func NewWorkerPool(ctx context.Context, seqs *tx.Sequences, cfg *config.CustomConfig, indexes []int) (*load.WorkerPool, error) {
+	accounts := make([]load.Account, 0, len(indexes))
+	for _, i := range indexes {
+		addr, s, err := accountSigner(cfg, i)
+		if err != nil {
+			return nil, err
+		}
+		// the account is queried up front, so a missing one fails before the first round
+		if _, _, err := seqs.Load(ctx, addr); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, load.Account{
+			Addr:   addr,
+			Signer: s,
+		})
+	}
+	return load.NewWorkerPool(accounts), nil
+}
+
+const (
+	flagScenario         = "scenario"
+	flagFindCapacity     = "find-capacity"
+	flagMinTxs           = "min-txs"
+	flagMaxTxs           = "max-txs"
+	flagResolution       = "resolution"
+	flagProbeBlocks      = "probe-blocks"
+	flagMinCommitRatio   = "min-commit-ratio"
+	flagMaxBlockDuration = "max-block-duration"
+	flagResume           = "resume"
+	flagCheckpoint       = "checkpoint"
+)
+
+// stressRunner runs the rounds of the stress test and records their results.
+type stressRunner struct {
+	client *client.Client
+	cfg    *config.Config
+	tx     *tx.Transaction
+	seqs   *tx.Sequences // shared by the workers of every scenario
+	bw     *blockWatcher
+	seed   int64
+
+	// report records the result of every round.
+	report func(ctx context.Context, res roundResult) error
+	// share is the part of the planned txs this runner sends.
+	share load.Share
+	// start returns the height of the first round of a scenario; by default, the height after the next block.
+	start func(ctx context.Context, no int) (int64, error)
+
+	// interrupted is closed when the run is interrupted.
+	interrupted <-chan struct{}
+	summary     *load.Summary
+	inclusion   *load.InclusionTracker // optional
+	gas         *gasTracker            // optional
+	mempool     *load.MempoolMonitor   // optional
+	policies    errclass.Policies      // how rejected txs are handled by error class
+
+	scenarioPath   string
+	checkpointPath string // optional
+}
+
+// phase holds what is needed to run the rounds of a scenario.
+type phase struct {
+	scenario        scenario.Scenario
+	name            string // recorded in the results, the scenario name by default
+	mix             *load.Mix
+	offerCoin       sdk.Coin
+	demandCoinDenom string
+	depositCoins    sdk.Coins
+	poolCoin        sdk.Coin
+	transferCoin    sdk.Coin
+	timeoutHeight   clienttypes.Height
+	workers         *load.WorkerPool
+	accounts        map[string]bool // addresses of the workers, whose committed txs are counted
+	rands           []*rand.Rand
+	stats           *msgTypeStats
 }
 
-func NewAccountDispenser(c *client.Client, mnemonics []string) *AccountDispenser {
-	return &AccountDispenser{
-		c:         c,
-		mnemonics: mnemonics,
+// roundResult is the result of a single round of the stress test.
+type roundResult struct {
+	scenario      string
+	height        int64
+	blockTime     time.Time
+	planned       int
+	sent          int
+	committed     int
+	blockDuration time.Duration
+	codes         map[uint32]int // number of broadcast responses by code
+	classes       map[string]int // number of broadcast responses by error class
+	msgTypes      []sink.Record  // results of the txs of every message type
+}
+
+// records returns the record of the block followed by those of the message types.
+func (res roundResult) records() []sink.Record {
+	recs := []sink.Record{{
+		Scenario:      res.scenario,
+		Height:        res.height,
+		BlockTime:     res.blockTime,
+		BlockDuration: res.blockDuration,
+		Broadcast:     res.sent,
+		Failed:        countFailed(res.codes),
+		Committed:     res.committed,
+		Planned:       res.planned,
+		Codes:         res.codes,
+		Classes:       res.classes,
+	}}
+	for _, rec := range res.msgTypes {
+		rec.Scenario = res.scenario
+		rec.Height = res.height
+		rec.BlockTime = res.blockTime
+		recs = append(recs, rec)
 	}
+	return recs
 }
 
-func (d *AccountDispenser) Next() error {
-	mnemonic := d.mnemonics[d.i]
-	addr, privKey, err := wallet.RecoverAccountFromMnemonic(mnemonic, "")
+// preparePhase loads the accounts of the scenario and prepares its phase,
+// whose random sources are derived from the seed of the run and the phase number.
+func (r *stressRunner) preparePhase(ctx context.Context, no int, s scenario.Scenario) (*phase, error) {
+	workers, err := NewWorkerPool(ctx, r.seqs, r.cfg.Custom, s.SelectAccounts(numAccounts(r.cfg.Custom)))
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("new worker pool: %w", err)
 	}
-	d.addr = addr
-	d.privKey = privKey
-	acc, err := d.c.GRPC.GetBaseAccountInfo(context.Background(), addr)
+	for _, w := range workers.Workers {
+		r.gas.AddAccounts(w.Addr)
+	}
+	return newPhase(ctx, r.client, s, workers, r.seed+int64(no))
+}
+
+// newPhase parses the message parameters of the scenario for the given workers,
+// each of which gets its own random source derived from the seed.
+func newPhase(ctx context.Context, c *client.Client, s scenario.Scenario, workers *load.WorkerPool, seed int64) (*phase, error) {
+	mix, err := s.MsgMix()
 	if err != nil {
-		return fmt.Errorf("get base account info: %w", err)
+		return nil, err
 	}
-	d.accSeq = acc.GetSequence()
-	d.accNum = acc.GetAccountNumber()
-	d.i++
-	if d.i >= len(d.mnemonics) {
-		d.i = 0
+	p := &phase{
+		scenario: s,
+		name:     s.Name,
+		mix:      mix,
+		workers:  workers,
+		accounts: make(map[string]bool, len(workers.Workers)),
+		rands:    make([]*rand.Rand, len(workers.Workers)),
+		stats:    newMsgTypeStats(),
 	}
-	return nil
+	for i, w := range workers.Workers {
+		p.accounts[w.Addr] = true
+		p.rands[i] = load.NewRand(seed, i)
+	}
+
+	for _, msgType := range mix.Types() {
+		switch msgType {
+		case scenario.MsgTypeSwap:
+			p.offerCoin, err = s.ParseOfferCoin()
+			if err != nil {
+				return nil, err
+			}
+
+			pool, err := c.GRPC.GetPool(ctx, s.PoolID)
+			if err != nil {
+				return nil, fmt.Errorf("get pool: %w", err)
+			}
+
+			if pool.ReserveCoinDenoms[0] == p.offerCoin.Denom {
+				p.demandCoinDenom = pool.ReserveCoinDenoms[1]
+			} else {
+				p.demandCoinDenom = pool.ReserveCoinDenoms[0]
+			}
+		case scenario.MsgTypeDeposit:
+			p.depositCoins, err = s.ParseDepositCoins()
+			if err != nil {
+				return nil, err
+			}
+		case scenario.MsgTypeWithdraw:
+			p.poolCoin, err = s.ParsePoolCoin()
+			if err != nil {
+				return nil, err
+			}
+		case scenario.MsgTypeTransfer:
+			p.transferCoin, err = s.ParseTransferAmount()
+			if err != nil {
+				return nil, err
+			}
+			if !strings.HasPrefix(p.transferCoin.Denom, "ibc/") {
+				denomTrace := ibctypes.ParseDenomTrace(p.transferCoin.Denom)
+				p.transferCoin.Denom = denomTrace.IBCDenom()
+			}
+			p.timeoutHeight, err = clienttypes.ParseHeight(ibctypes.DefaultRelativePacketTimeoutHeight)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// createMsgs creates the messages of the given type to be sent by the given worker.
+func (p *phase) createMsgs(ctx context.Context, t *tx.Transaction, w *load.Worker, msgType string) ([]sdk.Msg, error) {
+	addr := w.Addr
+	var msg sdk.Msg
+	var err error
+	switch msgType {
+	case scenario.MsgTypeSwap:
+		return t.CreateSwapBot(ctx, p.rands[w.ID], addr, p.scenario.PoolID, p.offerCoin, p.demandCoinDenom, 1)
+	case scenario.MsgTypeDeposit:
+		msg, err = tx.MsgDeposit(addr, p.scenario.PoolID, p.depositCoins)
+	case scenario.MsgTypeWithdraw:
+		msg, err = tx.MsgWithdraw(addr, p.scenario.PoolID, p.poolCoin)
+	case scenario.MsgTypeTransfer:
+		msg, err = tx.NewMsgTransfer(t.Client.GetCLIContext(), p.scenario.Transfer.Port, p.scenario.Transfer.Channel, p.transferCoin,
+			addr, p.scenario.Transfer.Receiver, p.timeoutHeight, ibctypes.DefaultRelativePacketTimeoutTimestamp, false)
+	default:
+		return nil, fmt.Errorf("unsupported msg type: %s", msgType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []sdk.Msg{msg}, nil
 }
 
-func (d *AccountDispenser) Addr() string {
-	return d.addr
+// waitStart waits for the block before the first round of the given scenario to be committed
+// and returns the height of the first round.
+func (r *stressRunner) waitStart(ctx context.Context, no int) (int64, error) {
+	var startingHeight int64
+	if r.start != nil {
+		h, err := r.start(ctx, no)
+		if err != nil {
+			return 0, fmt.Errorf("get starting height: %w", err)
+		}
+		startingHeight = h
+		log.Info().Msgf("starting height is %d, waiting for the block before it to be committed", startingHeight)
+	} else {
+		st, err := r.client.RPC.Status(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("get status: %w", err)
+		}
+		startingHeight = st.SyncInfo.LatestBlockHeight + 2
+		log.Info().Msgf("current block height is %d, waiting for the next block to be committed", st.SyncInfo.LatestBlockHeight)
+	}
+
+	if err := waitForHeight(ctx, r.client, startingHeight-1); err != nil {
+		return 0, fmt.Errorf("wait for height: %w", err)
+	}
+	return startingHeight, nil
+}
+
+// runRound broadcasts the planned number of transactions to be included in the block of
+// the target height and waits for the block to be committed. The target height is moved
+// forward when the network is already ahead of it.
+func (r *stressRunner) runRound(ctx context.Context, p *phase, targetHeight int64, planned int) (roundResult, error) {
+	st, err := r.client.RPC.Status(ctx)
+	if err != nil {
+		return roundResult{}, fmt.Errorf("get status: %w", err)
+	}
+	if st.SyncInfo.LatestBlockHeight != targetHeight-1 {
+		log.Warn().Int64("expected", targetHeight-1).Int64("got", st.SyncInfo.LatestBlockHeight).Msg("mismatching block height")
+		targetHeight = st.SyncInfo.LatestBlockHeight + 1
+	}
+	ctx, span := tracing.Start(ctx, "Round",
+		tracing.ScenarioKey.String(p.name),
+		tracing.HeightKey.Int64(targetHeight))
+	defer span.End()
+
+	started := time.Now()
+	msgs := make([]map[string][]sdk.Msg, len(p.workers.Workers))
+	for i := range msgs {
+		msgs[i] = make(map[string][]sdk.Msg)
+	}
+	var codesMu sync.Mutex
+	codes := make(map[uint32]int)
+	classes := make(errclass.Histogram)
+	sent, err := p.workers.RunUntil(ctx, r.interrupted, planned, func(ctx context.Context, w *load.Worker) (load.Outcome, error) {
+		msgType := p.mix.Pick()
+		if msgs[w.ID][msgType] == nil {
+			m, err := p.createMsgs(ctx, r.tx, w, msgType)
+			if err != nil {
+				return 0, fmt.Errorf("generate %s msgs: %s", msgType, err)
+			}
+			msgs[w.ID][msgType] = m
+		}
+
+		accNum, accSeq, err := r.seqs.Reserve(ctx, w.Addr)
+		if err != nil {
+			return 0, fmt.Errorf("reserve sequence: %w", err)
+		}
+		txByte, err := r.tx.SignWith(ctx, w.Signer, accSeq, accNum, msgs[w.ID][msgType]...)
+		if err != nil {
+			r.seqs.Release(w.Addr, accSeq)
+			return 0, fmt.Errorf("sign tx: %w", err)
+		}
+		broadcastAt := time.Now()
+		resp, err := r.tx.Broadcast(ctx, w.Addr, txByte)
+		if err != nil {
+			r.seqs.Invalidate(w.Addr)
+			return 0, fmt.Errorf("broadcast tx: %w", err)
+		}
+		if resp.TxResponse.Code == 0 && r.inclusion != nil {
+			r.inclusion.Broadcast(resp.TxResponse.TxHash, w.Addr, r.tx.GasPrice(w.Addr, accSeq), broadcastAt)
+		}
+		p.stats.addBroadcast(msgType, resp.TxResponse.Code == 0)
+		r.summary.AddResponse(resp.TxResponse.Codespace, resp.TxResponse.Code)
+		r.mempool.RecordCode(resp.TxResponse.Code)
+		codesMu.Lock()
+		codes[resp.TxResponse.Code]++
+		e := classes.Add(resp.TxResponse.Codespace, resp.TxResponse.Code)
+		codesMu.Unlock()
+		if e.Class == errclass.ClassOK {
+			return load.Sent, nil
+		}
+		r.seqs.Reject(w.Addr, accSeq, resp.TxResponse.Codespace, resp.TxResponse.Code, resp.TxResponse.RawLog)
+		return r.handleRejected(ctx, w, e, resp.TxResponse.RawLog)
+	})
+	if err != nil {
+		return roundResult{}, err
+	}
+	log.Debug().Msgf("took %s broadcasting txs", time.Since(started))
+
+	block, blockDuration, err := r.bw.Wait(ctx, targetHeight)
+	if err != nil {
+		return roundResult{}, err
+	}
+	// only the txs of the workers are counted, not the ones of the other users of the chain
+	committedByType := countCommittedByMsgType(r.client.GetCLIContext().TxConfig.TxDecoder(), block.Txs, p.accounts)
+	committed := totalCommitted(committedByType)
+	log.Info().
+		Int64("height", targetHeight).
+		Str("block-time", block.Time.Format(time.RFC3339Nano)).
+		Str("block-duration", blockDuration.String()).
+		Int("broadcast-txs", sent).
+		Int("committed-txs", committed).
+		Int("block-txs", len(block.Txs)).
+		Int("planned-txs", planned).
+		Msg("block committed")
+
+	res := roundResult{
+		scenario:      p.name,
+		height:        targetHeight,
+		blockTime:     block.Time,
+		planned:       planned,
+		sent:          sent,
+		committed:     committed,
+		blockDuration: blockDuration,
+		codes:         codes,
+		classes:       classes.Counts(),
+	}
+	if err := r.gas.Collect(ctx, targetHeight, block.Txs, r.summary); err != nil {
+		log.Warn().Err(err).Msg("failed to collect gas")
+	}
+	p.stats.addCommitted(committedByType)
+	res.msgTypes = p.stats.flushRound(p.mix.Types())
+	if err := r.report(ctx, res); err != nil {
+		return roundResult{}, fmt.Errorf("report result: %w", err)
+	}
+	r.summary.AddBlock(targetHeight, sent, committed)
+
+	return res, nil
 }
 
-func (d *AccountDispenser) PrivKey() *secp256k1.PrivKey {
-	return d.privKey
+// handleRejected handles a tx rejected with the given error by the policy of its class. The sequence of
+// the tx has already been given back or resynced.
+func (r *stressRunner) handleRejected(ctx context.Context, w *load.Worker, e errclass.Error, rawLog string) (load.Outcome, error) {
+	policy := r.policies.Of(e.Class)
+	log.Warn().Int("worker", w.ID).Str("addr", w.Addr).Str("error", e.String()).Str("policy", string(policy)).Str("log", rawLog).Msg("tx rejected")
+	switch policy {
+	case errclass.PolicyRetry:
+		// the worker backs off before trying again, unless the run is cancelled or interrupted meanwhile
+		select {
+		case <-ctx.Done():
+		case <-r.interrupted:
+		case <-time.After(retryBackoff):
+		}
+		return load.Failed, nil
+	case errclass.PolicySwitchAccount:
+		return load.StopWorker, nil
+	case errclass.PolicySkip:
+		return load.Skipped, nil
+	case errclass.PolicyStopRound:
+		return load.StopRound, nil
+	default:
+		return 0, fmt.Errorf("tx rejected with %s: %s", e, rawLog)
+	}
 }
 
-func (d *AccountDispenser) AccSeq() uint64 {
-	return d.accSeq
+// sinkReporter returns a reporter which writes the results to the result sink.
+func sinkReporter(s sink.ResultSink) func(context.Context, roundResult) error {
+	return func(ctx context.Context, res roundResult) error {
+		for _, rec := range res.records() {
+			if err := s.Write(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 }
 
-func (d *AccountDispenser) AccNum() uint64 {
-	return d.accNum
+// coolDown waits for the mempool to be emptied and then for the given duration.
+// It returns early when the run is interrupted.
+func (r *stressRunner) coolDown(ctx context.Context, d time.Duration) error {
+	started := time.Now()
+	log.Debug().Msg("cooling down")
+	for {
+		st, err := r.client.RPC.NumUnconfirmedTxs(ctx)
+		if err != nil {
+			return fmt.Errorf("get status: %w", err)
+		}
+		if st.Total == 0 {
+			break
+		}
+		if !r.sleep(5 * time.Second) {
+			return nil
+		}
+	}
+	log.Debug().Str("elapsed", time.Since(started).String()).Msg("done cooling down")
+	r.sleep(d)
+	return nil
 }
 
-func (d *AccountDispenser) IncAccSeq() uint64 {
-	r := d.accSeq
-	d.accSeq++
-	return r
+// sleep sleeps for the given duration and returns false if the run is interrupted meanwhile.
+func (r *stressRunner) sleep(d time.Duration) bool {
+	select {
+	case <-r.interrupted:
+		return false
+	case <-time.After(d):
+		return true
+	}
 }
 
-func (d *AccountDispenser) DecAccSeq() {
-	d.accSeq--
+// saveCheckpoint records the scenario and the round to continue from with --resume.
+func (r *stressRunner) saveCheckpoint(scenarios []scenario.Scenario, no, round int) error {
+	if r.checkpointPath == "" {
+		return nil
+	}
+	if round >= scenarios[no].Rounds {
+		no, round = no+1, 0
+	}
+	cp := checkpoint{
+		ScenarioFile: r.scenarioPath,
+		Scenario:     no,
+		Round:        round,
+		Seed:         r.seed,
+	}
+	if no < len(scenarios) {
+		cp.ScenarioName = scenarios[no].Name
+	}
+	return writeCheckpoint(r.checkpointPath, cp)
 }
 
-type Scenario struct {
-	Rounds         int
-	NumTxsPerBlock int
+// runScenarios runs every round of the scenarios in order, starting from the given scenario and round.
+// The checkpoint is updated after every round and removed once every scenario is done.
+// It returns without error when the run is interrupted.
+func (r *stressRunner) runScenarios(ctx context.Context, scenarios []scenario.Scenario, startScenario, startRound int) error {
+	if err := r.saveCheckpoint(scenarios, startScenario, startRound); err != nil {
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+
+	for no := startScenario; no < len(scenarios); no++ {
+		if isInterrupted(r.interrupted) {
+			return nil
+		}
+
+		s := scenarios[no]
+		firstRound := 0
+		if no == startScenario {
+			firstRound = startRound
+		}
+
+		profile, err := s.LoadProfile()
+		if err != nil {
+			return err
+		}
+
+		p, err := r.preparePhase(ctx, no, s)
+		if err != nil {
+			return err
+		}
+
+		targetHeight, err := r.waitStart(ctx, no)
+		if err != nil {
+			return err
+		}
+		log.Info().Msgf("starting simulation #%d(%s), rounds = %d, first round = %d, load profile = %s, msg mix = %s", no+1, s.Name, s.Rounds, firstRound+1, profile, p.mix)
+
+		for i := firstRound; i < s.Rounds; i++ {
+			res, err := r.runRound(ctx, p, targetHeight, r.share.Part(profile.Count(i)))
+			if err != nil {
+				return err
+			}
+			targetHeight = res.height + 1
+
+			if err := r.saveCheckpoint(scenarios, no, i+1); err != nil {
+				return fmt.Errorf("save checkpoint: %w", err)
+			}
+			if isInterrupted(r.interrupted) {
+				log.Warn().Msgf("stopped after round %d of simulation #%d(%s)", i+1, no+1, s.Name)
+				return nil
+			}
+		}
+
+		for _, w := range p.workers.Workers {
+			log.Info().Int("worker", w.ID).Str("addr", w.Addr).Int("sent", w.Sent).Int("failed", w.Failed).Msg("worker stats")
+		}
+		for _, msgType := range p.mix.Types() {
+			c := p.stats.totals[msgType]
+			log.Info().Str("msg-type", msgType).Int("broadcast", c.broadcast).Int("failed", c.failed).Int("committed", c.committed).Msg("msg type stats")
+		}
+		if err := r.coolDown(ctx, s.CoolDown); err != nil {
+			return err
+		}
+	}
+
+	if r.checkpointPath == "" {
+		return nil
+	}
+	if err := os.Remove(r.checkpointPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove checkpoint: %w", err)
+	}
+	return nil
 }
 
-var (
-	scenarios = []Scenario{
-		{100, 100},
-		{100, 200},
-		{100, 300},
-		{100, 400},
-		{100, 500},
-	}
-	//scenarios = []Scenario{
-	//	{5, 10},
-	//	{5, 50},
-	//	{5, 100},
-	//	{5, 200},
-	//	{5, 300},
-	//	{5, 400},
-	//	{5, 500},
-	//}
-)
+// findCapacity searches the highest number of txs per block the network keeps up with,
+// running the first scenario at every probed load. When the run is interrupted,
+// the probe in progress is dropped and the probes done so far are reported.
+func (r *stressRunner) findCapacity(ctx context.Context, s scenario.Scenario, search *load.CapacitySearch, probeBlocks int) error {
+	p, err := r.preparePhase(ctx, 0, s)
+	if err != nil {
+		return err
+	}
+
+	for {
+		n, ok := search.Next()
+		if !ok {
+			break
+		}
+
+		targetHeight, err := r.waitStart(ctx, 0)
+		if err != nil {
+			return err
+		}
+		log.Info().Msgf("probing %d txs per block for %d blocks", n, probeBlocks)
+
+		probe := load.Probe{NumTxsPerBlock: n}
+		p.name = fmt.Sprintf("%s@%d", s.Name, n)
+		for i := 0; i < probeBlocks && !isInterrupted(r.interrupted); i++ {
+			res, err := r.runRound(ctx, p, targetHeight, n)
+			if err != nil {
+				return err
+			}
+			probe.Add(res.sent, res.committed, res.blockDuration)
+			targetHeight = res.height + 1
+		}
+		if isInterrupted(r.interrupted) {
+			break
+		}
+
+		pass := search.Record(probe)
+		log.Info().
+			Int("txs-per-block", n).
+			Float64("commit-ratio", probe.CommitRatio()).
+			Str("avg-block-duration", probe.AvgBlockDuration().String()).
+			Bool("pass", pass).
+			Msg("probe done")
+
+		if err := r.coolDown(ctx, s.CoolDown); err != nil {
+			return err
+		}
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "txs_per_block\tblocks\tbroadcast\tcommitted\tcommit_ratio\tavg_block_duration\tmax_block_duration\tpass")
+	for _, probe := range search.Probes {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%.3f\t%s\t%s\t%t\n",
+			probe.NumTxsPerBlock,
+			probe.Blocks,
+			probe.Broadcast,
+			probe.Committed,
+			probe.CommitRatio(),
+			probe.AvgBlockDuration(),
+			probe.MaxBlockDuration,
+			search.Thresholds.Pass(probe),
+		)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("capacity: %d txs per block\n", search.Capacity())
+
+	return nil
+}
 
 func StressTestCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "stress-test [pool-id] [offer-coin]",
+		Use:   "stress-test",
 		Short: "run stress test",
-		Args:  cobra.ExactArgs(2),
+		Args:  cobra.NoArgs,
+		Long: `Run stress test with the scenarios described in the scenario file.
+
+With --find-capacity, the first scenario is run at adaptively searched numbers of txs per block instead,
+to find the highest one at which the commit ratio and the block duration stay within the thresholds.
+
+On interrupt, the transactions in flight and the block of the current round are waited for, the results are
+written and a summary is printed. The next scenario and round are recorded in the checkpoint file after every
+round, so that an interrupted run can be continued with --resume. Interrupt again to quit immediately.
+
+Example: $ tester stress-test --scenario ./scenario.toml
+Example: $ tester stress-test --find-capacity --min-txs 10 --max-txs 5000 --min-commit-ratio 0.95 --max-block-duration 10s
+Example: $ tester stress-test --resume
+`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cmd.SilenceUsage = true
 
-			ctx := context.Background()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
 
 			err := SetLogger(logLevel)
 			if err != nil {
 				return fmt.Errorf("set logger: %w", err)
 			}
 
+			interrupted, stopNotify := notifyInterrupt()
+			defer stopNotify()
+
+			resume, err := cmd.Flags().GetBool(flagResume)
+			if err != nil {
+				return err
+			}
+			checkpointPath, err := cmd.Flags().GetString(flagCheckpoint)
+			if err != nil {
+				return err
+			}
+			findCapacity, err := cmd.Flags().GetBool(flagFindCapacity)
+			if err != nil {
+				return err
+			}
+			if findCapacity && resume {
+				return fmt.Errorf("--%s cannot be used with --%s", flagResume, flagFindCapacity)
+			}
+
+			var cp checkpoint
+			if resume {
+				cp, err = readCheckpoint(checkpointPath)
+				if err != nil {
+					return fmt.Errorf("read checkpoint: %w", err)
+				}
+				// regenerate the same messages unless another seed is given
+				if !cmd.Flags().Changed(flagSeed) {
+					if err := cmd.Flags().Set(flagSeed, strconv.FormatInt(cp.Seed, 10)); err != nil {
+						return err
+					}
+				}
+			}
+
+			run, err := startRun(cmd, args)
+			if err != nil {
+				return fmt.Errorf("start run: %w", err)
+			}
+			dash, stopDashboard, err := startDashboard(cmd)
+			if err != nil {
+				return err
+			}
+			defer stopDashboard()
+			run.Dashboard = dash
+
 			cfg, err := config.Read(config.DefaultConfigPath)
 			if err != nil {
 				return fmt.Errorf("read config: %w", err)
@@ -134,30 +701,28 @@ func StressTestCmd() *cobra.Command {
 			}
 			defer client.Stop() // nolint: errcheck
 
-			poolID, err := strconv.ParseUint(args[0], 10, 64)
+			scenarioPath, err := cmd.Flags().GetString(flagScenario)
 			if err != nil {
-				return fmt.Errorf("invalid pool id: %w", err)
+				return err
 			}
 
-			offerCoin, err := sdk.ParseCoinNormalized(args[1])
+			scenarioFile, err := scenario.Read(scenarioPath)
 			if err != nil {
-				return fmt.Errorf("invalid offer coin: %w", err)
-			}
-
-			if err := offerCoin.Validate(); err != nil {
-				return fmt.Errorf("invalid offer coin: %w", err)
+				return fmt.Errorf("read scenario: %w", err)
 			}
 
-			pool, err := client.GRPC.GetPool(ctx, poolID)
-			if err != nil {
-				return fmt.Errorf("get pool: %w", err)
+			if err := scenarioFile.Validate(numAccounts(cfg.Custom)); err != nil {
+				return fmt.Errorf("invalid scenario: %w", err)
 			}
 
-			var demandCoinDenom string
-			if pool.ReserveCoinDenoms[0] == offerCoin.Denom {
-				demandCoinDenom = pool.ReserveCoinDenoms[1]
-			} else {
-				demandCoinDenom = pool.ReserveCoinDenoms[0]
+			if resume {
+				if cp.Scenario >= len(scenarioFile.Scenarios) {
+					return fmt.Errorf("checkpoint is past the last scenario: %d", cp.Scenario)
+				}
+				if name := scenarioFile.Scenarios[cp.Scenario].Name; name != cp.ScenarioName {
+					return fmt.Errorf("checkpoint is of scenario %q, but the scenario file has %q", cp.ScenarioName, name)
+				}
+				log.Info().Msgf("resuming from round %d of simulation #%d(%s)", cp.Round+1, cp.Scenario+1, cp.ScenarioName)
 			}
 
 			chainID, err := client.RPC.GetNetworkChainID(ctx)
@@ -168,169 +733,162 @@ func StressTestCmd() *cobra.Command {
 			gasLimit := uint64(cfg.Custom.GasLimit)
 			fees := sdk.NewCoins(sdk.NewCoin(cfg.Custom.FeeDenom, sdk.NewInt(cfg.Custom.FeeAmount)))
 			memo := cfg.Custom.Memo
+			seqs := tx.NewSequences(client.GRPC)
 			tx := tx.NewTransaction(client, chainID, gasLimit, fees, memo)
 
-			f, err := os.OpenFile("result.csv", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+			m, stopMetrics, err := startMetrics(cmd)
 			if err != nil {
-				return fmt.Errorf("open file: %w", err)
+				return err
 			}
-			defer f.Close()
-			w := csv.NewWriter(f)
-			fi, err := f.Stat()
+			defer stopMetrics()
+			stopTracing, err := startTracing(cmd)
 			if err != nil {
-				return fmt.Errorf("stat file: %w", err)
-			}
-			if fi.Size() == 0 {
-				if err := w.Write([]string{
-					"height",
-					"block_time",
-					"block_duration",
-					"num_broadcast_txs",
-					"num_committed_txs",
-					"planned_num_broadcast_txs",
-				}); err != nil {
-					return fmt.Errorf("emit header: %w", err)
-				}
-				w.Flush()
-				if err := w.Error(); err != nil {
-					return fmt.Errorf("write header: %w", err)
-				}
+				return err
 			}
+			defer stopTracing()
+			tx.Metrics = m
 
-			d := NewAccountDispenser(client, cfg.Custom.Mnemonics)
-			if err := d.Next(); err != nil {
-				return fmt.Errorf("get next account: %w", err)
+			sampler, err := startMempoolSampler(ctx, cmd, run, m)
+			if err != nil {
+				return err
 			}
+			defer sampler.Close()
 
-			blockTimes := make(map[int64]time.Time)
-
-			for no, scenario := range scenarios {
-				st, err := client.RPC.Status(ctx)
-				if err != nil {
-					return fmt.Errorf("get status: %w", err)
-				}
-				startingHeight := st.SyncInfo.LatestBlockHeight + 2
-				log.Info().Msgf("current block height is %d, waiting for the next block to be committed", st.SyncInfo.LatestBlockHeight)
-
-				if err := rpcclient.WaitForHeight(client.RPC, startingHeight-1, nil); err != nil {
-					return fmt.Errorf("wait for height: %w", err)
-				}
-				log.Info().Msgf("starting simulation #%d, rounds = %d, num txs per block = %d", no+1, scenario.Rounds, scenario.NumTxsPerBlock)
-
-				targetHeight := startingHeight
-
-				for i := 0; i < scenario.Rounds; i++ {
-					st, err := client.RPC.Status(ctx)
-					if err != nil {
-						return fmt.Errorf("get status: %w", err)
-					}
-					if st.SyncInfo.LatestBlockHeight != targetHeight-1 {
-						log.Warn().Int64("expected", targetHeight-1).Int64("got", st.SyncInfo.LatestBlockHeight).Msg("mismatching block height")
-						targetHeight = st.SyncInfo.LatestBlockHeight + 1
-					}
+			inclusion, err := startInclusionTracker(ctx, cmd, client)
+			if err != nil {
+				return err
+			}
 
-					started := time.Now()
-					sent := 0
-				loop:
-					for sent < scenario.NumTxsPerBlock {
-						msgs, err := tx.CreateSwapBot(ctx, d.Addr(), poolID, offerCoin, demandCoinDenom, 1)
-						if err != nil {
-							return fmt.Errorf("generate msgs: %s", err)
-						}
-
-						for sent < scenario.NumTxsPerBlock {
-							accSeq := d.IncAccSeq()
-							txByte, err := tx.Sign(ctx, accSeq, d.AccNum(), d.PrivKey(), msgs...)
-							if err != nil {
-								return fmt.Errorf("sign tx: %w", err)
-							}
-							resp, err := client.GRPC.BroadcastTx(ctx, txByte)
-							if err != nil {
-								return fmt.Errorf("broadcast tx: %w", err)
-							}
-							if resp.TxResponse.Code != 0 {
-								if resp.TxResponse.Code == 0x14 {
-									log.Warn().Msg("mempool is full, stopping")
-									d.DecAccSeq()
-									break loop
-								}
-								if resp.TxResponse.Code == 0x13 || resp.TxResponse.Code == 0x20 {
-									if err := d.Next(); err != nil {
-										return fmt.Errorf("get next account: %w", err)
-									}
-									log.Warn().Str("addr", d.Addr()).Uint64("seq", d.AccSeq()).Msgf("received %#v, using next account", resp.TxResponse)
-									time.Sleep(500 * time.Millisecond)
-									break
-								} else {
-									panic(fmt.Sprintf("%#v\n", resp.TxResponse))
-								}
-							}
-							sent++
-						}
-					}
-					log.Debug().Msgf("took %s broadcasting txs", time.Since(started))
+			gas, err := newGasTracker(cmd, client)
+			if err != nil {
+				return err
+			}
+			stopGas, err := startGasEstimation(cmd, run, tx, gas)
+			if err != nil {
+				return err
+			}
+			defer stopGas()
+			stopConfirmations, err := startConfirmations(ctx, cmd, run, tx, seqs)
+			if err != nil {
+				return err
+			}
+			defer stopConfirmations()
+			if err := setGasPrices(cmd, run, tx, cfg.Custom.GasPrices); err != nil {
+				return err
+			}
 
-					if err := rpcclient.WaitForHeight(client.RPC, targetHeight, nil); err != nil {
-						return fmt.Errorf("wait for height: %w", err)
-					}
+			policies, err := errorPolicies(cmd, errclass.DefaultPolicies())
+			if err != nil {
+				return err
+			}
 
-					r, err := client.RPC.Block(ctx, &targetHeight)
-					if err != nil {
-						return err
-					}
-					var blockDuration time.Duration
-					bt, ok := blockTimes[targetHeight-1]
-					if !ok {
-						log.Warn().Msg("past block time not found")
-					} else {
-						blockDuration = r.Block.Time.Sub(bt)
-						delete(blockTimes, targetHeight-1)
-					}
-					blockTimes[targetHeight] = r.Block.Time
-					log.Info().
-						Int64("height", targetHeight).
-						Str("block-time", r.Block.Time.Format(time.RFC3339Nano)).
-						Str("block-duration", blockDuration.String()).
-						Int("broadcast-txs", sent).
-						Int("committed-txs", len(r.Block.Txs)).
-						Msg("block committed")
-
-					if err := w.Write([]string{
-						strconv.FormatInt(targetHeight, 10),
-						r.Block.Time.Format(time.RFC3339Nano),
-						blockDuration.String(),
-						strconv.Itoa(sent),
-						strconv.Itoa(len(r.Block.Txs)),
-						strconv.Itoa(scenario.NumTxsPerBlock),
-					}); err != nil {
-						return fmt.Errorf("emit row: %w", err)
-					}
-					w.Flush()
-					if err := w.Error(); err != nil {
-						return fmt.Errorf("write row: %w", err)
+			results, err := openResultSink(cmd, run, chainID)
+			if err != nil {
+				return err
+			}
+			defer results.Close()
+
+			r := &stressRunner{
+				client: client,
+				cfg:    cfg,
+				tx:     tx,
+				seqs:   seqs,
+				bw:     newBlockWatcher(client, m, chainID),
+				seed:   run.Seed,
+				report: sinkReporter(results),
+
+				interrupted: interrupted,
+				summary:     load.NewSummary(),
+				inclusion:   inclusion,
+				gas:         gas,
+				mempool:     sampler.Watch(client, chainID),
+				policies:    policies,
+
+				scenarioPath:   scenarioPath,
+				checkpointPath: checkpointPath,
+			}
+			defer func() {
+				if inclusion != nil {
+					if err := reportInclusion(cmd, inclusion, run.ID, chainID); err != nil {
+						log.Err(err).Msg("failed to report inclusion")
 					}
-
-					targetHeight++
 				}
-
-				started := time.Now()
-				log.Debug().Msg("cooling down")
-				for {
-					st, err := client.RPC.NumUnconfirmedTxs(ctx)
-					if err != nil {
-						return fmt.Errorf("get status: %w", err)
-					}
-					if st.Total == 0 {
-						break
+				if err := r.summary.Print(os.Stdout); err != nil {
+					log.Err(err).Msg("failed to print summary")
+				}
+				if gas != nil {
+					if err := reportGas(cmd, run.ID, gas); err != nil {
+						log.Err(err).Msg("failed to report gas")
 					}
-					time.Sleep(5 * time.Second)
 				}
-				log.Debug().Str("elapsed", time.Since(started).String()).Msg("done cooling down")
-				time.Sleep(time.Minute)
+				if isInterrupted(interrupted) && !findCapacity {
+					log.Warn().Str("checkpoint", checkpointPath).Msg("run interrupted; continue it with --resume")
+				}
+			}()
+
+			if !findCapacity {
+				return r.runScenarios(ctx, scenarioFile.Scenarios, cp.Scenario, cp.Round)
 			}
 
-			return nil
+			minTxs, err := cmd.Flags().GetInt(flagMinTxs)
+			if err != nil {
+				return err
+			}
+			maxTxs, err := cmd.Flags().GetInt(flagMaxTxs)
+			if err != nil {
+				return err
+			}
+			resolution, err := cmd.Flags().GetInt(flagResolution)
+			if err != nil {
+				return err
+			}
+			probeBlocks, err := cmd.Flags().GetInt(flagProbeBlocks)
+			if err != nil {
+				return err
+			}
+			minCommitRatio, err := cmd.Flags().GetFloat64(flagMinCommitRatio)
+			if err != nil {
+				return err
+			}
+			maxBlockDuration, err := cmd.Flags().GetDuration(flagMaxBlockDuration)
+			if err != nil {
+				return err
+			}
+			if minTxs <= 0 || maxTxs < minTxs {
+				return fmt.Errorf("invalid range of txs per block: [%d, %d]", minTxs, maxTxs)
+			}
+			if probeBlocks <= 0 {
+				return fmt.Errorf("probe blocks must be positive: %d", probeBlocks)
+			}
+
+			search := load.NewCapacitySearch(minTxs, maxTxs, resolution, load.Thresholds{
+				MinCommitRatio:   minCommitRatio,
+				MaxBlockDuration: maxBlockDuration,
+			})
+			return r.findCapacity(ctx, scenarioFile.Scenarios[0], search, probeBlocks)
 		},
 	}
+	cmd.Flags().String(flagScenario, scenario.DefaultScenarioPath, "path to the scenario file")
+	cmd.Flags().Bool(flagFindCapacity, false, "search the highest number of txs per block within the thresholds instead of running the scenarios")
+	cmd.Flags().Int(flagMinTxs, 10, "lowest number of txs per block to probe")
+	cmd.Flags().Int(flagMaxTxs, 5000, "highest number of txs per block to probe")
+	cmd.Flags().Int(flagResolution, 10, "stop searching when the capacity is known within this number of txs per block")
+	cmd.Flags().Int(flagProbeBlocks, 5, "number of blocks to measure every probed load")
+	cmd.Flags().Float64(flagMinCommitRatio, 0.95, "lowest ratio of committed to broadcast txs to keep up with the load")
+	cmd.Flags().Duration(flagMaxBlockDuration, 10*time.Second, "highest average block duration to keep up with the load; 0 to disable")
+	cmd.Flags().Bool(flagResume, false, "continue an interrupted run from the scenario and round recorded in the checkpoint file")
+	cmd.Flags().String(flagCheckpoint, DefaultCheckpointPath, "path to the checkpoint file")
+	addSeedFlag(cmd)
+	addOutputFlags(cmd)
+	addGasFlags(cmd)
+	addGasEstimationFlags(cmd)
+	addBroadcastFlags(cmd)
+	addFeeFlags(cmd)
+	addErrorPolicyFlag(cmd, errclass.DefaultPolicies())
+	addMetricsFlag(cmd)
+	addTracingFlags(cmd)
+	addMempoolFlags(cmd)
+	addDashboardFlags(cmd)
+	addInclusionFlags(cmd)
 	return cmd
 }
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+
+	"github.com/b-harvest/modules-test-tool/client"
+	"github.com/b-harvest/modules-test-tool/tracing"
+)
+
+const (
+	flagTraceEndpoint = "trace-endpoint"
+	flagTraceFile     = "trace-file"
+)
+
+// addTracingFlags adds the flags exporting the spans of a workload command.
+func addTracingFlags(cmd *cobra.Command) {
+	cmd.Flags().String(flagTraceEndpoint, "", "OTLP/HTTP endpoint of a collector to export the spans to, e.g. localhost:4318; disabled if empty")
+	cmd.Flags().String(flagTraceFile, "", "file to append the spans to as OTLP/JSON lines; disabled if empty")
+}
+
+// startTracing exports the spans of the command as set by the tracing flags, and returns the function
+// flushing the remaining spans. Spans are dropped when neither an endpoint nor a file is set.
+func startTracing(cmd *cobra.Command) (func(), error) {
+	endpoint, err := cmd.Flags().GetString(flagTraceEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	file, err := cmd.Flags().GetString(flagTraceFile)
+	if err != nil {
+		return nil, err
+	}
+	shutdown, err := tracing.Setup(tracing.Options{Endpoint: endpoint, File: file, ServiceName: "tester " + cmd.Name()})
+	if err != nil {
+		return nil, fmt.Errorf("set up tracing: %w", err)
+	}
+	return func() {
+		if err := shutdown(context.Background()); err != nil {
+			log.Warn().Err(err).Msg("failed to flush spans")
+		}
+	}, nil
+}
+
+// waitForHeight waits for the block of the given height to be committed.
+func waitForHeight(ctx context.Context, c *client.Client, height int64) error {
+	_, span := tracing.Start(ctx, "WaitForHeight", tracing.HeightKey.Int64(height))
+	err := rpcclient.WaitForHeight(c.RPC, height, nil)
+	tracing.End(span, err)
+	return err
+}
+
+// fetchBlock fetches the committed block of the given height.
+func fetchBlock(ctx context.Context, c *client.Client, height int64) (*coretypes.ResultBlock, error) {
+	ctx, span := tracing.Start(ctx, "GetBlock", tracing.HeightKey.Int64(height))
+	r, err := c.RPC.Block(ctx, &height)
+	if err == nil {
+		span.SetAttributes(tracing.NumTxsKey.Int(len(r.Block.Txs)))
+	}
+	tracing.End(span, err)
+	return r, err
+}
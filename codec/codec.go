@@ -5,6 +5,8 @@ import (
 
 	liqapp "github.com/gravity-devs/liquidity/app"
 	liqappparams "github.com/gravity-devs/liquidity/app/params"
+
+	"github.com/b-harvest/modules-test-tool/signer/ethsecp256k1"
 )
 
 // Codec is the application-wide Amino codec and is initialized upon package loading.
@@ -14,9 +16,11 @@ var (
 	EncodingConfig liqappparams.EncodingConfig
 )
 
-// SetCodec sets encoding config.
+// SetCodec sets encoding config. The keys of the Ethermint based chains are registered as well,
+// so that their txs can be decoded.
 func SetCodec() {
 	EncodingConfig = liqapp.MakeEncodingConfig()
+	ethsecp256k1.RegisterInterfaces(EncodingConfig.InterfaceRegistry)
 	AppCodec = EncodingConfig.Marshaler
 	AminoCodec = EncodingConfig.Amino
 }
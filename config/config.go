@@ -43,7 +43,17 @@ type CustomConfig struct {
 	GasLimit  int64    `toml:"gas_limit"`
 	FeeDenom  string   `toml:"fee_denom"`
 	FeeAmount int64    `toml:"fee_amount"`
+	GasPrices string   `toml:"gas_prices"` // fees from the gas limits at the gas prices instead of fee_amount, if set
 	Memo      string   `toml:"memo"`
+
+	// AccountHD is the HD path of the keys of the mnemonics, the Cosmos one if empty.
+	AccountHD string `toml:"accounthd"`
+	// KeyType is the type of the keys of the accounts, secp256k1 if empty or eth_secp256k1.
+	KeyType string `toml:"keytype"`
+	// KeyNames are the names of the keys in the keyring to sign with, instead of the mnemonics.
+	KeyNames       []string `toml:"keynames"`
+	KeyringBackend string   `toml:"keyringbackend"`
+	KeyringDir     string   `toml:"keyringdir"`
 }
 type IBCchain struct {
 	ChainId           string `toml:"chainid"`
@@ -53,6 +63,13 @@ type IBCchain struct {
 	TokenDenom        string `toml:"tokendenom"`
 	AccountHD         string `toml:"accounthd"`
 	AccountaddrPrefix string `toml:"accountaddrprefix"`
+
+	// KeyType is the type of the keys of the chain, secp256k1 if empty or eth_secp256k1.
+	KeyType string `toml:"keytype"`
+	// KeyName is the name of the key in the keyring to sign with, instead of the mnemonics.
+	KeyName        string `toml:"keyname"`
+	KeyringBackend string `toml:"keyringbackend"`
+	KeyringDir     string `toml:"keyringdir"`
 }
 
 type IBCconfig struct {
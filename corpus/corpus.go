@@ -0,0 +1,97 @@
+package corpus
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// SignedTx is a transaction signed ahead of time to be broadcast at its planned block.
+type SignedTx struct {
+	Account  string `json:"account"`
+	Sequence uint64 `json:"sequence"`
+	MsgType  string `json:"msg_type"`
+	Block    int    `json:"block"`
+	TxBytes  []byte `json:"tx_bytes"`
+}
+
+// Writer writes signed transactions as JSON lines.
+type Writer struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// NewWriter creates a new Writer which writes to the given writer.
+func NewWriter(w io.Writer) *Writer {
+	bw := bufio.NewWriter(w)
+	return &Writer{
+		w:   bw,
+		enc: json.NewEncoder(bw),
+	}
+}
+
+// Write writes a signed transaction.
+func (w *Writer) Write(stx SignedTx) error {
+	return w.enc.Encode(stx)
+}
+
+// Flush writes any buffered data to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// Reader streams signed transactions grouped by their planned block.
+type Reader struct {
+	dec  *json.Decoder
+	next *SignedTx
+	line int
+}
+
+// NewReader creates a new Reader which reads from the given reader.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{dec: json.NewDecoder(bufio.NewReader(r))}
+}
+
+// Next returns the next signed transaction. It returns io.EOF when there is no more.
+func (r *Reader) Next() (SignedTx, error) {
+	if r.next != nil {
+		stx := *r.next
+		r.next = nil
+		return stx, nil
+	}
+	var stx SignedTx
+	if err := r.dec.Decode(&stx); err != nil {
+		if err == io.EOF {
+			return SignedTx{}, io.EOF
+		}
+		return SignedTx{}, fmt.Errorf("decode tx #%d: %w", r.line+1, err)
+	}
+	r.line++
+	return stx, nil
+}
+
+// NextBlock returns all consecutive signed transactions planned for the same block.
+// It returns io.EOF when there is no more.
+func (r *Reader) NextBlock() (int, []SignedTx, error) {
+	first, err := r.Next()
+	if err != nil {
+		return 0, nil, err
+	}
+	txs := []SignedTx{first}
+	for {
+		stx, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+		if stx.Block != first.Block {
+			r.next = &stx
+			break
+		}
+		txs = append(txs, stx)
+	}
+	return first.Block, txs, nil
+}
@@ -0,0 +1,57 @@
+package corpus_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/corpus"
+)
+
+func TestWriteAndReadBlocks(t *testing.T) {
+	txs := []corpus.SignedTx{
+		{Account: "cosmos1a", Sequence: 1, MsgType: "swap", Block: 0, TxBytes: []byte{0x01}},
+		{Account: "cosmos1b", Sequence: 7, MsgType: "swap", Block: 0, TxBytes: []byte{0x02}},
+		{Account: "cosmos1a", Sequence: 2, MsgType: "swap", Block: 1, TxBytes: []byte{0x03}},
+		{Account: "cosmos1a", Sequence: 3, MsgType: "swap", Block: 3, TxBytes: []byte{0x04, 0x05}},
+	}
+
+	var buf bytes.Buffer
+	w := corpus.NewWriter(&buf)
+	for _, stx := range txs {
+		require.NoError(t, w.Write(stx))
+	}
+	require.NoError(t, w.Flush())
+
+	r := corpus.NewReader(&buf)
+
+	block, got, err := r.NextBlock()
+	require.NoError(t, err)
+	require.Equal(t, 0, block)
+	require.Equal(t, txs[:2], got)
+
+	block, got, err = r.NextBlock()
+	require.NoError(t, err)
+	require.Equal(t, 1, block)
+	require.Equal(t, txs[2:3], got)
+
+	block, got, err = r.NextBlock()
+	require.NoError(t, err)
+	require.Equal(t, 3, block)
+	require.Equal(t, txs[3:], got)
+
+	_, _, err = r.NextBlock()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestReadMalformed(t *testing.T) {
+	r := corpus.NewReader(bytes.NewBufferString("{\"block\": 0}\nnot json\n"))
+
+	_, err := r.Next()
+	require.NoError(t, err)
+	_, err = r.Next()
+	require.Error(t, err)
+	require.NotErrorIs(t, err, io.EOF)
+}
@@ -0,0 +1,333 @@
+// Package dashboard renders a live terminal dashboard of a run from the records of its blocks,
+// the samples of the mempools and the logs, with a panel per chain.
+package dashboard
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/b-harvest/modules-test-tool/sink"
+)
+
+// The ANSI escape sequences of the dashboard.
+const (
+	clearScreen = "\x1b[H\x1b[2J"
+	hideCursor  = "\x1b[?25l"
+	showCursor  = "\x1b[?25h"
+)
+
+// DefaultDurations is the number of recent block durations drawn as a sparkline.
+const DefaultDurations = 30
+
+// Chain is the state of the panel of a chain.
+type Chain struct {
+	ID        string
+	Scenario  string
+	Round     int
+	Height    int64
+	Planned   int // txs planned to be broadcast in the last block
+	Achieved  int // txs committed in the last block, or broadcast if not waited for
+	Broadcast int
+	Committed int
+	Durations []time.Duration
+	Codes     map[uint32]int
+
+	MempoolTxs   int
+	MempoolBytes int64
+}
+
+// CommitRatio returns the ratio of the committed txs to the broadcast ones, or NaN if none was committed,
+// as the commands which do not wait for their txs to be committed record none.
+func (c *Chain) CommitRatio() float64 {
+	if c.Committed == 0 || c.Broadcast == 0 {
+		return math.NaN()
+	}
+	return float64(c.Committed) / float64(c.Broadcast)
+}
+
+// Dashboard is the state of the dashboard of a run. It is safe for concurrent use, and
+// recording to a nil dashboard is a no-op.
+type Dashboard struct {
+	mu        sync.Mutex
+	title     string
+	started   time.Time
+	durations int
+	chains    map[string]*Chain
+	order     []string
+
+	logLines int
+	logs     []string
+	partial  []byte
+}
+
+// New returns a dashboard of the given title which keeps the given number of log lines.
+func New(title string, logLines int) *Dashboard {
+	return &Dashboard{
+		title:     title,
+		started:   time.Now(),
+		durations: DefaultDurations,
+		chains:    make(map[string]*Chain),
+		logLines:  logLines,
+	}
+}
+
+func (d *Dashboard) chain(id string) *Chain {
+	c, ok := d.chains[id]
+	if !ok {
+		c = &Chain{ID: id, Codes: make(map[uint32]int)}
+		d.chains[id] = c
+		d.order = append(d.order, id)
+	}
+	return c
+}
+
+// Observe updates the panel of the chain of a record of a block. The records of single message types are ignored.
+func (d *Dashboard) Observe(rec sink.Record) {
+	if d == nil || rec.MsgType != "" {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c := d.chain(rec.ChainID)
+	if c.Scenario != rec.Scenario {
+		c.Scenario = rec.Scenario
+		c.Round = 0
+	}
+	c.Round++
+	c.Height = rec.Height
+	c.Planned = rec.Planned
+	c.Achieved = rec.Committed
+	if rec.Committed == 0 {
+		c.Achieved = rec.Broadcast
+	}
+	c.Broadcast += rec.Broadcast
+	c.Committed += rec.Committed
+	if rec.BlockDuration > 0 {
+		c.Durations = append(c.Durations, rec.BlockDuration)
+		if len(c.Durations) > d.durations {
+			c.Durations = c.Durations[len(c.Durations)-d.durations:]
+		}
+	}
+	for code, n := range rec.Codes {
+		c.Codes[code] += n
+	}
+}
+
+// SetMempool updates the size of the mempool of the chain.
+func (d *Dashboard) SetMempool(chainID string, txs int, bytes int64) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c := d.chain(chainID)
+	c.MempoolTxs = txs
+	c.MempoolBytes = bytes
+}
+
+// Chains returns a copy of the state of the chains in the order they were first seen.
+func (d *Dashboard) Chains() []Chain {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	chains := make([]Chain, 0, len(d.order))
+	for _, id := range d.order {
+		c := *d.chains[id]
+		c.Durations = append([]time.Duration(nil), c.Durations...)
+		c.Codes = make(map[uint32]int, len(d.chains[id].Codes))
+		for code, n := range d.chains[id].Codes {
+			c.Codes[code] = n
+		}
+		chains = append(chains, c)
+	}
+	return chains
+}
+
+// Write appends the written lines to the log pane, which keeps the last lines. It lets the dashboard
+// be the output of the logger.
+func (d *Dashboard) Write(p []byte) (int, error) {
+	if d == nil {
+		return len(p), nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.partial = append(d.partial, p...)
+	for {
+		i := bytes.IndexByte(d.partial, '\n')
+		if i < 0 {
+			break
+		}
+		d.logs = append(d.logs, string(d.partial[:i]))
+		d.partial = d.partial[i+1:]
+	}
+	if len(d.logs) > d.logLines {
+		d.logs = append([]string(nil), d.logs[len(d.logs)-d.logLines:]...)
+	}
+	return len(p), nil
+}
+
+// Wrap returns a result sink which updates the dashboard with the records written to the sink.
+// The sink is returned as is if the dashboard is nil.
+func (d *Dashboard) Wrap(s sink.ResultSink) sink.ResultSink {
+	if d == nil {
+		return s
+	}
+	return &dashboardSink{ResultSink: s, d: d}
+}
+
+type dashboardSink struct {
+	sink.ResultSink
+	d *Dashboard
+}
+
+func (s *dashboardSink) Write(rec sink.Record) error {
+	s.d.Observe(rec)
+	return s.ResultSink.Write(rec)
+}
+
+// Render writes a frame of the dashboard with lines of the given width.
+func (d *Dashboard) Render(w io.Writer, width int) error {
+	chains := d.Chains()
+	d.mu.Lock()
+	logs := append([]string(nil), d.logs...)
+	d.mu.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  elapsed %s\n", d.title, time.Since(d.started).Truncate(time.Second))
+	if len(chains) == 0 {
+		b.WriteString("\nwaiting for the first block...\n")
+	}
+	for _, c := range chains {
+		b.WriteString(rule(c.ID, width))
+		fmt.Fprintf(&b, "scenario   %-20s round %-6d height %d\n", orDash(c.Scenario), c.Round, c.Height)
+		ratio := "-"
+		if r := c.CommitRatio(); !math.IsNaN(r) {
+			ratio = fmt.Sprintf("%.2f%%", r*100)
+		}
+		fmt.Fprintf(&b, "txs/block  target %-6d achieved %-6d commit ratio %s\n", c.Planned, c.Achieved, ratio)
+		fmt.Fprintf(&b, "blocks     %s %s\n", Sparkline(seconds(c.Durations)), durationStats(c.Durations))
+		fmt.Fprintf(&b, "mempool    %d txs, %d bytes\n", c.MempoolTxs, c.MempoolBytes)
+		fmt.Fprintf(&b, "codes      %s\n", formatCodes(c.Codes))
+	}
+	if d.logLines > 0 {
+		b.WriteString(rule("logs", width))
+		for _, l := range logs {
+			b.WriteString(truncate(l, width))
+			b.WriteByte('\n')
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Start redraws the dashboard on the terminal of out at the given interval, until the returned function is
+// called. The last frame is left on the terminal, followed by anything written after it.
+func (d *Dashboard) Start(out io.Writer, width int, interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		io.WriteString(out, hideCursor)
+		for {
+			var frame bytes.Buffer
+			frame.WriteString(clearScreen)
+			_ = d.Render(&frame, width)
+			out.Write(frame.Bytes())
+			select {
+			case <-done:
+				io.WriteString(out, showCursor)
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// Sparkline draws the values as bars scaled from zero to the highest value.
+func Sparkline(values []float64) string {
+	const bars = "▁▂▃▄▅▆▇█"
+	runes := []rune(bars)
+	max := 0.0
+	for _, v := range values {
+		max = math.Max(max, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if max > 0 {
+			i = int(math.Round(v / max * float64(len(runes)-1)))
+		}
+		b.WriteRune(runes[i])
+	}
+	return b.String()
+}
+
+func seconds(ds []time.Duration) []float64 {
+	vs := make([]float64, len(ds))
+	for i, d := range ds {
+		vs[i] = d.Seconds()
+	}
+	return vs
+}
+
+func durationStats(ds []time.Duration) string {
+	if len(ds) == 0 {
+		return "-"
+	}
+	var sum time.Duration
+	for _, d := range ds {
+		sum += d
+	}
+	return fmt.Sprintf("last %s, avg %s", ds[len(ds)-1].Round(time.Millisecond), (sum / time.Duration(len(ds))).Round(time.Millisecond))
+}
+
+func formatCodes(codes map[uint32]int) string {
+	if len(codes) == 0 {
+		return "-"
+	}
+	keys := make([]uint32, 0, len(codes))
+	for code := range codes {
+		keys = append(keys, code)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	fields := make([]string, len(keys))
+	for i, code := range keys {
+		fields[i] = fmt.Sprintf("%d:%d", code, codes[code])
+	}
+	return strings.Join(fields, "  ")
+}
+
+func rule(title string, width int) string {
+	s := "── " + orDash(title) + " "
+	if n := width - len([]rune(s)); n > 0 {
+		s += strings.Repeat("─", n)
+	}
+	return s + "\n"
+}
+
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if width > 0 && len(r) > width {
+		return string(r[:width])
+	}
+	return s
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
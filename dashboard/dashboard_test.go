@@ -0,0 +1,106 @@
+package dashboard_test
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/dashboard"
+	"github.com/b-harvest/modules-test-tool/sink"
+)
+
+type memSink struct {
+	recs []sink.Record
+}
+
+func (s *memSink) Write(rec sink.Record) error {
+	s.recs = append(s.recs, rec)
+	return nil
+}
+
+func (s *memSink) Close() error { return nil }
+
+func TestSparkline(t *testing.T) {
+	require.Equal(t, "▁▅█", dashboard.Sparkline([]float64{0, 1, 2}))
+	require.Equal(t, "██", dashboard.Sparkline([]float64{3, 3}))
+	require.Equal(t, "▁▁", dashboard.Sparkline([]float64{0, 0}))
+	require.Empty(t, dashboard.Sparkline(nil))
+}
+
+func TestDashboard(t *testing.T) {
+	d := dashboard.New("tester stress-test", 2)
+	mem := &memSink{}
+	s := d.Wrap(mem)
+
+	for i, sc := range []string{"warmup", "steady", "steady"} {
+		require.NoError(t, s.Write(sink.Record{
+			ChainID:       "chain-a",
+			Scenario:      sc,
+			Height:        int64(10 + i),
+			BlockDuration: time.Duration(i+1) * time.Second,
+			Planned:       100,
+			Broadcast:     100,
+			Committed:     90,
+			Codes:         map[uint32]int{0: 100, 20: 2},
+		}))
+		require.NoError(t, s.Write(sink.Record{ChainID: "chain-a", Scenario: sc, MsgType: "swap", Broadcast: 100}))
+	}
+	require.NoError(t, s.Write(sink.Record{ChainID: "chain-b", Scenario: "transfer", Height: 5, Broadcast: 10}))
+	d.SetMempool("chain-a", 42, 4096)
+	require.Len(t, mem.recs, 7, "the records are passed through")
+
+	chains := d.Chains()
+	require.Len(t, chains, 2)
+	a := chains[0]
+	require.Equal(t, "chain-a", a.ID)
+	require.Equal(t, "steady", a.Scenario)
+	require.Equal(t, 2, a.Round, "the round restarts with the scenario")
+	require.Equal(t, int64(12), a.Height)
+	require.Equal(t, 90, a.Achieved)
+	require.InDelta(t, 0.9, a.CommitRatio(), 1e-9)
+	require.Equal(t, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}, a.Durations)
+	require.Equal(t, map[uint32]int{0: 300, 20: 6}, a.Codes)
+	require.Equal(t, 42, a.MempoolTxs)
+
+	b := chains[1]
+	require.Equal(t, 10, b.Achieved, "the broadcast txs are achieved when none is committed")
+	require.True(t, math.IsNaN(b.CommitRatio()))
+
+	fmt.Fprint(d, "first\nsecond\nthi")
+	fmt.Fprint(d, "rd\n")
+
+	var buf bytes.Buffer
+	require.NoError(t, d.Render(&buf, 60))
+	out := buf.String()
+	for _, want := range []string{"tester stress-test", "── chain-a", "── chain-b", "round 2", "commit ratio 90.00%",
+		"▃▆█", "42 txs", "0:300  20:6", "second\nthird\n"} {
+		require.Contains(t, out, want)
+	}
+	require.NotContains(t, out, "first")
+	require.Equal(t, 1, strings.Count(out, "commit ratio -"))
+}
+
+func TestNilDashboard(t *testing.T) {
+	var d *dashboard.Dashboard
+	mem := &memSink{}
+	require.Equal(t, mem, d.Wrap(mem))
+	d.Observe(sink.Record{})
+	d.SetMempool("chain", 1, 1)
+	n, err := d.Write([]byte("log\n"))
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+}
+
+func TestStart(t *testing.T) {
+	d := dashboard.New("tester", 0)
+	var buf bytes.Buffer
+	stop := d.Start(&buf, 40, time.Hour)
+	stop()
+	require.Contains(t, buf.String(), "waiting for the first block")
+	require.True(t, strings.HasSuffix(buf.String(), "\x1b[?25h"))
+}
@@ -0,0 +1,338 @@
+// Package errclass classifies the codes of the responses of txs, both of CheckTx and DeliverTx,
+// into named SDK and liquidity errors and groups them into classes handled by a policy.
+package errclass
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	liquiditytypes "github.com/gravity-devs/liquidity/x/liquidity/types"
+)
+
+// Class is a class of errors handled alike.
+type Class string
+
+const (
+	ClassOK          Class = "ok"
+	ClassSequence    Class = "sequence"     // the account sequence is out of sync
+	ClassMempoolFull Class = "mempool_full" // the mempool has no room for the tx
+	ClassFee         Class = "fee"          // the fee or the gas of the tx is not enough
+	ClassFunds       Class = "funds"        // the account has not enough coins
+	ClassLiquidity   Class = "liquidity"    // the liquidity module rejected the msg, e.g. of a bad order
+	ClassInvalidTx   Class = "invalid_tx"   // the tx is malformed or not authorized
+	ClassUnknown     Class = "unknown"
+)
+
+// Classes are the classes in the order they are reported.
+var Classes = []Class{
+	ClassOK,
+	ClassSequence,
+	ClassMempoolFull,
+	ClassFee,
+	ClassFunds,
+	ClassLiquidity,
+	ClassInvalidTx,
+	ClassUnknown,
+}
+
+// Error is a named error of a codespace and a code.
+type Error struct {
+	Codespace string
+	Code      uint32
+	Name      string
+	Class     Class
+}
+
+func (e Error) String() string {
+	return fmt.Sprintf("%s/%d (%s)", e.Codespace, e.Code, e.Name)
+}
+
+type key struct {
+	codespace string
+	code      uint32
+}
+
+var known = make(map[key]Error)
+
+func register(class Class, errs ...*sdkerrors.Error) {
+	for _, err := range errs {
+		k := key{err.Codespace(), err.ABCICode()}
+		known[k] = Error{Codespace: k.codespace, Code: k.code, Name: err.Error(), Class: class}
+	}
+}
+
+func init() {
+	register(ClassSequence,
+		sdkerrors.ErrInvalidSequence,
+		sdkerrors.ErrWrongSequence,
+		// a tx of the same bytes is resent after the sequence went out of sync
+		sdkerrors.ErrTxInMempoolCache,
+	)
+	register(ClassMempoolFull, sdkerrors.ErrMempoolIsFull)
+	register(ClassFee,
+		sdkerrors.ErrInsufficientFee,
+		sdkerrors.ErrOutOfGas,
+	)
+	register(ClassFunds,
+		sdkerrors.ErrInsufficientFunds,
+		liquiditytypes.ErrInsufficientBalance,
+		liquiditytypes.ErrInsufficientPoolCreationFee,
+	)
+	register(ClassInvalidTx,
+		sdkerrors.ErrTxDecode,
+		sdkerrors.ErrUnauthorized,
+		sdkerrors.ErrUnknownRequest,
+		sdkerrors.ErrInvalidAddress,
+		sdkerrors.ErrInvalidPubKey,
+		sdkerrors.ErrUnknownAddress,
+		sdkerrors.ErrInvalidCoins,
+		sdkerrors.ErrMemoTooLarge,
+		sdkerrors.ErrTooManySignatures,
+		sdkerrors.ErrNoSignatures,
+		sdkerrors.ErrInvalidRequest,
+		sdkerrors.ErrTxTooLarge,
+		sdkerrors.ErrorInvalidSigner,
+		sdkerrors.ErrInvalidChainID,
+		sdkerrors.ErrInvalidType,
+		sdkerrors.ErrTxTimeoutHeight,
+		sdkerrors.ErrUnknownExtensionOptions,
+		sdkerrors.ErrUnpackAny,
+	)
+	register(ClassLiquidity,
+		liquiditytypes.ErrPoolNotExists,
+		liquiditytypes.ErrPoolTypeNotExists,
+		liquiditytypes.ErrEqualDenom,
+		liquiditytypes.ErrInvalidDenom,
+		liquiditytypes.ErrNumOfReserveCoin,
+		liquiditytypes.ErrNumOfPoolCoin,
+		liquiditytypes.ErrInsufficientPool,
+		liquiditytypes.ErrLessThanMinInitDeposit,
+		liquiditytypes.ErrNotImplementedYet,
+		liquiditytypes.ErrPoolAlreadyExists,
+		liquiditytypes.ErrPoolBatchNotExists,
+		liquiditytypes.ErrOrderBookInvalidity,
+		liquiditytypes.ErrBatchNotExecuted,
+		liquiditytypes.ErrInvalidPoolCreatorAddr,
+		liquiditytypes.ErrInvalidDepositorAddr,
+		liquiditytypes.ErrInvalidWithdrawerAddr,
+		liquiditytypes.ErrInvalidSwapRequesterAddr,
+		liquiditytypes.ErrBadPoolCoinAmount,
+		liquiditytypes.ErrBadDepositCoinsAmount,
+		liquiditytypes.ErrBadOfferCoinAmount,
+		liquiditytypes.ErrBadOrderingReserveCoin,
+		liquiditytypes.ErrBadOrderPrice,
+		liquiditytypes.ErrNumOfReserveCoinDenoms,
+		liquiditytypes.ErrEmptyReserveAccountAddress,
+		liquiditytypes.ErrEmptyPoolCoinDenom,
+		liquiditytypes.ErrBadOrderingReserveCoinDenoms,
+		liquiditytypes.ErrBadReserveAccountAddress,
+		liquiditytypes.ErrBadPoolCoinDenom,
+		liquiditytypes.ErrExceededMaxOrderable,
+		liquiditytypes.ErrBadBatchMsgIndex,
+		liquiditytypes.ErrSwapTypeNotExists,
+		liquiditytypes.ErrLessThanMinOfferAmount,
+		liquiditytypes.ErrBadOfferCoinFee,
+		liquiditytypes.ErrNotMatchedReserveCoin,
+		liquiditytypes.ErrBadPoolTypeID,
+		liquiditytypes.ErrExceededReserveCoinLimit,
+		liquiditytypes.ErrDepletedPool,
+		liquiditytypes.ErrCircuitBreakerEnabled,
+		liquiditytypes.ErrOverflowAmount,
+	)
+}
+
+// Classify returns the named error of the codespace and the code of a response. A response of code 0 is
+// of ClassOK, and an empty codespace is taken for the one of the SDK, as the responses of older nodes lack it.
+func Classify(codespace string, code uint32) Error {
+	if code == 0 {
+		return Error{Codespace: codespace, Name: "ok", Class: ClassOK}
+	}
+	if codespace == "" {
+		codespace = sdkerrors.RootCodespace
+	}
+	if e, ok := known[key{codespace, code}]; ok {
+		return e
+	}
+	return Error{Codespace: codespace, Code: code, Name: "unknown", Class: ClassUnknown}
+}
+
+// Policy is how a rejected tx is handled.
+type Policy string
+
+const (
+	// PolicyRetry resyncs the account sequence if out of sync and tries the tx again.
+	PolicyRetry Policy = "retry"
+	// PolicySwitchAccount moves on to another account.
+	PolicySwitchAccount Policy = "switch_account"
+	// PolicySkip drops the tx without trying it again.
+	PolicySkip Policy = "skip"
+	// PolicyStopRound stops sending txs until the next round.
+	PolicyStopRound Policy = "stop_round"
+	// PolicyAbort aborts the run.
+	PolicyAbort Policy = "abort"
+)
+
+var policies = []Policy{PolicyRetry, PolicySwitchAccount, PolicySkip, PolicyStopRound, PolicyAbort}
+
+// Policies are the policies per class.
+type Policies map[Class]Policy
+
+// DefaultPolicies returns the default policies: a full mempool stops the round, an out of sync sequence
+// is retried, an account without funds is switched, rejected liquidity msgs are skipped and anything else aborts.
+func DefaultPolicies() Policies {
+	return Policies{
+		ClassSequence:    PolicyRetry,
+		ClassMempoolFull: PolicyStopRound,
+		ClassFee:         PolicyAbort,
+		ClassFunds:       PolicySwitchAccount,
+		ClassLiquidity:   PolicySkip,
+		ClassInvalidTx:   PolicyAbort,
+		ClassUnknown:     PolicyAbort,
+	}
+}
+
+// ParsePolicies parses a comma-separated list of class=policy, e.g. "liquidity=abort,unknown=skip".
+func ParsePolicies(s string) (Policies, error) {
+	p := make(Policies)
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("policy %q must be of class=policy", field)
+		}
+		class, policy := Class(strings.TrimSpace(kv[0])), Policy(strings.TrimSpace(kv[1]))
+		if class == ClassOK || !containsClass(class) {
+			return nil, fmt.Errorf("unknown error class %q", class)
+		}
+		if !containsPolicy(policy) {
+			return nil, fmt.Errorf("unknown policy %q", policy)
+		}
+		p[class] = policy
+	}
+	return p, nil
+}
+
+// Override returns a copy of the policies overridden by the other ones.
+func (p Policies) Override(other Policies) Policies {
+	merged := make(Policies, len(p)+len(other))
+	for class, policy := range p {
+		merged[class] = policy
+	}
+	for class, policy := range other {
+		merged[class] = policy
+	}
+	return merged
+}
+
+// Of returns the policy of the class; abort if it has none.
+func (p Policies) Of(class Class) Policy {
+	if policy, ok := p[class]; ok {
+		return policy
+	}
+	return PolicyAbort
+}
+
+func (p Policies) String() string {
+	var fields []string
+	for _, class := range Classes {
+		if policy, ok := p[class]; ok {
+			fields = append(fields, fmt.Sprintf("%s=%s", class, policy))
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+func containsClass(class Class) bool {
+	for _, c := range Classes {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPolicy(policy Policy) bool {
+	for _, p := range policies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// Histogram is the number of responses per class. It is not safe for concurrent use.
+type Histogram map[Class]int
+
+// Add classifies a response and counts it. It returns the named error of the response.
+func (h Histogram) Add(codespace string, code uint32) Error {
+	e := Classify(codespace, code)
+	h[e.Class]++
+	return e
+}
+
+// Merge adds the counts of another histogram.
+func (h Histogram) Merge(other Histogram) {
+	for class, n := range other {
+		h[class] += n
+	}
+}
+
+// Counts returns the counts keyed by the names of the classes.
+func (h Histogram) Counts() map[string]int {
+	if len(h) == 0 {
+		return nil
+	}
+	counts := make(map[string]int, len(h))
+	for class, n := range h {
+		counts[string(class)] = n
+	}
+	return counts
+}
+
+// SortedClasses returns the classes of the histogram in the order they are reported, followed by
+// any other class in alphabetical order.
+func SortedClasses(counts map[string]int) []string {
+	order := make(map[string]int, len(Classes))
+	for i, c := range Classes {
+		order[string(c)] = i
+	}
+	classes := make([]string, 0, len(counts))
+	for class := range counts {
+		classes = append(classes, class)
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		oi, iok := order[classes[i]]
+		oj, jok := order[classes[j]]
+		switch {
+		case iok && jok:
+			return oi < oj
+		case iok != jok:
+			return iok
+		default:
+			return classes[i] < classes[j]
+		}
+	})
+	return classes
+}
+
+// Print prints the histogram as a table with the share of every class.
+func (h Histogram) Print(w io.Writer) error {
+	total := 0
+	for _, n := range h {
+		total += n
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "class\tresponses\tshare")
+	counts := h.Counts()
+	for _, class := range SortedClasses(counts) {
+		fmt.Fprintf(tw, "%s\t%d\t%.2f%%\n", class, counts[class], float64(counts[class])/float64(total)*100)
+	}
+	return tw.Flush()
+}
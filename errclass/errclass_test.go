@@ -0,0 +1,78 @@
+package errclass_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/errclass"
+)
+
+func TestClassify(t *testing.T) {
+	for _, tc := range []struct {
+		codespace string
+		code      uint32
+		name      string
+		class     errclass.Class
+	}{
+		{"", 0, "ok", errclass.ClassOK},
+		{"sdk", 0x13, "tx already in mempool", errclass.ClassSequence},
+		{"sdk", 0x14, "mempool is full", errclass.ClassMempoolFull},
+		{"", 0x14, "mempool is full", errclass.ClassMempoolFull},
+		{"sdk", 0x20, "incorrect account sequence", errclass.ClassSequence},
+		{"sdk", 5, "insufficient funds", errclass.ClassFunds},
+		{"sdk", 11, "out of gas", errclass.ClassFee},
+		{"liquidity", 1, "pool not exists", errclass.ClassLiquidity},
+		{"liquidity", 8, "insufficient coin balance", errclass.ClassFunds},
+		{"liquidity", 999, "unknown", errclass.ClassUnknown},
+		{"wasm", 5, "unknown", errclass.ClassUnknown},
+	} {
+		e := errclass.Classify(tc.codespace, tc.code)
+		require.Equal(t, tc.name, e.Name, "%s/%d", tc.codespace, tc.code)
+		require.Equal(t, tc.class, e.Class, "%s/%d", tc.codespace, tc.code)
+	}
+	require.Equal(t, "liquidity/31 (can not exceed max order ratio of reserve coins that can be ordered at a order)",
+		errclass.Classify("liquidity", 31).String())
+}
+
+func TestParsePolicies(t *testing.T) {
+	p, err := errclass.ParsePolicies("")
+	require.NoError(t, err)
+	require.Empty(t, p)
+
+	defaults := errclass.DefaultPolicies()
+	require.Equal(t, errclass.PolicyStopRound, defaults.Of(errclass.ClassMempoolFull))
+	require.Equal(t, errclass.PolicyAbort, defaults.Of(errclass.ClassOK))
+
+	p, err = errclass.ParsePolicies("liquidity=abort, unknown=skip")
+	require.NoError(t, err)
+	merged := defaults.Override(p)
+	require.Equal(t, errclass.PolicyAbort, merged.Of(errclass.ClassLiquidity))
+	require.Equal(t, errclass.PolicySkip, merged.Of(errclass.ClassUnknown))
+	require.Equal(t, errclass.PolicyRetry, merged.Of(errclass.ClassSequence))
+	require.Equal(t, errclass.PolicySkip, defaults.Of(errclass.ClassLiquidity), "the defaults are not modified")
+	require.Contains(t, merged.String(), "liquidity=abort")
+
+	for _, s := range []string{"liquidity", "foo=skip", "ok=skip", "liquidity=ignore"} {
+		_, err := errclass.ParsePolicies(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	h := make(errclass.Histogram)
+	h.Add("", 0)
+	h.Add("", 0)
+	h.Add("sdk", 0x14)
+	require.Equal(t, errclass.ClassLiquidity, h.Add("liquidity", 31).Class)
+	h.Merge(errclass.Histogram{errclass.ClassOK: 4})
+	require.Equal(t, map[string]int{"ok": 6, "mempool_full": 1, "liquidity": 1}, h.Counts())
+	require.Equal(t, []string{"ok", "mempool_full", "liquidity", "custom"},
+		errclass.SortedClasses(map[string]int{"custom": 1, "liquidity": 1, "ok": 1, "mempool_full": 1}))
+	require.Nil(t, errclass.Histogram{}.Counts())
+
+	var buf bytes.Buffer
+	require.NoError(t, h.Print(&buf))
+	require.Contains(t, buf.String(), "75.00%")
+}
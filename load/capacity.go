@@ -0,0 +1,140 @@
+package load
+
+import (
+	"time"
+)
+
+// Probe is the measurement of a number of txs per block during a few blocks.
+type Probe struct {
+	NumTxsPerBlock   int
+	Blocks           int
+	Broadcast        int
+	Committed        int
+	TotalDuration    time.Duration
+	MaxBlockDuration time.Duration
+	measured         int
+}
+
+// Add adds the result of a single block to the probe. Unknown block durations are given as zero.
+func (p *Probe) Add(broadcast, committed int, blockDuration time.Duration) {
+	p.Blocks++
+	p.Broadcast += broadcast
+	p.Committed += committed
+	if blockDuration > 0 {
+		p.measured++
+		p.TotalDuration += blockDuration
+		if blockDuration > p.MaxBlockDuration {
+			p.MaxBlockDuration = blockDuration
+		}
+	}
+}
+
+// CommitRatio returns the ratio of the committed txs to the broadcast txs.
+func (p Probe) CommitRatio() float64 {
+	if p.Broadcast == 0 {
+		return 0
+	}
+	return float64(p.Committed) / float64(p.Broadcast)
+}
+
+// AvgBlockDuration returns the average of the measured block durations.
+func (p Probe) AvgBlockDuration() time.Duration {
+	if p.measured == 0 {
+		return 0
+	}
+	return p.TotalDuration / time.Duration(p.measured)
+}
+
+// Thresholds are the conditions under which the network is considered to keep up with the load.
+type Thresholds struct {
+	MinCommitRatio   float64
+	MaxBlockDuration time.Duration
+}
+
+// Pass returns whether the probe stays within the thresholds.
+func (t Thresholds) Pass(p Probe) bool {
+	if p.CommitRatio() < t.MinCommitRatio {
+		return false
+	}
+	if t.MaxBlockDuration > 0 && p.AvgBlockDuration() > t.MaxBlockDuration {
+		return false
+	}
+	return true
+}
+
+// CapacitySearch searches the highest number of txs per block within the thresholds.
+// It doubles the load from Min until a probe fails or Max is reached,
+// and then bisects between the highest passing and the lowest failing load.
+type CapacitySearch struct {
+	Min        int
+	Max        int
+	Resolution int
+	Thresholds Thresholds
+	Probes     []Probe
+
+	low  int // highest passing load
+	high int // lowest failing load, zero if no probe has failed yet
+	done bool
+}
+
+// NewCapacitySearch creates a new CapacitySearch which stops when the gap between
+// the highest passing and the lowest failing load is not larger than resolution.
+func NewCapacitySearch(min, max, resolution int, thresholds Thresholds) *CapacitySearch {
+	if resolution < 1 {
+		resolution = 1
+	}
+	return &CapacitySearch{
+		Min:        min,
+		Max:        max,
+		Resolution: resolution,
+		Thresholds: thresholds,
+	}
+}
+
+// Next returns the next number of txs per block to probe.
+// It returns false when the search has converged.
+func (s *CapacitySearch) Next() (int, bool) {
+	switch {
+	case s.done:
+		return 0, false
+	case len(s.Probes) == 0:
+		return s.Min, true
+	case s.high == 0:
+		if s.low >= s.Max {
+			return 0, false
+		}
+		next := s.low * 2
+		if next > s.Max {
+			next = s.Max
+		}
+		return next, true
+	case s.high-s.low <= s.Resolution:
+		return 0, false
+	default:
+		return (s.low + s.high) / 2, true
+	}
+}
+
+// Record records the probe of the load returned by Next and returns whether it passed.
+func (s *CapacitySearch) Record(p Probe) bool {
+	s.Probes = append(s.Probes, p)
+	pass := s.Thresholds.Pass(p)
+	if pass {
+		if p.NumTxsPerBlock > s.low {
+			s.low = p.NumTxsPerBlock
+		}
+	} else {
+		if s.high == 0 || p.NumTxsPerBlock < s.high {
+			s.high = p.NumTxsPerBlock
+		}
+		if p.NumTxsPerBlock <= s.Min {
+			s.done = true
+		}
+	}
+	return pass
+}
+
+// Capacity returns the highest number of txs per block which passed, zero if none did.
+func (s *CapacitySearch) Capacity() int {
+	return s.low
+}
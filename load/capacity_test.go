@@ -0,0 +1,66 @@
+package load_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/load"
+)
+
+// simulate runs the search against a network which commits every tx up to capacity per block.
+func simulate(s *load.CapacitySearch, capacity int) []int {
+	var probed []int
+	for {
+		n, ok := s.Next()
+		if !ok {
+			return probed
+		}
+		probed = append(probed, n)
+		p := load.Probe{NumTxsPerBlock: n}
+		for i := 0; i < 3; i++ {
+			committed := n
+			if committed > capacity {
+				committed = capacity
+			}
+			p.Add(n, committed, time.Second)
+		}
+		s.Record(p)
+	}
+}
+
+func TestCapacitySearch(t *testing.T) {
+	th := load.Thresholds{MinCommitRatio: 1}
+
+	s := load.NewCapacitySearch(10, 5000, 10, th)
+	probed := simulate(s, 730)
+	require.Equal(t, []int{10, 20, 40, 80, 160, 320, 640, 1280, 960, 800, 720, 760, 740, 730}, probed)
+	require.Equal(t, 730, s.Capacity())
+	require.Len(t, s.Probes, len(probed))
+
+	s = load.NewCapacitySearch(10, 100, 10, th)
+	simulate(s, 1000)
+	require.Equal(t, 100, s.Capacity())
+
+	s = load.NewCapacitySearch(10, 100, 10, th)
+	require.Equal(t, []int{10}, simulate(s, 5))
+	require.Equal(t, 0, s.Capacity())
+}
+
+func TestProbeThresholds(t *testing.T) {
+	p := load.Probe{NumTxsPerBlock: 100}
+	p.Add(100, 100, 0)
+	p.Add(100, 90, 4*time.Second)
+	p.Add(100, 100, 8*time.Second)
+
+	require.Equal(t, 3, p.Blocks)
+	require.InDelta(t, 290.0/300.0, p.CommitRatio(), 1e-9)
+	require.Equal(t, 6*time.Second, p.AvgBlockDuration())
+	require.Equal(t, 8*time.Second, p.MaxBlockDuration)
+
+	require.True(t, load.Thresholds{MinCommitRatio: 0.9, MaxBlockDuration: 6 * time.Second}.Pass(p))
+	require.False(t, load.Thresholds{MinCommitRatio: 0.99}.Pass(p))
+	require.False(t, load.Thresholds{MinCommitRatio: 0.9, MaxBlockDuration: 5 * time.Second}.Pass(p))
+	require.False(t, load.Thresholds{MinCommitRatio: 0.9}.Pass(load.Probe{}))
+}
@@ -0,0 +1,96 @@
+package load
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// The statuses of the txs followed by a ConfirmationTracker.
+const (
+	ConfirmPending   = "pending"
+	ConfirmCommitted = "committed"
+	ConfirmTimedOut  = "timed_out"
+)
+
+// ConfirmStatus returns the status of the tx as followed by a ConfirmationTracker.
+func (in Inclusion) ConfirmStatus() string {
+	switch {
+	case in.Committed():
+		return ConfirmCommitted
+	case in.TimedOut():
+		return ConfirmTimedOut
+	default:
+		return ConfirmPending
+	}
+}
+
+// ConfirmationCounts are the numbers of the followed txs by status.
+type ConfirmationCounts struct {
+	Tracked   int
+	Committed int
+	Failed    int // committed with a non-zero code
+	TimedOut  int
+	Pending   int
+}
+
+// ConfirmationTracker follows the txs broadcast without waiting for their CheckTx until they are committed,
+// or times them out. It is an InclusionTracker with a timeout, safe for concurrent use, and tracking with
+// a nil tracker is a no-op.
+type ConfirmationTracker struct {
+	*InclusionTracker
+	timeout time.Duration
+}
+
+// NewConfirmationTracker returns a tracker timing out the txs which are not committed within the timeout of their broadcast.
+func NewConfirmationTracker(timeout time.Duration) *ConfirmationTracker {
+	return &ConfirmationTracker{
+		InclusionTracker: NewInclusionTracker(),
+		timeout:          timeout,
+	}
+}
+
+// Track starts following a tx broadcast from the account.
+func (t *ConfirmationTracker) Track(hash, account string, at time.Time) {
+	if t == nil {
+		return
+	}
+	t.Broadcast(hash, account, "", at)
+}
+
+// Expired returns the pending txs which have not been committed within the timeout at now.
+func (t *ConfirmationTracker) Expired(now time.Time) []Inclusion {
+	return t.Unconfirmed(now.Add(-t.timeout))
+}
+
+// Counts returns the numbers of the followed txs by status.
+func (t *ConfirmationTracker) Counts() ConfirmationCounts {
+	var counts ConfirmationCounts
+	for _, in := range t.Inclusions() {
+		counts.Tracked++
+		switch in.ConfirmStatus() {
+		case ConfirmCommitted:
+			counts.Committed++
+			if in.Code != 0 {
+				counts.Failed++
+			}
+		case ConfirmTimedOut:
+			counts.TimedOut++
+		default:
+			counts.Pending++
+		}
+	}
+	return counts
+}
+
+// Print prints the counts as a table.
+func (c ConfirmationCounts) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "async broadcast txs\t%d\n", c.Tracked)
+	fmt.Fprintf(tw, "committed txs\t%d\n", c.Committed)
+	fmt.Fprintf(tw, "committed failed txs\t%d\n", c.Failed)
+	fmt.Fprintf(tw, "timed out txs\t%d\n", c.TimedOut)
+	fmt.Fprintf(tw, "pending txs\t%d\n", c.Pending)
+	return tw.Flush()
+}
@@ -0,0 +1,71 @@
+package load_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/load"
+)
+
+func TestConfirmationTracker(t *testing.T) {
+	start := time.Unix(1000, 0)
+	tr := load.NewConfirmationTracker(time.Minute)
+
+	tr.CommitResult("TX0", 9, 0, "", start.Add(time.Second)) // committed before its broadcast has been tracked
+	tr.Track("TX0", "addr", start)
+	tr.Track("TX1", "addr", start)
+	tr.Track("TX2", "addr", start.Add(30*time.Second))
+	tr.Track("TX3", "addr", start.Add(30*time.Second))
+	tr.Track("TX1", "addr", start.Add(time.Hour)) // duplicate broadcasts are ignored
+	require.Equal(t, 3, tr.Pending())
+
+	tr.CommitResult("TX1", 10, 0, "", start.Add(5*time.Second))
+	tr.CommitResult("TX2", 11, 5, "sdk", start.Add(40*time.Second))
+	require.Equal(t, 1, tr.Pending())
+	require.Empty(t, tr.Expired(start.Add(89*time.Second)))
+	expired := tr.Expired(start.Add(90 * time.Second))
+	require.Len(t, expired, 1)
+	require.Equal(t, "TX3", expired[0].Hash)
+
+	tr.TimeOut("TX3", start.Add(90*time.Second))
+	tr.TimeOut("TX1", start.Add(90*time.Second)) // committed txs do not time out
+	require.Equal(t, 0, tr.Pending())
+	require.Empty(t, tr.Expired(start.Add(time.Hour)))
+
+	cfs := tr.Inclusions()
+	require.Len(t, cfs, 4)
+	require.Equal(t, load.ConfirmCommitted, cfs[0].ConfirmStatus())
+	require.Equal(t, int64(9), cfs[0].Height)
+	require.Equal(t, load.ConfirmCommitted, cfs[1].ConfirmStatus())
+	require.Equal(t, uint32(5), cfs[2].Code)
+	require.Equal(t, "sdk", cfs[2].Codespace)
+	require.Equal(t, load.ConfirmTimedOut, cfs[3].ConfirmStatus())
+
+	// a tx committed after its timeout is still recorded as committed
+	tr.CommitResult("TX3", 12, 0, "", start.Add(100*time.Second))
+	require.Equal(t, 0, tr.Pending())
+	require.Equal(t, load.ConfirmationCounts{Tracked: 4, Committed: 4, Failed: 1}, tr.Counts())
+}
+
+func TestConfirmationCountsPrint(t *testing.T) {
+	start := time.Unix(1000, 0)
+	tr := load.NewConfirmationTracker(time.Second)
+	tr.Track("TX0", "addr", start)
+	tr.Track("TX1", "addr", start)
+	tr.TimeOut("TX1", start.Add(time.Second))
+	counts := tr.Counts()
+	require.Equal(t, load.ConfirmationCounts{Tracked: 2, TimedOut: 1, Pending: 1}, counts)
+
+	var buf bytes.Buffer
+	require.NoError(t, counts.Print(&buf))
+	require.True(t, strings.Contains(buf.String(), "timed out txs         1"), buf.String())
+}
+
+func TestConfirmationTrackerNil(t *testing.T) {
+	var tr *load.ConfirmationTracker
+	require.NotPanics(t, func() { tr.Track("TX0", "addr", time.Now()) })
+}
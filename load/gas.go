@@ -0,0 +1,131 @@
+package load
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"text/tabwriter"
+)
+
+// GasKey groups the txs by the type and the number of their messages.
+type GasKey struct {
+	MsgType string
+	MsgNum  int
+}
+
+// GasDistribution is the distribution of the gas used by the committed txs of a key.
+type GasDistribution struct {
+	GasKey
+	Txs       int
+	Failed    int   // txs which failed in DeliverTx, e.g. because they ran out of gas
+	MaxWanted int64 // highest gas limit of the txs
+	Min       int64
+	Avg       int64
+	P50       int64
+	P90       int64
+	P99       int64
+	Max       int64
+	// Recommended is the gas limit which covers the highest gas used with the margin.
+	Recommended int64
+}
+
+// GasStats collects the gas wanted and used by committed txs.
+type GasStats struct {
+	mu     sync.Mutex
+	used   map[GasKey][]int64
+	wanted map[GasKey]int64
+	failed map[GasKey]int
+}
+
+// NewGasStats creates a new empty GasStats.
+func NewGasStats() *GasStats {
+	return &GasStats{
+		used:   make(map[GasKey][]int64),
+		wanted: make(map[GasKey]int64),
+		failed: make(map[GasKey]int),
+	}
+}
+
+// Add adds a committed tx. It is safe for concurrent use.
+func (s *GasStats) Add(key GasKey, wanted, used int64, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.used[key] = append(s.used[key], used)
+	if wanted > s.wanted[key] {
+		s.wanted[key] = wanted
+	}
+	if failed {
+		s.failed[key]++
+	}
+}
+
+// Distributions returns the distribution of every key in order, recommending gas limits
+// with the given margin, e.g. 0.2 for 20% over the highest gas used.
+func (s *GasStats) Distributions(margin float64) []GasDistribution {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]GasKey, 0, len(s.used))
+	for key := range s.used {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].MsgType != keys[j].MsgType {
+			return keys[i].MsgType < keys[j].MsgType
+		}
+		return keys[i].MsgNum < keys[j].MsgNum
+	})
+
+	dists := make([]GasDistribution, len(keys))
+	for i, key := range keys {
+		used := append([]int64(nil), s.used[key]...)
+		sort.Slice(used, func(i, j int) bool { return used[i] < used[j] })
+		var total int64
+		for _, u := range used {
+			total += u
+		}
+		max := used[len(used)-1]
+		dists[i] = GasDistribution{
+			GasKey:      key,
+			Txs:         len(used),
+			Failed:      s.failed[key],
+			MaxWanted:   s.wanted[key],
+			Min:         used[0],
+			Avg:         total / int64(len(used)),
+			P50:         percentileInt64(used, 50),
+			P90:         percentileInt64(used, 90),
+			P99:         percentileInt64(used, 99),
+			Max:         max,
+			Recommended: RecommendGasLimit(max, margin),
+		}
+	}
+	return dists
+}
+
+// RecommendGasLimit returns the gas used with the margin, rounded up to a thousand.
+func RecommendGasLimit(used int64, margin float64) int64 {
+	limit := int64(math.Ceil(float64(used) * (1 + margin)))
+	return (limit + 999) / 1000 * 1000
+}
+
+// percentileInt64 returns the nearest-rank percentile of the sorted values.
+func percentileInt64(sorted []int64, p float64) int64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// PrintGasDistributions prints the distributions as a table.
+func PrintGasDistributions(w io.Writer, dists []GasDistribution) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "msg_type\tmsg_num\ttxs\tfailed\tmax_wanted\tmin\tavg\tp50\tp90\tp99\tmax\trecommended_gas_limit")
+	for _, d := range dists {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\n",
+			d.MsgType, d.MsgNum, d.Txs, d.Failed, d.MaxWanted, d.Min, d.Avg, d.P50, d.P90, d.P99, d.Max, d.Recommended)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,53 @@
+package load_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/load"
+)
+
+func TestGasStats(t *testing.T) {
+	s := load.NewGasStats()
+	swap1 := load.GasKey{MsgType: "swap", MsgNum: 1}
+	swap2 := load.GasKey{MsgType: "swap", MsgNum: 2}
+	for i := int64(1); i <= 100; i++ {
+		s.Add(swap1, 100000, 50000+i*100, false)
+	}
+	s.Add(swap2, 200000, 150000, false)
+	s.Add(swap2, 200000, 200000, true)
+	s.Add(load.GasKey{MsgType: "deposit", MsgNum: 1}, 100000, 70000, false)
+
+	dists := s.Distributions(0.2)
+	require.Len(t, dists, 3)
+	require.Equal(t, "deposit", dists[0].MsgType)
+	require.Equal(t, int64(84000), dists[0].Recommended)
+
+	d := dists[1]
+	require.Equal(t, swap1, d.GasKey)
+	require.Equal(t, 100, d.Txs)
+	require.Equal(t, int64(100000), d.MaxWanted)
+	require.Equal(t, int64(50100), d.Min)
+	require.Equal(t, int64(55050), d.Avg)
+	require.Equal(t, int64(55000), d.P50)
+	require.Equal(t, int64(59000), d.P90)
+	require.Equal(t, int64(59900), d.P99)
+	require.Equal(t, int64(60000), d.Max)
+	require.Equal(t, int64(72000), d.Recommended)
+
+	require.Equal(t, swap2, dists[2].GasKey)
+	require.Equal(t, 1, dists[2].Failed)
+	require.Equal(t, int64(240000), dists[2].Recommended)
+
+	var buf bytes.Buffer
+	require.NoError(t, load.PrintGasDistributions(&buf, dists))
+	require.Contains(t, buf.String(), "recommended_gas_limit")
+}
+
+func TestRecommendGasLimit(t *testing.T) {
+	require.Equal(t, int64(121000), load.RecommendGasLimit(100001, 0.2))
+	require.Equal(t, int64(100000), load.RecommendGasLimit(100000, 0))
+	require.Equal(t, int64(0), load.RecommendGasLimit(0, 0.2))
+}
@@ -0,0 +1,279 @@
+package load
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+	"unicode"
+)
+
+// Inclusion is the broadcast and the commit of a single tx. A tx which has not been committed has a zero Height.
+type Inclusion struct {
+	Hash        string
+	Account     string
+	GasPrice    string // empty if the fees are not derived from gas prices
+	BroadcastAt time.Time
+	CommittedAt time.Time
+	Height      int64
+	Code        uint32 // result of DeliverTx
+	Codespace   string
+	TimedOutAt  time.Time // zero unless the tx has been given up on before its commit
+}
+
+// Committed returns whether the tx has been committed.
+func (in Inclusion) Committed() bool {
+	return in.Height > 0
+}
+
+// TimedOut returns whether the tx has been given up on and has not been committed since.
+func (in Inclusion) TimedOut() bool {
+	return !in.Committed() && !in.TimedOutAt.IsZero()
+}
+
+// Latency returns the time from the broadcast to the commit of the tx.
+func (in Inclusion) Latency() time.Duration {
+	if !in.Committed() {
+		return 0
+	}
+	return in.CommittedAt.Sub(in.BroadcastAt)
+}
+
+// InclusionTracker tracks the time every broadcast tx takes to be committed. It is safe for concurrent use.
+type InclusionTracker struct {
+	mu         sync.Mutex
+	inclusions []*Inclusion
+	byHash     map[string]*Inclusion
+	early      map[string]Inclusion // commits seen before their broadcast has been recorded
+	pending    int
+}
+
+// NewInclusionTracker creates a new InclusionTracker.
+func NewInclusionTracker() *InclusionTracker {
+	return &InclusionTracker{
+		byHash: make(map[string]*Inclusion),
+		early:  make(map[string]Inclusion),
+	}
+}
+
+// Broadcast records the broadcast of a tx accepted by the node, with its gas price if any.
+// A tx broadcast again is recorded once.
+func (t *InclusionTracker) Broadcast(hash, account, gasPrice string, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.byHash[hash]; ok {
+		return
+	}
+	in := &Inclusion{Hash: hash, Account: account, GasPrice: gasPrice, BroadcastAt: at}
+	if e, ok := t.early[hash]; ok {
+		in.Height, in.CommittedAt, in.Code, in.Codespace = e.Height, e.CommittedAt, e.Code, e.Codespace
+		delete(t.early, hash)
+	} else {
+		t.pending++
+	}
+	t.inclusions = append(t.inclusions, in)
+	t.byHash[hash] = in
+}
+
+// Commit records the commit of a tx at the given height.
+func (t *InclusionTracker) Commit(hash string, height int64, at time.Time) {
+	t.CommitResult(hash, height, 0, "", at)
+}
+
+// CommitResult records the commit of a tx at the given height with the result of its DeliverTx.
+// A tx which has timed out is still recorded as committed.
+func (t *InclusionTracker) CommitResult(hash string, height int64, code uint32, codespace string, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	in, ok := t.byHash[hash]
+	if !ok {
+		t.early[hash] = Inclusion{Hash: hash, Height: height, CommittedAt: at, Code: code, Codespace: codespace}
+		return
+	}
+	if in.Committed() {
+		return
+	}
+	if in.TimedOutAt.IsZero() {
+		t.pending--
+	}
+	in.Height, in.CommittedAt, in.Code, in.Codespace = height, at, code, codespace
+}
+
+// TimeOut gives up on a pending tx at the given time, so it is no longer pending.
+func (t *InclusionTracker) TimeOut(hash string, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	in, ok := t.byHash[hash]
+	if !ok || in.Committed() || !in.TimedOutAt.IsZero() {
+		return
+	}
+	in.TimedOutAt = at
+	t.pending--
+}
+
+// Unconfirmed returns the pending txs broadcast up to the given time.
+func (t *InclusionTracker) Unconfirmed(upTo time.Time) []Inclusion {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var unconfirmed []Inclusion
+	for _, in := range t.inclusions {
+		if !in.Committed() && in.TimedOutAt.IsZero() && !in.BroadcastAt.After(upTo) {
+			unconfirmed = append(unconfirmed, *in)
+		}
+	}
+	return unconfirmed
+}
+
+// PruneEarly drops the commits seen before the given time whose broadcasts have not been recorded, which
+// are the txs of other accounts once their broadcasts would have been recorded. It returns the number of
+// dropped commits.
+func (t *InclusionTracker) PruneEarly(before time.Time) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := 0
+	for hash, e := range t.early {
+		if e.CommittedAt.Before(before) {
+			delete(t.early, hash)
+			n++
+		}
+	}
+	return n
+}
+
+// Pending returns the number of broadcast txs which have neither been committed nor timed out yet.
+func (t *InclusionTracker) Pending() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.pending
+}
+
+// Inclusions returns the tracked txs in the order of their broadcasts.
+func (t *InclusionTracker) Inclusions() []Inclusion {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	inclusions := make([]Inclusion, len(t.inclusions))
+	for i, in := range t.inclusions {
+		inclusions[i] = *in
+	}
+	return inclusions
+}
+
+// LatencyStats are the percentiles of the inclusion latencies of the committed txs.
+type LatencyStats struct {
+	Broadcast int
+	Committed int
+	P50       time.Duration
+	P90       time.Duration
+	P99       time.Duration
+	Max       time.Duration
+}
+
+// Stats returns the latency stats of the tracked txs.
+func (t *InclusionTracker) Stats() LatencyStats {
+	return NewLatencyStats(t.Inclusions())
+}
+
+// NewLatencyStats returns the latency stats of the txs.
+func NewLatencyStats(inclusions []Inclusion) LatencyStats {
+	var latencies []time.Duration
+	for _, in := range inclusions {
+		if in.Committed() {
+			latencies = append(latencies, in.Latency())
+		}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	st := LatencyStats{Broadcast: len(inclusions), Committed: len(latencies)}
+	if len(latencies) > 0 {
+		st.P50 = Percentile(latencies, 50)
+		st.P90 = Percentile(latencies, 90)
+		st.P99 = Percentile(latencies, 99)
+		st.Max = latencies[len(latencies)-1]
+	}
+	return st
+}
+
+// GasPriceLatency are the latency stats of the txs of a gas price.
+type GasPriceLatency struct {
+	GasPrice string
+	LatencyStats
+}
+
+// LatenciesByGasPrice buckets the txs by their gas prices, e.g. "0.025uatom", and returns the latency stats
+// of every bucket in the order of the denoms and the amounts of the prices. The txs without a gas price are skipped.
+func LatenciesByGasPrice(inclusions []Inclusion) []GasPriceLatency {
+	buckets := make(map[string][]Inclusion)
+	for _, in := range inclusions {
+		if in.GasPrice != "" {
+			buckets[in.GasPrice] = append(buckets[in.GasPrice], in)
+		}
+	}
+	latencies := make([]GasPriceLatency, 0, len(buckets))
+	for price, ins := range buckets {
+		latencies = append(latencies, GasPriceLatency{GasPrice: price, LatencyStats: NewLatencyStats(ins)})
+	}
+	sort.Slice(latencies, func(i, j int) bool {
+		ai, di := splitGasPrice(latencies[i].GasPrice)
+		aj, dj := splitGasPrice(latencies[j].GasPrice)
+		if di != dj {
+			return di < dj
+		}
+		if ai != aj {
+			return ai < aj
+		}
+		return latencies[i].GasPrice < latencies[j].GasPrice
+	})
+	return latencies
+}
+
+// splitGasPrice splits a gas price into its amount and its denom.
+func splitGasPrice(price string) (float64, string) {
+	i := strings.IndexFunc(price, func(r rune) bool { return unicode.IsLetter(r) })
+	if i < 0 {
+		i = len(price)
+	}
+	amount, err := strconv.ParseFloat(price[:i], 64)
+	if err != nil {
+		return math.Inf(1), price[i:]
+	}
+	return amount, price[i:]
+}
+
+// PrintGasPriceLatencies prints the latency stats of the gas prices as a table.
+func PrintGasPriceLatencies(w io.Writer, latencies []GasPriceLatency) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "gas_price\ttxs\tcommitted\tp50\tp90\tp99\tmax")
+	for _, l := range latencies {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t%s\n", l.GasPrice, l.Broadcast, l.Committed, l.P50, l.P90, l.P99, l.Max)
+	}
+	return tw.Flush()
+}
+
+// Percentile returns the nearest-rank percentile of the sorted durations.
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Print prints the latency stats as a table.
+func (st LatencyStats) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "tracked txs\t%d\n", st.Broadcast)
+	fmt.Fprintf(tw, "never committed txs\t%d\n", st.Broadcast-st.Committed)
+	fmt.Fprintf(tw, "inclusion latency p50\t%s\n", st.P50)
+	fmt.Fprintf(tw, "inclusion latency p90\t%s\n", st.P90)
+	fmt.Fprintf(tw, "inclusion latency p99\t%s\n", st.P99)
+	fmt.Fprintf(tw, "inclusion latency max\t%s\n", st.Max)
+	return tw.Flush()
+}
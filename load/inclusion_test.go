@@ -0,0 +1,90 @@
+package load_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/load"
+)
+
+func TestInclusionTracker(t *testing.T) {
+	start := time.Unix(1000, 0)
+	tr := load.NewInclusionTracker()
+	for i := 0; i < 100; i++ {
+		tr.Broadcast(fmt.Sprintf("TX%d", i), "addr", "", start)
+	}
+	require.Equal(t, 100, tr.Pending())
+
+	// the first 98 txs take 1s to 98s, the others never commit
+	for i := 0; i < 98; i++ {
+		tr.Commit(fmt.Sprintf("TX%d", i), 10, start.Add(time.Duration(i+1)*time.Second))
+	}
+	tr.Commit("TX0", 11, start.Add(time.Hour)) // duplicate events are ignored
+	require.Equal(t, 2, tr.Pending())
+
+	st := tr.Stats()
+	require.Equal(t, 100, st.Broadcast)
+	require.Equal(t, 98, st.Committed)
+	require.Equal(t, 49*time.Second, st.P50)
+	require.Equal(t, 89*time.Second, st.P90)
+	require.Equal(t, 98*time.Second, st.P99)
+	require.Equal(t, 98*time.Second, st.Max)
+
+	var never []string
+	for _, in := range tr.Inclusions() {
+		if !in.Committed() {
+			never = append(never, in.Hash)
+		}
+	}
+	require.Equal(t, []string{"TX98", "TX99"}, never)
+}
+
+func TestInclusionTrackerEarlyCommit(t *testing.T) {
+	start := time.Unix(1000, 0)
+	tr := load.NewInclusionTracker()
+	tr.Commit("TX", 10, start.Add(time.Second))
+	tr.Broadcast("TX", "addr", "", start)
+	require.Zero(t, tr.Pending())
+	require.Equal(t, time.Second, tr.Stats().Max)
+
+	// the commits of other txs are not kept after their broadcasts would have been recorded
+	tr.Commit("OTHER0", 11, start.Add(2*time.Second))
+	tr.Commit("OTHER1", 12, start.Add(3*time.Second))
+	require.Equal(t, 1, tr.PruneEarly(start.Add(3*time.Second)))
+	tr.Broadcast("OTHER0", "addr", "", start.Add(time.Minute))
+	tr.Broadcast("OTHER1", "addr", "", start.Add(time.Minute))
+	require.Equal(t, 1, tr.Pending())
+}
+
+func TestLatenciesByGasPrice(t *testing.T) {
+	start := time.Now()
+	tr := load.NewInclusionTracker()
+	for i, price := range []string{"0.1uatom", "0.025uatom", "0.1uatom", "", "1stake", "0.025uatom"} {
+		hash := fmt.Sprintf("TX%d", i)
+		tr.Broadcast(hash, "addr", price, start)
+		if i != 5 {
+			tr.Commit(hash, 1, start.Add(time.Duration(i+1)*time.Second))
+		}
+	}
+
+	byPrice := load.LatenciesByGasPrice(tr.Inclusions())
+	require.Len(t, byPrice, 3)
+	require.Equal(t, "1stake", byPrice[0].GasPrice, "the prices are ordered by denom first")
+	require.Equal(t, "0.025uatom", byPrice[1].GasPrice)
+	require.Equal(t, 2, byPrice[1].Broadcast)
+	require.Equal(t, 1, byPrice[1].Committed)
+	require.Equal(t, 2*time.Second, byPrice[1].Max)
+	require.Equal(t, "0.1uatom", byPrice[2].GasPrice)
+	require.Equal(t, 2, byPrice[2].Committed)
+	require.Equal(t, time.Second, byPrice[2].P50)
+	require.Equal(t, 3*time.Second, byPrice[2].Max)
+
+	var b strings.Builder
+	require.NoError(t, load.PrintGasPriceLatencies(&b, byPrice))
+	require.Contains(t, b.String(), "gas_price")
+	require.Contains(t, b.String(), "0.025uatom")
+}
@@ -0,0 +1,117 @@
+package load
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// codeMempoolFull is the code of the responses of txs rejected because the mempool is full.
+const codeMempoolFull = 0x14
+
+// MempoolSample is the size of the mempool at a time.
+type MempoolSample struct {
+	At    time.Time
+	Txs   int
+	Bytes int64
+	// FullRejections is the number of txs rejected as the mempool was full since the previous sample.
+	FullRejections int
+	Saturated      bool
+}
+
+// SaturationPeriod is a run of consecutive saturated samples.
+type SaturationPeriod struct {
+	Start          time.Time
+	End            time.Time
+	Samples        int
+	MaxTxs         int
+	FullRejections int
+}
+
+// Duration returns the time between the first and the last sample of the period.
+func (p SaturationPeriod) Duration() time.Duration {
+	return p.End.Sub(p.Start)
+}
+
+// MempoolMonitor records the samples of the mempool of a chain. A sample is saturated when txs were
+// rejected as the mempool was full, or when the mempool holds at least the threshold of txs.
+type MempoolMonitor struct {
+	mu        sync.Mutex
+	threshold int // zero to only flag the rejections
+	full      int
+	samples   []MempoolSample
+}
+
+// NewMempoolMonitor creates a new MempoolMonitor which flags samples of at least threshold txs, if positive.
+func NewMempoolMonitor(threshold int) *MempoolMonitor {
+	return &MempoolMonitor{threshold: threshold}
+}
+
+// RecordCode records the code of a broadcast response. It is safe for concurrent use and a no-op on nil.
+func (m *MempoolMonitor) RecordCode(code uint32) {
+	if m == nil || code != codeMempoolFull {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.full++
+}
+
+// Sample adds a sample of the mempool size and returns it.
+func (m *MempoolMonitor) Sample(at time.Time, txs int, bytes int64) MempoolSample {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := MempoolSample{
+		At:             at,
+		Txs:            txs,
+		Bytes:          bytes,
+		FullRejections: m.full,
+		Saturated:      m.full > 0 || (m.threshold > 0 && txs >= m.threshold),
+	}
+	m.full = 0
+	m.samples = append(m.samples, s)
+	return s
+}
+
+// Samples returns the samples in order.
+func (m *MempoolMonitor) Samples() []MempoolSample {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MempoolSample(nil), m.samples...)
+}
+
+// SaturationPeriods returns the periods of consecutive saturated samples.
+func (m *MempoolMonitor) SaturationPeriods() []SaturationPeriod {
+	var periods []SaturationPeriod
+	var cur *SaturationPeriod
+	for _, s := range m.Samples() {
+		if !s.Saturated {
+			cur = nil
+			continue
+		}
+		if cur == nil {
+			periods = append(periods, SaturationPeriod{Start: s.At})
+			cur = &periods[len(periods)-1]
+		}
+		cur.End = s.At
+		cur.Samples++
+		cur.FullRejections += s.FullRejections
+		if s.Txs > cur.MaxTxs {
+			cur.MaxTxs = s.Txs
+		}
+	}
+	return periods
+}
+
+// PrintSaturationPeriods prints the periods as a table.
+func PrintSaturationPeriods(w io.Writer, periods []SaturationPeriod) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "start\tend\tduration\tsamples\tmax_txs\tfull_rejections")
+	for _, p := range periods {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\n",
+			p.Start.Format(time.RFC3339), p.End.Format(time.RFC3339), p.Duration(), p.Samples, p.MaxTxs, p.FullRejections)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,44 @@
+package load_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/load"
+)
+
+func TestMempoolMonitor(t *testing.T) {
+	m := load.NewMempoolMonitor(1000)
+	t0 := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(sec int) time.Time { return t0.Add(time.Duration(sec) * time.Second) }
+
+	require.False(t, m.Sample(at(0), 10, 1000).Saturated)
+	require.True(t, m.Sample(at(1), 1000, 100000).Saturated)
+	m.RecordCode(0)
+	m.RecordCode(0x14)
+	m.RecordCode(0x14)
+	s := m.Sample(at(2), 900, 90000)
+	require.True(t, s.Saturated)
+	require.Equal(t, 2, s.FullRejections)
+	require.False(t, m.Sample(at(3), 100, 10000).Saturated)
+	m.RecordCode(0x14)
+	require.True(t, m.Sample(at(4), 0, 0).Saturated)
+
+	require.Len(t, m.Samples(), 5)
+	require.Equal(t, []load.SaturationPeriod{
+		{Start: at(1), End: at(2), Samples: 2, MaxTxs: 1000, FullRejections: 2},
+		{Start: at(4), End: at(4), Samples: 1, FullRejections: 1},
+	}, m.SaturationPeriods())
+	require.Equal(t, time.Second, m.SaturationPeriods()[0].Duration())
+
+	var nilMonitor *load.MempoolMonitor
+	nilMonitor.RecordCode(0x14)
+}
+
+func TestMempoolMonitorWithoutThreshold(t *testing.T) {
+	m := load.NewMempoolMonitor(0)
+	require.False(t, m.Sample(time.Now(), 5000, 1<<20).Saturated)
+	require.Empty(t, m.SaturationPeriods())
+}
@@ -0,0 +1,77 @@
+package load
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mix picks message types in proportion to their weights. It uses smooth weighted
+// round-robin, so that every window of picks follows the weights as closely as possible
+// and the same sequence of types is picked on every run.
+type Mix struct {
+	mu      sync.Mutex
+	types   []string
+	weights []int
+	current []int
+	total   int
+}
+
+// NewMix creates a new Mix of the given weights per message type. Types of zero weight are never picked.
+func NewMix(weights map[string]int) (*Mix, error) {
+	m := &Mix{}
+	for t := range weights {
+		m.types = append(m.types, t)
+	}
+	sort.Strings(m.types)
+
+	var types []string
+	for _, t := range m.types {
+		w := weights[t]
+		if w < 0 {
+			return nil, fmt.Errorf("weight of %s must not be negative: %d", t, w)
+		}
+		if w == 0 {
+			continue
+		}
+		types = append(types, t)
+		m.weights = append(m.weights, w)
+		m.total += w
+	}
+	m.types = types
+	if m.total == 0 {
+		return nil, fmt.Errorf("mix must have a positive weight")
+	}
+	m.current = make([]int, len(m.types))
+	return m, nil
+}
+
+// Pick returns the next message type.
+func (m *Mix) Pick() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	best := 0
+	for i, w := range m.weights {
+		m.current[i] += w
+		if m.current[i] > m.current[best] {
+			best = i
+		}
+	}
+	m.current[best] -= m.total
+	return m.types[best]
+}
+
+// Types returns the message types of positive weights in order.
+func (m *Mix) Types() []string {
+	return m.types
+}
+
+func (m *Mix) String() string {
+	parts := make([]string, len(m.types))
+	for i, t := range m.types {
+		parts[i] = fmt.Sprintf("%s=%d", t, m.weights[i])
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,48 @@
+package load_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/load"
+)
+
+func TestMixFollowsWeights(t *testing.T) {
+	m, err := load.NewMix(map[string]int{
+		"swap":     70,
+		"deposit":  15,
+		"withdraw": 10,
+		"transfer": 5,
+		"unused":   0,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"deposit", "swap", "transfer", "withdraw"}, m.Types())
+	require.Equal(t, "deposit=15,swap=70,transfer=5,withdraw=10", m.String())
+
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		counts[m.Pick()]++
+	}
+	require.Equal(t, map[string]int{"swap": 700, "deposit": 150, "withdraw": 100, "transfer": 50}, counts)
+}
+
+func TestMixIsSmooth(t *testing.T) {
+	m, err := load.NewMix(map[string]int{"a": 5, "b": 1, "c": 1})
+	require.NoError(t, err)
+
+	var picks []string
+	for i := 0; i < 7; i++ {
+		picks = append(picks, m.Pick())
+	}
+	require.Equal(t, []string{"a", "a", "b", "a", "c", "a", "a"}, picks)
+}
+
+func TestInvalidMix(t *testing.T) {
+	_, err := load.NewMix(map[string]int{})
+	require.Error(t, err)
+	_, err = load.NewMix(map[string]int{"swap": 0})
+	require.Error(t, err)
+	_, err = load.NewMix(map[string]int{"swap": 1, "deposit": -1})
+	require.Error(t, err)
+}
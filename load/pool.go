@@ -0,0 +1,146 @@
+package load
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/b-harvest/modules-test-tool/signer"
+)
+
+// MaxConsecutiveFailures is the number of failures in a row after which
+// a worker gives up for the rest of the round.
+var MaxConsecutiveFailures = 3
+
+// Outcome is the outcome of a single job run by a worker.
+type Outcome int
+
+const (
+	// Sent means the transaction has been accepted by the node.
+	Sent Outcome = iota
+	// Failed means the transaction has been rejected. The quota is returned
+	// so that the transaction is tried again.
+	Failed
+	// StopRound means the transaction has been rejected and no more transactions
+	// should be sent in this round by any worker, e.g. when the mempool is full.
+	StopRound
+	// Skipped means the transaction has been rejected and dropped. The quota is not
+	// returned, so that the transaction is not tried again.
+	Skipped
+	// StopWorker means the transaction has been rejected and the account of the worker
+	// cannot send any more in this round. The quota is returned to the other workers.
+	StopWorker
+)
+
+// Account is an account owned by a single worker, whose txs are signed by its signer. Its sequences are
+// reserved from a sequence manager shared by the workloads signing with the account.
+type Account struct {
+	Addr   string
+	Signer signer.Signer
+}
+
+// Worker signs and broadcasts transactions of its own account.
+// Its fields must only be accessed by the job running on it while the pool runs.
+type Worker struct {
+	ID int
+	Account
+
+	Sent   int
+	Failed int
+}
+
+// Job is run by a worker for every transaction taken from the quota.
+// An error aborts all workers of the pool.
+type Job func(ctx context.Context, w *Worker) (Outcome, error)
+
+// WorkerPool runs a worker per account, all feeding from a shared quota.
+type WorkerPool struct {
+	Workers []*Worker
+}
+
+// NewWorkerPool creates a new WorkerPool with a worker for each of the given accounts.
+func NewWorkerPool(accounts []Account) *WorkerPool {
+	workers := make([]*Worker, len(accounts))
+	for i, acc := range accounts {
+		workers[i] = &Worker{ID: i, Account: acc}
+	}
+	return &WorkerPool{Workers: workers}
+}
+
+// Run has the workers run the job concurrently until quota transactions are sent,
+// the round is stopped or every worker gives up. It returns the number of sent transactions.
+func (p *WorkerPool) Run(ctx context.Context, quota int, job Job) (int, error) {
+	return p.RunUntil(ctx, nil, quota, job)
+}
+
+// RunUntil is like Run, but the workers also stop taking new jobs once stop is closed.
+// Unlike cancelling ctx, closing stop lets the jobs in flight finish.
+func (p *WorkerPool) RunUntil(ctx context.Context, stop <-chan struct{}, quota int, job Job) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	remaining := int64(quota)
+	var sent int64
+	var stopped int32
+	var once sync.Once
+	var firstErr error
+
+	var wg sync.WaitGroup
+	for _, w := range p.Workers {
+		wg.Add(1)
+		go func(w *Worker) {
+			defer wg.Done()
+			failures := 0
+			for atomic.LoadInt32(&stopped) == 0 && ctx.Err() == nil && !isClosed(stop) {
+				if atomic.AddInt64(&remaining, -1) < 0 {
+					atomic.AddInt64(&remaining, 1)
+					return
+				}
+				outcome, err := job(ctx, w)
+				if err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					return
+				}
+				switch outcome {
+				case Sent:
+					atomic.AddInt64(&sent, 1)
+					w.Sent++
+					failures = 0
+				case Failed:
+					atomic.AddInt64(&remaining, 1)
+					w.Failed++
+					failures++
+					if failures >= MaxConsecutiveFailures {
+						return
+					}
+				case StopRound:
+					atomic.AddInt64(&remaining, 1)
+					w.Failed++
+					atomic.StoreInt32(&stopped, 1)
+					return
+				case Skipped:
+					w.Failed++
+				case StopWorker:
+					atomic.AddInt64(&remaining, 1)
+					w.Failed++
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	return int(sent), firstErr
+}
+
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
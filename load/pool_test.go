@@ -0,0 +1,150 @@
+package load_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/load"
+)
+
+func newPool(n int) *load.WorkerPool {
+	accounts := make([]load.Account, n)
+	for i := range accounts {
+		accounts[i] = load.Account{Addr: fmt.Sprintf("addr%d", i)}
+	}
+	return load.NewWorkerPool(accounts)
+}
+
+func TestWorkerPoolQuota(t *testing.T) {
+	p := newPool(4)
+
+	// every worker only counts the jobs of its own account
+	jobs := make([]int, len(p.Workers))
+	sent, err := p.Run(context.Background(), 1000, func(ctx context.Context, w *load.Worker) (load.Outcome, error) {
+		require.Equal(t, fmt.Sprintf("addr%d", w.ID), w.Addr)
+		jobs[w.ID]++
+		return load.Sent, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1000, sent)
+
+	total := 0
+	for _, w := range p.Workers {
+		require.Equal(t, jobs[w.ID], w.Sent)
+		total += w.Sent
+	}
+	require.Equal(t, 1000, total)
+}
+
+func TestWorkerPoolRetriesFailures(t *testing.T) {
+	p := newPool(2)
+
+	var calls int64
+	sent, err := p.Run(context.Background(), 10, func(ctx context.Context, w *load.Worker) (load.Outcome, error) {
+		atomic.AddInt64(&calls, 1)
+		if (w.Sent+w.Failed)%2 == 1 {
+			return load.Failed, nil
+		}
+		return load.Sent, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 10, sent)
+	require.Equal(t, 10, p.Workers[0].Sent+p.Workers[1].Sent)
+	require.Equal(t, int(calls)-10, p.Workers[0].Failed+p.Workers[1].Failed)
+}
+
+func TestWorkerPoolGivesUp(t *testing.T) {
+	p := newPool(1)
+
+	sent, err := p.Run(context.Background(), 10, func(ctx context.Context, w *load.Worker) (load.Outcome, error) {
+		return load.Failed, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, sent)
+	require.Equal(t, load.MaxConsecutiveFailures, p.Workers[0].Failed)
+}
+
+func TestWorkerPoolStopRound(t *testing.T) {
+	p := newPool(3)
+
+	var calls int64
+	sent, err := p.Run(context.Background(), 1000, func(ctx context.Context, w *load.Worker) (load.Outcome, error) {
+		if atomic.AddInt64(&calls, 1) > 5 {
+			return load.StopRound, nil
+		}
+		return load.Sent, nil
+	})
+	require.NoError(t, err)
+	require.Less(t, sent, 1000)
+}
+
+func TestWorkerPoolSkipped(t *testing.T) {
+	p := newPool(1)
+
+	var calls int64
+	sent, err := p.Run(context.Background(), 10, func(ctx context.Context, w *load.Worker) (load.Outcome, error) {
+		if atomic.AddInt64(&calls, 1)%2 == 0 {
+			return load.Skipped, nil
+		}
+		return load.Sent, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 5, sent)
+	require.Equal(t, 5, p.Workers[0].Failed)
+}
+
+func TestWorkerPoolStopWorker(t *testing.T) {
+	p := newPool(2)
+
+	sent, err := p.Run(context.Background(), 10, func(ctx context.Context, w *load.Worker) (load.Outcome, error) {
+		if w.ID == 0 {
+			return load.StopWorker, nil
+		}
+		return load.Sent, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 10, sent)
+	// the first worker stops at its first job, if it gets one before the quota is used up
+	require.LessOrEqual(t, p.Workers[0].Failed, 1)
+	require.Equal(t, 10, p.Workers[1].Sent)
+}
+
+func TestWorkerPoolError(t *testing.T) {
+	p := newPool(3)
+
+	expErr := errors.New("sign tx")
+	var calls int64
+	_, err := p.Run(context.Background(), 1000, func(ctx context.Context, w *load.Worker) (load.Outcome, error) {
+		if atomic.AddInt64(&calls, 1) == 10 {
+			return load.Sent, expErr
+		}
+		return load.Sent, nil
+	})
+	require.ErrorIs(t, err, expErr)
+}
+
+func TestWorkerPoolRunUntilStop(t *testing.T) {
+	p := newPool(3)
+
+	stop := make(chan struct{})
+	var calls int64
+	var cancelled int64
+	sent, err := p.RunUntil(context.Background(), stop, 1000, func(ctx context.Context, w *load.Worker) (load.Outcome, error) {
+		if atomic.AddInt64(&calls, 1) == 5 {
+			close(stop)
+		}
+		if ctx.Err() != nil {
+			atomic.AddInt64(&cancelled, 1)
+		}
+		return load.Sent, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, int(calls), sent)
+	require.Less(t, sent, 1000)
+	require.Zero(t, cancelled)
+}
@@ -0,0 +1,100 @@
+package load
+
+import (
+	"fmt"
+	"math"
+)
+
+// Profile shapes the load over time. It is queried once per step, which is
+// a block for the block-synchronized workloads and a second for the rate-driven ones.
+type Profile interface {
+	// Count returns the planned number of transactions at the given zero-based step.
+	Count(step int) int
+	fmt.Stringer
+}
+
+// Flat is a profile which plans the same number of transactions at every step.
+type Flat struct {
+	N int
+}
+
+func (p Flat) Count(int) int { return p.N }
+
+func (p Flat) String() string { return fmt.Sprintf("flat(%d)", p.N) }
+
+// Ramp is a profile which linearly increases or decreases from From to To over Steps steps.
+type Ramp struct {
+	From  int
+	To    int
+	Steps int
+}
+
+func (p Ramp) Count(step int) int {
+	if step <= 0 {
+		return p.From
+	}
+	if step >= p.Steps-1 {
+		return p.To
+	}
+	return p.From + int(math.Round(float64(p.To-p.From)*float64(step)/float64(p.Steps-1)))
+}
+
+func (p Ramp) String() string { return fmt.Sprintf("ramp(%d->%d over %d)", p.From, p.To, p.Steps) }
+
+// Step is a profile which starts at Base and increases by Increment every Every steps.
+type Step struct {
+	Base      int
+	Increment int
+	Every     int
+}
+
+func (p Step) Count(step int) int {
+	return clamp(p.Base + p.Increment*(step/p.Every))
+}
+
+func (p Step) String() string {
+	return fmt.Sprintf("step(%d%+d every %d)", p.Base, p.Increment, p.Every)
+}
+
+// Spike is a profile which plans Base transactions, except Peak transactions
+// for Length steps starting at At after which it recovers back to Base.
+type Spike struct {
+	Base   int
+	Peak   int
+	At     int
+	Length int
+}
+
+func (p Spike) Count(step int) int {
+	if step >= p.At && step < p.At+p.Length {
+		return p.Peak
+	}
+	return p.Base
+}
+
+func (p Spike) String() string {
+	return fmt.Sprintf("spike(%d, %d at %d for %d)", p.Base, p.Peak, p.At, p.Length)
+}
+
+// Sine is a profile which oscillates around Base by Amplitude with the period of Period steps.
+type Sine struct {
+	Base      int
+	Amplitude int
+	Period    int
+}
+
+func (p Sine) Count(step int) int {
+	v := float64(p.Base) + float64(p.Amplitude)*math.Sin(2*math.Pi*float64(step)/float64(p.Period))
+	return clamp(int(math.Round(v)))
+}
+
+func (p Sine) String() string {
+	return fmt.Sprintf("sine(%d±%d per %d)", p.Base, p.Amplitude, p.Period)
+}
+
+func clamp(n int) int {
+	if n < 0 {
+		return 0
+	}
+	return n
+}
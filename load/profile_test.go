@@ -0,0 +1,41 @@
+package load_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/load"
+)
+
+func counts(p load.Profile, steps int) []int {
+	var cs []int
+	for i := 0; i < steps; i++ {
+		cs = append(cs, p.Count(i))
+	}
+	return cs
+}
+
+func TestProfiles(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		profile load.Profile
+		steps   int
+		exp     []int
+	}{
+		{"flat", load.Flat{N: 100}, 3, []int{100, 100, 100}},
+		{"ramp up", load.Ramp{From: 10, To: 500, Steps: 5}, 6, []int{10, 133, 255, 378, 500, 500}},
+		{"ramp down", load.Ramp{From: 100, To: 0, Steps: 3}, 3, []int{100, 50, 0}},
+		{"single step ramp", load.Ramp{From: 10, To: 20, Steps: 1}, 2, []int{10, 20}},
+		{"step", load.Step{Base: 100, Increment: 50, Every: 2}, 6, []int{100, 100, 150, 150, 200, 200}},
+		{"step down", load.Step{Base: 50, Increment: -30, Every: 1}, 3, []int{50, 20, 0}},
+		{"spike", load.Spike{Base: 10, Peak: 1000, At: 2, Length: 2}, 6, []int{10, 10, 1000, 1000, 10, 10}},
+		{"sine", load.Sine{Base: 100, Amplitude: 50, Period: 4}, 5, []int{100, 150, 100, 50, 100}},
+		{"sine below zero", load.Sine{Base: 10, Amplitude: 50, Period: 4}, 4, []int{10, 60, 10, 0}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.exp, counts(tc.profile, tc.steps))
+			require.NotEmpty(t, tc.profile.String())
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package load
+
+import (
+	"math/rand"
+)
+
+// NewRand returns the random source of a stream, e.g. a worker, derived from the seed of a run.
+// Every stream generates the same values on every run of the same seed, regardless of
+// how the streams are scheduled.
+func NewRand(seed int64, stream int) *rand.Rand {
+	return rand.New(rand.NewSource(mixSeed(seed, stream)))
+}
+
+// mixSeed spreads the seeds of the streams with the splitmix64 finalizer,
+// so that nearby seeds and streams do not produce correlated sources.
+func mixSeed(seed int64, stream int) int64 {
+	z := uint64(seed) + uint64(stream+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
+}
@@ -0,0 +1,27 @@
+package load_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/load"
+)
+
+func draw(r *rand.Rand) []int {
+	values := make([]int, 10)
+	for i := range values {
+		values[i] = r.Intn(1000)
+	}
+	return values
+}
+
+func TestNewRandIsReproducible(t *testing.T) {
+	require.Equal(t, draw(load.NewRand(42, 0)), draw(load.NewRand(42, 0)))
+	require.Equal(t, draw(load.NewRand(42, 3)), draw(load.NewRand(42, 3)))
+
+	require.NotEqual(t, draw(load.NewRand(42, 0)), draw(load.NewRand(42, 1)))
+	require.NotEqual(t, draw(load.NewRand(42, 0)), draw(load.NewRand(43, 0)))
+	require.NotEqual(t, draw(load.NewRand(42, 1)), draw(load.NewRand(43, 0)))
+}
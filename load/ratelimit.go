@@ -0,0 +1,237 @@
+package load
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TokenBucket is a token bucket scheduler which releases tokens at a constant rate,
+// or at the rate planned by a profile for every second, regardless of the block production of the network.
+type TokenBucket struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	tokens  float64
+	last    time.Time
+	profile Profile
+	start   time.Time
+}
+
+// NewTokenBucket creates a new TokenBucket which releases rate tokens per second
+// and holds at most burst tokens. The bucket starts empty.
+func NewTokenBucket(rate float64, burst int) (*TokenBucket, error) {
+	if rate <= 0 {
+		return nil, fmt.Errorf("rate must be positive: %f", rate)
+	}
+	if burst <= 0 {
+		return nil, fmt.Errorf("burst must be positive: %d", burst)
+	}
+	return &TokenBucket{
+		rate:  rate,
+		burst: float64(burst),
+	}, nil
+}
+
+// Shape has the bucket release the number of tokens planned by the profile in every second
+// from the start instead of the constant rate.
+func (b *TokenBucket) Shape(p Profile, start time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.profile = p
+	b.start = start
+}
+
+// Wait blocks until a token is available or the context is done.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	for {
+		d := b.Reserve(time.Now())
+		if d == 0 {
+			return nil
+		}
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Reserve takes a token at the given time if available and returns zero.
+// Otherwise it returns how long to wait until the next token is available.
+func (b *TokenBucket) Reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.last.IsZero() {
+		b.last = now
+	}
+	second := 0
+	if b.profile != nil {
+		// the tokens of the seconds passed are released at their own rates
+		second = int(now.Sub(b.start) / time.Second)
+		for s := int(b.last.Sub(b.start) / time.Second); s < second; s++ {
+			b.rate = float64(b.profile.Count(s))
+			b.accrue(b.start.Add(time.Duration(s+1) * time.Second))
+		}
+		b.rate = float64(b.profile.Count(second))
+	}
+	b.accrue(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	if b.rate <= 0 {
+		// nothing is planned until the next second
+		return b.start.Add(time.Duration(second+1) * time.Second).Sub(now)
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// accrue releases the tokens from the last release up to the given time.
+func (b *TokenBucket) accrue(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+// RateSample is the achieved rate of a single second of the run.
+type RateSample struct {
+	Second   int
+	Target   int
+	Achieved int
+	Rejected int
+	Lag      time.Duration
+}
+
+// RateRecorder records the achieved rate against the target rate per second
+// and tracks how far the sender falls behind the schedule.
+type RateRecorder struct {
+	mu         sync.Mutex
+	target     float64
+	profile    Profile
+	cumulative []int // txs planned by the profile before every second
+	start      time.Time
+	sent       int
+	current    RateSample
+	finished   []RateSample
+}
+
+// NewRateRecorder creates a new RateRecorder with the target rate per second starting at the given time.
+func NewRateRecorder(target float64, start time.Time) *RateRecorder {
+	return &RateRecorder{
+		target:  target,
+		start:   start,
+		current: RateSample{Second: 0, Target: targetOf(target, 0)},
+	}
+}
+
+// Shape has the recorder follow the number of transactions planned by the profile in every second
+// instead of the constant target rate.
+func (r *RateRecorder) Shape(p Profile) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.profile = p
+	r.cumulative = []int{0}
+	r.current.Target = r.targetAt(r.current.Second)
+}
+
+// targetAt returns the number of transactions planned within the given second.
+func (r *RateRecorder) targetAt(second int) int {
+	if r.profile != nil {
+		return r.profile.Count(second)
+	}
+	return targetOf(r.target, second)
+}
+
+// targetOf returns the number of tokens scheduled within the given second.
+func targetOf(rate float64, second int) int {
+	return int(math.Floor(rate*float64(second+1))) - int(math.Floor(rate*float64(second)))
+}
+
+// RecordSent records a broadcast transaction at the given time.
+func (r *RateRecorder) RecordSent(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.advance(now)
+	r.sent++
+	r.current.Achieved++
+	r.current.Lag = r.lag(now)
+}
+
+// RecordRejected records a transaction rejected by the node at the given time.
+func (r *RateRecorder) RecordRejected(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.advance(now)
+	r.current.Rejected++
+}
+
+// Lag returns how far the sender is behind the schedule at the given time.
+func (r *RateRecorder) Lag(now time.Time) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lag(now)
+}
+
+func (r *RateRecorder) lag(now time.Time) time.Duration {
+	if r.profile != nil {
+		return r.profileLag(now)
+	}
+	scheduled := now.Sub(r.start).Seconds() * r.target
+	behind := scheduled - float64(r.sent)
+	if behind <= 0 {
+		return 0
+	}
+	return time.Duration(behind / r.target * float64(time.Second))
+}
+
+// profileLag returns how long ago the next transaction to be sent was planned by the profile.
+func (r *RateRecorder) profileLag(now time.Time) time.Duration {
+	elapsed := now.Sub(r.start)
+	second := int(elapsed / time.Second)
+	for len(r.cumulative) < second+2 {
+		n := len(r.cumulative)
+		r.cumulative = append(r.cumulative, r.cumulative[n-1]+r.profile.Count(n-1))
+	}
+	frac := float64(elapsed%time.Second) / float64(time.Second)
+	scheduled := float64(r.cumulative[second]) + float64(r.profile.Count(second))*frac
+	if scheduled <= float64(r.sent) {
+		return 0
+	}
+	// the next transaction is planned within the second s
+	s := sort.SearchInts(r.cumulative, r.sent+1) - 1
+	due := time.Duration(s)*time.Second +
+		time.Duration(float64(r.sent-r.cumulative[s])/float64(r.profile.Count(s))*float64(time.Second))
+	return elapsed - due
+}
+
+// Flush returns the samples of the seconds completed before the given time.
+func (r *RateRecorder) Flush(now time.Time) []RateSample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.advance(now)
+	samples := r.finished
+	r.finished = nil
+	return samples
+}
+
+// advance moves the current sample to the second of the given time,
+// finishing samples of the seconds passed.
+func (r *RateRecorder) advance(now time.Time) {
+	second := int(now.Sub(r.start) / time.Second)
+	for r.current.Second < second {
+		if r.current.Achieved == 0 {
+			r.current.Lag = r.lag(r.start.Add(time.Duration(r.current.Second+1) * time.Second))
+		}
+		r.finished = append(r.finished, r.current)
+		next := r.current.Second + 1
+		r.current = RateSample{Second: next, Target: r.targetAt(next)}
+	}
+}
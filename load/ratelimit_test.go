@@ -0,0 +1,109 @@
+package load_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/load"
+)
+
+func TestTokenBucketReserve(t *testing.T) {
+	b, err := load.NewTokenBucket(10, 5)
+	require.NoError(t, err)
+
+	start := time.Unix(0, 0)
+
+	// the bucket starts empty
+	require.Equal(t, 100*time.Millisecond, b.Reserve(start))
+	require.Equal(t, time.Duration(0), b.Reserve(start.Add(100*time.Millisecond)))
+
+	// tokens accumulate up to the burst
+	now := start.Add(10 * time.Second)
+	for i := 0; i < 5; i++ {
+		require.Equal(t, time.Duration(0), b.Reserve(now))
+	}
+	require.Equal(t, 100*time.Millisecond, b.Reserve(now))
+
+	_, err = load.NewTokenBucket(0, 1)
+	require.Error(t, err)
+	_, err = load.NewTokenBucket(1, 0)
+	require.Error(t, err)
+}
+
+func TestTokenBucketWait(t *testing.T) {
+	b, err := load.NewTokenBucket(1000, 1)
+	require.NoError(t, err)
+	require.NoError(t, b.Wait(context.Background()))
+
+	b, err = load.NewTokenBucket(0.001, 1)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, b.Wait(ctx), context.Canceled)
+}
+
+func TestRateRecorder(t *testing.T) {
+	start := time.Unix(0, 0)
+	r := load.NewRateRecorder(4, start)
+
+	// on schedule during the first second
+	for i := 0; i < 4; i++ {
+		r.RecordSent(start.Add(time.Duration(i) * 250 * time.Millisecond))
+	}
+	// only one tx sent during the second second
+	r.RecordSent(start.Add(1500 * time.Millisecond))
+	r.RecordRejected(start.Add(1600 * time.Millisecond))
+
+	require.Equal(t, 750*time.Millisecond, r.Lag(start.Add(2*time.Second)))
+
+	samples := r.Flush(start.Add(3 * time.Second))
+	require.Len(t, samples, 3)
+	require.Equal(t, load.RateSample{Second: 0, Target: 4, Achieved: 4}, samples[0])
+	require.Equal(t, 1, samples[1].Achieved)
+	require.Equal(t, 1, samples[1].Rejected)
+	require.Equal(t, 4, samples[1].Target)
+	require.Equal(t, 0, samples[2].Achieved)
+	require.Equal(t, 1750*time.Millisecond, samples[2].Lag)
+
+	require.Empty(t, r.Flush(start.Add(3*time.Second)))
+}
+
+func TestTokenBucketShape(t *testing.T) {
+	start := time.Unix(0, 0)
+	b, err := load.NewTokenBucket(1, 10)
+	require.NoError(t, err)
+	b.Shape(load.Spike{Base: 0, Peak: 2, At: 1, Length: 1}, start)
+
+	// nothing is planned during the first second
+	require.Equal(t, time.Second, b.Reserve(start))
+	require.Equal(t, 500*time.Millisecond, b.Reserve(start.Add(500*time.Millisecond)))
+
+	// two tokens are released during the second second
+	require.Equal(t, 500*time.Millisecond, b.Reserve(start.Add(time.Second)))
+	require.Equal(t, time.Duration(0), b.Reserve(start.Add(1500*time.Millisecond)))
+	require.Equal(t, time.Duration(0), b.Reserve(start.Add(2*time.Second)))
+	require.Equal(t, time.Second, b.Reserve(start.Add(2*time.Second)))
+}
+
+func TestRateRecorderShape(t *testing.T) {
+	start := time.Unix(0, 0)
+	r := load.NewRateRecorder(1, start)
+	r.Shape(load.Ramp{From: 2, To: 4, Steps: 3})
+
+	r.RecordSent(start.Add(100 * time.Millisecond))
+	r.RecordSent(start.Add(600 * time.Millisecond))
+	// the third tx was planned at the start of the second second
+	require.Equal(t, 250*time.Millisecond, r.Lag(start.Add(1250*time.Millisecond)))
+
+	samples := r.Flush(start.Add(3 * time.Second))
+	require.Len(t, samples, 3)
+	require.Equal(t, 2, samples[0].Target)
+	require.Equal(t, 3, samples[1].Target)
+	require.Equal(t, 4, samples[2].Target)
+	require.Equal(t, time.Duration(0), samples[0].Lag)
+	// 9 txs were planned by the end of the run, and the third of them was planned 2 seconds before
+	require.Equal(t, 2*time.Second, samples[2].Lag)
+}
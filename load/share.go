@@ -0,0 +1,21 @@
+package load
+
+// Share is the part of the load taken by one of several generators, e.g. the agents of a distributed run.
+// The zero value takes the whole load.
+type Share struct {
+	Index int
+	Of    int
+}
+
+// Part returns the share of n txs. The remainder is spread over the first generators,
+// so that the parts of all generators add up to n.
+func (s Share) Part(n int) int {
+	if s.Of <= 1 {
+		return n
+	}
+	p := n / s.Of
+	if s.Index < n%s.Of {
+		p++
+	}
+	return p
+}
@@ -0,0 +1,23 @@
+package load_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/load"
+)
+
+func TestSharePart(t *testing.T) {
+	require.Equal(t, 100, load.Share{}.Part(100))
+
+	for _, n := range []int{0, 1, 2, 99, 100, 101} {
+		total := 0
+		for i := 0; i < 3; i++ {
+			p := load.Share{Index: i, Of: 3}.Part(n)
+			require.InDelta(t, float64(n)/3, p, 1)
+			total += p
+		}
+		require.Equal(t, n, total)
+	}
+}
@@ -0,0 +1,98 @@
+package load
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/b-harvest/modules-test-tool/errclass"
+)
+
+// Summary sums up the blocks and the responses of a run.
+type Summary struct {
+	mu sync.Mutex
+
+	FirstHeight int64
+	LastHeight  int64
+	Blocks      int
+	Broadcast   int
+	Committed   int
+	Codes       map[uint32]int     // number of broadcast responses by code
+	Classes     errclass.Histogram // number of broadcast responses by error class
+	Delivered   errclass.Histogram // number of results of the committed txs by error class
+}
+
+// NewSummary creates a new empty Summary.
+func NewSummary() *Summary {
+	return &Summary{
+		Codes:     make(map[uint32]int),
+		Classes:   make(errclass.Histogram),
+		Delivered: make(errclass.Histogram),
+	}
+}
+
+// AddResponse counts a broadcast response by its code and its error class, which it returns.
+// It is safe for concurrent use.
+func (s *Summary) AddResponse(codespace string, code uint32) errclass.Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Codes[code]++
+	return s.Classes.Add(codespace, code)
+}
+
+// AddDelivered counts the result of a committed tx by its error class. It is safe for concurrent use.
+func (s *Summary) AddDelivered(codespace string, code uint32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Delivered.Add(codespace, code)
+}
+
+// AddBlock adds the result of a single block.
+func (s *Summary) AddBlock(height int64, broadcast, committed int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Blocks == 0 || height < s.FirstHeight {
+		s.FirstHeight = height
+	}
+	if height > s.LastHeight {
+		s.LastHeight = height
+	}
+	s.Blocks++
+	s.Broadcast += broadcast
+	s.Committed += committed
+}
+
+// Print prints the summary as a table.
+func (s *Summary) Print(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if s.Blocks == 0 {
+		fmt.Fprintln(tw, "blocks\t0")
+	} else {
+		fmt.Fprintf(tw, "blocks\t%d (%d-%d)\n", s.Blocks, s.FirstHeight, s.LastHeight)
+	}
+	fmt.Fprintf(tw, "broadcast txs\t%d\n", s.Broadcast)
+	fmt.Fprintf(tw, "committed txs\t%d\n", s.Committed)
+
+	codes := make([]uint32, 0, len(s.Codes))
+	for code := range s.Codes {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	for _, code := range codes {
+		fmt.Fprintf(tw, "code %#x\t%d\n", code, s.Codes[code])
+	}
+	classes := s.Classes.Counts()
+	for _, class := range errclass.SortedClasses(classes) {
+		fmt.Fprintf(tw, "class %s\t%d\n", class, classes[class])
+	}
+	delivered := s.Delivered.Counts()
+	for _, class := range errclass.SortedClasses(delivered) {
+		fmt.Fprintf(tw, "delivered class %s\t%d\n", class, delivered[class])
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,56 @@
+package load_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/load"
+)
+
+func TestSummary(t *testing.T) {
+	s := load.NewSummary()
+	s.AddBlock(101, 10, 9)
+	s.AddBlock(102, 20, 20)
+	for i := 0; i < 30; i++ {
+		s.AddResponse("", 0)
+	}
+	s.AddResponse("sdk", 0x14)
+	s.AddResponse("sdk", 0x13)
+
+	var b strings.Builder
+	require.NoError(t, s.Print(&b))
+	require.Equal(t, `blocks              2 (101-102)
+broadcast txs       30
+committed txs       29
+code 0x0            30
+code 0x13           1
+code 0x14           1
+class ok            30
+class sequence      1
+class mempool_full  1
+`, b.String())
+}
+
+func TestSummaryClasses(t *testing.T) {
+	s := load.NewSummary()
+	s.AddBlock(101, 2, 1)
+	require.Equal(t, "ok", s.AddResponse("", 0).Name)
+	require.Equal(t, "mempool is full", s.AddResponse("sdk", 0x14).Name)
+	s.AddDelivered("", 0)
+	s.AddDelivered("liquidity", 31)
+
+	var b strings.Builder
+	require.NoError(t, s.Print(&b))
+	require.Equal(t, `blocks                     1 (101-101)
+broadcast txs              2
+committed txs              1
+code 0x0                   1
+code 0x14                  1
+class ok                   1
+class mempool_full         1
+delivered class ok         1
+delivered class liquidity  1
+`, b.String())
+}
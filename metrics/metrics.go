@@ -0,0 +1,155 @@
+// Package metrics exposes the telemetry of the workload commands to Prometheus.
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
+)
+
+const namespace = "tester"
+
+// Metrics are the telemetry of a workload run, labeled by the chain id.
+// All methods are no-ops on a nil *Metrics, so that the metrics can be disabled by passing nil.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	txsSigned        *prometheus.CounterVec
+	txsBroadcast     *prometheus.CounterVec
+	txsRejected      *prometheus.CounterVec
+	txsCommitted     *prometheus.CounterVec
+	blockTxs         *prometheus.HistogramVec
+	signLatency      *prometheus.HistogramVec
+	broadcastLatency *prometheus.HistogramVec
+	blockDuration    *prometheus.HistogramVec
+	mempoolTxs       *prometheus.GaugeVec
+	mempoolBytes     *prometheus.GaugeVec
+}
+
+// New creates new Metrics of the given command.
+func New(command string) *Metrics {
+	labels := prometheus.Labels{"command": command}
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		txsSigned: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "txs_signed_total", Help: "Number of signed txs.", ConstLabels: labels,
+		}, []string{"chain_id"}),
+		txsBroadcast: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "txs_broadcast_total", Help: "Number of txs accepted by the node.", ConstLabels: labels,
+		}, []string{"chain_id"}),
+		txsRejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "txs_rejected_total", Help: "Number of txs rejected by the node by ABCI code.", ConstLabels: labels,
+		}, []string{"chain_id", "code"}),
+		txsCommitted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "txs_committed_total", Help: "Number of txs committed in the watched blocks.", ConstLabels: labels,
+		}, []string{"chain_id"}),
+		blockTxs: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "block_txs", Help: "Number of txs committed per block.", ConstLabels: labels,
+			Buckets: prometheus.ExponentialBuckets(1, 2, 14),
+		}, []string{"chain_id"}),
+		signLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "sign_duration_seconds", Help: "Time taken to sign a tx.", ConstLabels: labels,
+			Buckets: prometheus.ExponentialBuckets(0.0001, 2, 14),
+		}, []string{"chain_id"}),
+		broadcastLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "broadcast_duration_seconds", Help: "Time taken to broadcast a tx.", ConstLabels: labels,
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 14),
+		}, []string{"chain_id"}),
+		blockDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "block_duration_seconds", Help: "Time between the watched block and the previous one.", ConstLabels: labels,
+			Buckets: prometheus.LinearBuckets(1, 1, 20),
+		}, []string{"chain_id"}),
+		mempoolTxs: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "mempool_txs", Help: "Number of unconfirmed txs in the mempool.", ConstLabels: labels,
+		}, []string{"chain_id"}),
+		mempoolBytes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "mempool_bytes", Help: "Total size of the unconfirmed txs in the mempool.", ConstLabels: labels,
+		}, []string{"chain_id"}),
+	}
+	m.registry.MustRegister(
+		m.txsSigned,
+		m.txsBroadcast,
+		m.txsRejected,
+		m.txsCommitted,
+		m.blockTxs,
+		m.signLatency,
+		m.broadcastLatency,
+		m.blockDuration,
+		m.mempoolTxs,
+		m.mempoolBytes,
+	)
+	return m
+}
+
+// Registry returns the registry of the metrics.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Serve serves the metrics at /metrics of the given address until the returned server is closed.
+func (m *Metrics) Serve(addr string) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	srv := &http.Server{Handler: mux}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Err(err).Msg("failed to serve metrics")
+		}
+	}()
+	log.Info().Str("addr", ln.Addr().String()).Msg("serving metrics")
+	return srv, nil
+}
+
+// ObserveSign records a signed tx.
+func (m *Metrics) ObserveSign(chainID string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.txsSigned.WithLabelValues(chainID).Inc()
+	m.signLatency.WithLabelValues(chainID).Observe(d.Seconds())
+}
+
+// ObserveBroadcast records a broadcast tx with the code of its response.
+func (m *Metrics) ObserveBroadcast(chainID string, d time.Duration, code uint32) {
+	if m == nil {
+		return
+	}
+	m.broadcastLatency.WithLabelValues(chainID).Observe(d.Seconds())
+	if code == 0 {
+		m.txsBroadcast.WithLabelValues(chainID).Inc()
+	} else {
+		m.txsRejected.WithLabelValues(chainID, strconv.FormatUint(uint64(code), 10)).Inc()
+	}
+}
+
+// ObserveBlock records a committed block. A zero duration is unknown and not recorded.
+func (m *Metrics) ObserveBlock(chainID string, committed int, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.txsCommitted.WithLabelValues(chainID).Add(float64(committed))
+	m.blockTxs.WithLabelValues(chainID).Observe(float64(committed))
+	if d > 0 {
+		m.blockDuration.WithLabelValues(chainID).Observe(d.Seconds())
+	}
+}
+
+// SetMempool records the size of the mempool.
+func (m *Metrics) SetMempool(chainID string, txs int, bytes int64) {
+	if m == nil {
+		return
+	}
+	m.mempoolTxs.WithLabelValues(chainID).Set(float64(txs))
+	m.mempoolBytes.WithLabelValues(chainID).Set(float64(bytes))
+}
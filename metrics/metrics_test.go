@@ -0,0 +1,48 @@
+package metrics_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	m := metrics.New("stress-test")
+	m.ObserveSign("test", time.Millisecond)
+	m.ObserveSign("test", time.Millisecond)
+	m.ObserveBroadcast("test", 10*time.Millisecond, 0)
+	m.ObserveBroadcast("test", 10*time.Millisecond, 0x14)
+	m.ObserveBlock("test", 2, 5*time.Second)
+	m.SetMempool("test", 3, 1024)
+
+	expected := `
+# HELP tester_txs_rejected_total Number of txs rejected by the node by ABCI code.
+# TYPE tester_txs_rejected_total counter
+tester_txs_rejected_total{chain_id="test",code="20",command="stress-test"} 1
+# HELP tester_txs_signed_total Number of signed txs.
+# TYPE tester_txs_signed_total counter
+tester_txs_signed_total{chain_id="test",command="stress-test"} 2
+# HELP tester_mempool_bytes Total size of the unconfirmed txs in the mempool.
+# TYPE tester_mempool_bytes gauge
+tester_mempool_bytes{chain_id="test",command="stress-test"} 1024
+`
+	require.NoError(t, testutil.GatherAndCompare(m.Registry(), strings.NewReader(expected),
+		"tester_txs_rejected_total", "tester_txs_signed_total", "tester_mempool_bytes"))
+
+	n, err := testutil.GatherAndCount(m.Registry(), "tester_block_duration_seconds", "tester_broadcast_duration_seconds")
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+}
+
+func TestNilMetrics(t *testing.T) {
+	var m *metrics.Metrics
+	m.ObserveSign("test", time.Millisecond)
+	m.ObserveBroadcast("test", time.Millisecond, 0)
+	m.ObserveBlock("test", 1, time.Second)
+	m.SetMempool("test", 1, 1)
+}
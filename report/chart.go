@@ -0,0 +1,110 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+const (
+	chartWidth   = 720
+	chartHeight  = 240
+	chartPadding = 40
+)
+
+// series is a line of a chart.
+type series struct {
+	name   string
+	color  string
+	values []float64
+}
+
+// lineChart renders the series as an SVG line chart, one point per block.
+func lineChart(title, unit string, ss ...series) string {
+	n, max := 0, 0.0
+	for _, s := range ss {
+		if len(s.values) > n {
+			n = len(s.values)
+		}
+		for _, v := range s.values {
+			if v > max {
+				max = v
+			}
+		}
+	}
+	if max == 0 {
+		max = 1
+	}
+
+	plotW := float64(chartWidth - 2*chartPadding)
+	plotH := float64(chartHeight - 2*chartPadding)
+	x := func(i int) float64 {
+		if n <= 1 {
+			return chartPadding + plotW/2
+		}
+		return chartPadding + plotW*float64(i)/float64(n-1)
+	}
+	y := func(v float64) float64 {
+		return chartPadding + plotH*(1-v/max)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`,
+		chartWidth, chartHeight, chartWidth, chartHeight)
+	fmt.Fprintf(&sb, `<rect width="100%%" height="100%%" fill="white"/>`)
+	fmt.Fprintf(&sb, `<text x="%d" y="20" font-weight="bold">%s</text>`, chartPadding, html.EscapeString(title))
+
+	// axes with the maximum and zero on the left and the number of blocks at the bottom
+	fmt.Fprintf(&sb, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#888"/>`,
+		chartPadding, chartPadding, chartPadding, chartHeight-chartPadding)
+	fmt.Fprintf(&sb, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#888"/>`,
+		chartPadding, chartHeight-chartPadding, chartWidth-chartPadding, chartHeight-chartPadding)
+	fmt.Fprintf(&sb, `<text x="%d" y="%d" text-anchor="end">%s</text>`, chartPadding-4, chartPadding+4, formatValue(max, unit))
+	fmt.Fprintf(&sb, `<text x="%d" y="%d" text-anchor="end">0</text>`, chartPadding-4, chartHeight-chartPadding+4)
+	fmt.Fprintf(&sb, `<text x="%d" y="%d" text-anchor="end">%d blocks</text>`, chartWidth-chartPadding, chartHeight-chartPadding+16, n)
+
+	for i, s := range ss {
+		points := make([]string, len(s.values))
+		for j, v := range s.values {
+			points[j] = fmt.Sprintf("%.1f,%.1f", x(j), y(v))
+		}
+		fmt.Fprintf(&sb, `<polyline fill="none" stroke="%s" stroke-width="1.5" points="%s"/>`, s.color, strings.Join(points, " "))
+		legendX := chartPadding + 160*i
+		fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="10" height="10" fill="%s"/>`, legendX, chartHeight-16, s.color)
+		fmt.Fprintf(&sb, `<text x="%d" y="%d">%s</text>`, legendX+14, chartHeight-7, html.EscapeString(s.name))
+	}
+	sb.WriteString(`</svg>`)
+	return sb.String()
+}
+
+func formatValue(v float64, unit string) string {
+	if v == float64(int64(v)) {
+		return fmt.Sprintf("%d%s", int64(v), unit)
+	}
+	return fmt.Sprintf("%.2f%s", v, unit)
+}
+
+// txsChart charts the broadcast and committed txs per block of the scenario.
+func txsChart(s *Scenario) string {
+	broadcast := make([]float64, len(s.Blocks))
+	committed := make([]float64, len(s.Blocks))
+	for i, b := range s.Blocks {
+		broadcast[i] = float64(b.Broadcast)
+		committed[i] = float64(b.Committed)
+	}
+	return lineChart("txs per block", "",
+		series{name: "broadcast", color: "#1f77b4", values: broadcast},
+		series{name: "committed", color: "#2ca02c", values: committed},
+	)
+}
+
+// durationChart charts the block durations of the scenario in seconds. Unknown durations are charted as zero.
+func durationChart(s *Scenario) string {
+	durations := make([]float64, len(s.Blocks))
+	for i, b := range s.Blocks {
+		durations[i] = b.BlockDuration.Seconds()
+	}
+	return lineChart("block duration", "s",
+		series{name: "block duration", color: "#d62728", values: durations},
+	)
+}
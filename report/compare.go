@@ -0,0 +1,234 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/b-harvest/modules-test-tool/load"
+)
+
+// The metrics compared between runs.
+const (
+	MetricThroughput  = "throughput"
+	MetricBlockTime   = "block_time"
+	MetricCommitRatio = "commit_ratio"
+	MetricLatencyP50  = "latency_p50"
+	MetricLatencyP90  = "latency_p90"
+	MetricLatencyP99  = "latency_p99"
+)
+
+// LatencyScenario is the scenario of the latency deltas, which are compared over the whole runs.
+const LatencyScenario = "inclusion"
+
+// CompareOptions are the options of a comparison.
+type CompareOptions struct {
+	// Threshold is the relative change in the worse direction from which a significant change is a regression.
+	Threshold float64
+	// Alpha is the significance level of the tests.
+	Alpha float64
+}
+
+// Delta is the change of a metric of a scenario from the baseline to the candidate.
+type Delta struct {
+	Scenario  string
+	Metric    string
+	Baseline  float64
+	Candidate float64
+	// Change is the change relative to the baseline; positive if the candidate is higher.
+	Change float64
+	// PValue is the p-value of the test of the change, NaN if there were too few samples to test it.
+	PValue float64
+	// Worse is true if the change is in the worse direction of the metric.
+	Worse bool
+	// Regression is true if the candidate is worse by more than the threshold, and the change is significant
+	// or could not be tested.
+	Regression bool
+}
+
+// Significant returns true if the change was tested significant at the given level.
+func (d Delta) Significant(alpha float64) bool {
+	return !math.IsNaN(d.PValue) && d.PValue < alpha
+}
+
+// Comparison is the comparison of a candidate with a baseline, aligned by scenario.
+type Comparison struct {
+	Options       CompareOptions
+	Deltas        []Delta
+	OnlyBaseline  []string // scenarios run by the baseline only
+	OnlyCandidate []string // scenarios run by the candidate only
+}
+
+// Regressions returns the deltas which are regressions.
+func (c *Comparison) Regressions() []Delta {
+	var regressions []Delta
+	for _, d := range c.Deltas {
+		if d.Regression {
+			regressions = append(regressions, d)
+		}
+	}
+	return regressions
+}
+
+// Compare compares the throughput, the block time and the commit ratio of every scenario run by both
+// the baseline and the candidate.
+func Compare(baseline, candidate *Report, opts CompareOptions) *Comparison {
+	c := &Comparison{Options: opts}
+	candidates := make(map[string]*Scenario)
+	for _, s := range candidate.Scenarios {
+		candidates[s.Name] = s
+	}
+	baselines := make(map[string]bool)
+	for _, b := range baseline.Scenarios {
+		baselines[b.Name] = true
+		s, ok := candidates[b.Name]
+		if !ok {
+			c.OnlyBaseline = append(c.OnlyBaseline, b.Name)
+			continue
+		}
+		c.add(b.Name, MetricThroughput, b.Throughput(), s.Throughput(), true,
+			welchTest(b.blockThroughputs(), s.blockThroughputs()))
+		c.add(b.Name, MetricBlockTime, b.BlockDurations.Avg.Seconds(), s.BlockDurations.Avg.Seconds(), false,
+			welchTest(b.blockSeconds(), s.blockSeconds()))
+		c.add(b.Name, MetricCommitRatio, b.CommitRatio(), s.CommitRatio(), true,
+			proportionTest(b.Committed, b.Broadcast, s.Committed, s.Broadcast))
+	}
+	for _, s := range candidate.Scenarios {
+		if !baselines[s.Name] {
+			c.OnlyCandidate = append(c.OnlyCandidate, s.Name)
+		}
+	}
+	return c
+}
+
+// CompareLatencies adds the deltas of the inclusion latency percentiles of the runs to the comparison.
+// The percentiles are tested together by the Mann-Whitney U test of the latencies.
+func (c *Comparison) CompareLatencies(baseline, candidate []time.Duration) {
+	if len(baseline) == 0 || len(candidate) == 0 {
+		return
+	}
+	b, s := sortedDurations(baseline), sortedDurations(candidate)
+	p := mannWhitneyTest(durationSeconds(b), durationSeconds(s))
+	for _, pct := range []struct {
+		metric string
+		p      float64
+	}{{MetricLatencyP50, 50}, {MetricLatencyP90, 90}, {MetricLatencyP99, 99}} {
+		c.add(LatencyScenario, pct.metric, load.Percentile(b, pct.p).Seconds(), load.Percentile(s, pct.p).Seconds(), false, p)
+	}
+}
+
+func (c *Comparison) add(scenario, metric string, baseline, candidate float64, higherIsBetter bool, p float64) {
+	d := Delta{
+		Scenario:  scenario,
+		Metric:    metric,
+		Baseline:  baseline,
+		Candidate: candidate,
+		Change:    relativeChange(baseline, candidate),
+		PValue:    p,
+	}
+	d.Worse = candidate < baseline
+	if !higherIsBetter {
+		d.Worse = candidate > baseline
+	}
+	d.Regression = d.Worse && math.Abs(d.Change) > c.Options.Threshold &&
+		(math.IsNaN(p) || p < c.Options.Alpha)
+	c.Deltas = append(c.Deltas, d)
+}
+
+func relativeChange(baseline, candidate float64) float64 {
+	if baseline == candidate {
+		return 0
+	}
+	if baseline == 0 {
+		return math.Inf(1) * math.Copysign(1, candidate)
+	}
+	return (candidate - baseline) / math.Abs(baseline)
+}
+
+// blockThroughputs returns the committed txs per second of every block of a known duration.
+func (s *Scenario) blockThroughputs() []float64 {
+	var xs []float64
+	for _, b := range s.Blocks {
+		if b.BlockDuration > 0 {
+			xs = append(xs, float64(b.Committed)/b.BlockDuration.Seconds())
+		}
+	}
+	return xs
+}
+
+// blockSeconds returns the known block durations in seconds.
+func (s *Scenario) blockSeconds() []float64 {
+	var xs []float64
+	for _, b := range s.Blocks {
+		if b.BlockDuration > 0 {
+			xs = append(xs, b.BlockDuration.Seconds())
+		}
+	}
+	return xs
+}
+
+func sortedDurations(ds []time.Duration) []time.Duration {
+	sorted := append([]time.Duration(nil), ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+func durationSeconds(ds []time.Duration) []float64 {
+	xs := make([]float64, len(ds))
+	for i, d := range ds {
+		xs[i] = d.Seconds()
+	}
+	return xs
+}
+
+// Print prints the deltas as a table followed by the scenarios which could not be aligned.
+func (c *Comparison) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "scenario\tmetric\tbaseline\tcandidate\tchange\tp-value\tverdict")
+	for _, d := range c.Deltas {
+		p := "-"
+		if !math.IsNaN(d.PValue) {
+			p = fmt.Sprintf("%.4f", d.PValue)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%+.1f%%\t%s\t%s\n",
+			d.Scenario, d.Metric, formatMetric(d.Metric, d.Baseline), formatMetric(d.Metric, d.Candidate),
+			d.Change*100, p, c.verdict(d))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, name := range c.OnlyBaseline {
+		fmt.Fprintf(w, "scenario %s was run by the baseline only\n", name)
+	}
+	for _, name := range c.OnlyCandidate {
+		fmt.Fprintf(w, "scenario %s was run by the candidate only\n", name)
+	}
+	return nil
+}
+
+func (c *Comparison) verdict(d Delta) string {
+	switch {
+	case d.Regression:
+		return "REGRESSION"
+	case d.Change == 0 || !d.Significant(c.Options.Alpha):
+		return "no significant change"
+	case d.Worse:
+		return "worse, within threshold"
+	default:
+		return "better"
+	}
+}
+
+func formatMetric(metric string, v float64) string {
+	switch metric {
+	case MetricThroughput:
+		return fmt.Sprintf("%.2f tx/s", v)
+	case MetricCommitRatio:
+		return fmt.Sprintf("%.2f%%", v*100)
+	default:
+		return time.Duration(v * float64(time.Second)).Round(time.Millisecond).String()
+	}
+}
@@ -0,0 +1,139 @@
+package report_test
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/report"
+)
+
+// blocks returns the blocks of a scenario of the given durations in seconds, each committing the given txs.
+func blocks(scenario string, committed, broadcast int, seconds ...int) []report.Block {
+	bs := make([]report.Block, len(seconds))
+	for i, sec := range seconds {
+		bs[i] = report.Block{
+			Scenario:      scenario,
+			Height:        int64(i + 1),
+			BlockDuration: time.Duration(sec) * time.Second,
+			Broadcast:     broadcast,
+			Committed:     committed,
+		}
+	}
+	return bs
+}
+
+func deltaOf(t *testing.T, c *report.Comparison, scenario, metric string) report.Delta {
+	for _, d := range c.Deltas {
+		if d.Scenario == scenario && d.Metric == metric {
+			return d
+		}
+	}
+	t.Fatalf("no delta of %s %s", scenario, metric)
+	return report.Delta{}
+}
+
+func TestCompare(t *testing.T) {
+	opts := report.CompareOptions{Threshold: 0.05, Alpha: 0.05}
+	baseline := report.Build(report.Results{Blocks: append(
+		blocks("steady", 19, 20, 1, 2, 3, 4, 5),
+		blocks("only-baseline", 1, 1, 1)...)})
+	candidate := report.Build(report.Results{Blocks: append(
+		blocks("steady", 18, 20, 2, 3, 4, 5, 6),
+		blocks("only-candidate", 1, 1, 1)...)})
+
+	c := report.Compare(baseline, candidate, opts)
+	require.Equal(t, []string{"only-baseline"}, c.OnlyBaseline)
+	require.Equal(t, []string{"only-candidate"}, c.OnlyCandidate)
+	require.Len(t, c.Deltas, 3)
+
+	// Welch's t-test of 1..5s against 2..6s: t = -1 at 8 degrees of freedom
+	bt := deltaOf(t, c, "steady", report.MetricBlockTime)
+	require.InDelta(t, 3, bt.Baseline, 1e-9)
+	require.InDelta(t, 4, bt.Candidate, 1e-9)
+	require.InDelta(t, 1.0/3, bt.Change, 1e-9)
+	require.InDelta(t, 0.3466, bt.PValue, 1e-4)
+	require.True(t, bt.Worse)
+	require.False(t, bt.Regression)
+
+	// two-proportion z-test of 95/100 against 90/100
+	cr := deltaOf(t, c, "steady", report.MetricCommitRatio)
+	require.InDelta(t, 0.1795, cr.PValue, 1e-4)
+	require.False(t, cr.Regression)
+
+	tp := deltaOf(t, c, "steady", report.MetricThroughput)
+	require.Less(t, tp.Change, 0.0)
+	require.True(t, tp.Worse)
+
+	// a much slower candidate of many blocks is a significant regression
+	slow := report.Build(report.Results{Blocks: blocks("steady", 19, 20, 2, 3, 2, 3, 2, 3, 2, 3, 2, 3)})
+	fast := report.Build(report.Results{Blocks: blocks("steady", 19, 20, 1, 2, 1, 2, 1, 2, 1, 2, 1, 2)})
+	c = report.Compare(fast, slow, opts)
+	require.True(t, deltaOf(t, c, "steady", report.MetricBlockTime).Regression)
+	require.True(t, deltaOf(t, c, "steady", report.MetricThroughput).Regression)
+	require.Len(t, c.Regressions(), 2)
+
+	// and the other way around an improvement
+	c = report.Compare(slow, fast, opts)
+	require.Empty(t, c.Regressions())
+	require.True(t, deltaOf(t, c, "steady", report.MetricBlockTime).Significant(opts.Alpha))
+
+	var buf bytes.Buffer
+	require.NoError(t, report.Compare(fast, slow, opts).Print(&buf))
+	require.Contains(t, buf.String(), "REGRESSION")
+	require.NoError(t, c.Print(&buf))
+	require.Contains(t, buf.String(), "better")
+}
+
+func TestCompareLatencies(t *testing.T) {
+	opts := report.CompareOptions{Threshold: 0.1, Alpha: 0.05}
+	var baseline, candidate []time.Duration
+	for i := 1; i <= 100; i++ {
+		baseline = append(baseline, time.Duration(i)*10*time.Millisecond)
+		candidate = append(candidate, time.Duration(i)*20*time.Millisecond)
+	}
+
+	c := &report.Comparison{Options: opts}
+	c.CompareLatencies(baseline, candidate)
+	require.Len(t, c.Deltas, 3)
+	p99 := deltaOf(t, c, report.LatencyScenario, report.MetricLatencyP99)
+	require.InDelta(t, 0.99, p99.Baseline, 0.011)
+	require.InDelta(t, 1, p99.Change, 0.02)
+	require.Less(t, p99.PValue, 0.001)
+	require.Len(t, c.Regressions(), 3)
+
+	c = &report.Comparison{Options: opts}
+	c.CompareLatencies(baseline, baseline)
+	require.Empty(t, c.Regressions())
+	require.InDelta(t, 1, c.Deltas[0].PValue, 1e-9)
+
+	c = &report.Comparison{Options: opts}
+	c.CompareLatencies(baseline[:1], candidate[:1])
+	require.True(t, math.IsNaN(c.Deltas[0].PValue))
+	require.True(t, c.Deltas[0].Regression, "an untested change beyond the threshold is a regression")
+}
+
+func TestReadLatencies(t *testing.T) {
+	latencies, err := report.ReadLatencies(strings.NewReader(`run_id,chain_id,tx_hash,account,broadcast_time,height,commit_time,latency,committed
+a,chain,A,acc,2022-01-01T00:00:00Z,10,2022-01-01T00:00:01Z,1.5s,true
+a,chain,B,acc,2022-01-01T00:00:00Z,,,,false
+b,chain,C,acc,2022-01-02T00:00:00Z,20,2022-01-02T00:00:03Z,3s,true
+`), "a")
+	require.NoError(t, err)
+	require.Equal(t, []time.Duration{1500 * time.Millisecond}, latencies)
+}
+
+func TestResultsRun(t *testing.T) {
+	res := report.Results{
+		Blocks: []report.Block{{RunID: "a", Height: 1}, {RunID: "b", Height: 2}},
+		Codes:  []report.Code{{RunID: "b", Height: 2}},
+	}
+	run := res.Run("b")
+	require.Len(t, run.Blocks, 1)
+	require.Equal(t, int64(2), run.Blocks[0].Height)
+	require.Len(t, run.Codes, 1)
+}
@@ -0,0 +1,190 @@
+package report
+
+import (
+	"encoding/base64"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"text/template"
+	"time"
+)
+
+// Formats of the rendered reports.
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+var funcs = map[string]interface{}{
+	"percent": func(v float64) string {
+		return fmt.Sprintf("%.2f%%", v*100)
+	},
+	"float": func(v float64) string {
+		return fmt.Sprintf("%.2f", v)
+	},
+	"duration": func(d time.Duration) string {
+		return d.Round(time.Millisecond).String()
+	},
+	"code": func(code uint32) string {
+		return fmt.Sprintf("%#x", code)
+	},
+	"share": func(n, total int) string {
+		if total == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%.2f%%", float64(n)/float64(total)*100)
+	},
+}
+
+// Write renders the report in the given format.
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatMarkdown:
+		return r.WriteMarkdown(w)
+	case FormatHTML:
+		return r.WriteHTML(w)
+	default:
+		return fmt.Errorf("unknown report format %q; must be either %s or %s", format, FormatMarkdown, FormatHTML)
+	}
+}
+
+// WriteMarkdown renders the report as Markdown. The charts are embedded as SVG data URIs.
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	t := template.Must(template.New("report").Funcs(funcs).Funcs(map[string]interface{}{
+		"chart": func(title, svg string) string {
+			return fmt.Sprintf("![%s](data:image/svg+xml;base64,%s)", title, base64.StdEncoding.EncodeToString([]byte(svg)))
+		},
+		"txsChart":      txsChart,
+		"durationChart": durationChart,
+	}).Parse(markdownTemplate))
+	return t.Execute(w, r)
+}
+
+// WriteHTML renders the report as a single HTML page with inline SVG charts.
+func (r *Report) WriteHTML(w io.Writer) error {
+	t := htmltemplate.Must(htmltemplate.New("report").Funcs(funcs).Funcs(map[string]interface{}{
+		"txsChart": func(s *Scenario) htmltemplate.HTML {
+			return htmltemplate.HTML(txsChart(s)) // nolint: gosec
+		},
+		"durationChart": func(s *Scenario) htmltemplate.HTML {
+			return htmltemplate.HTML(durationChart(s)) // nolint: gosec
+		},
+	}).Parse(htmlTemplate))
+	return t.Execute(w, r)
+}
+
+const markdownTemplate = `# Stress test report
+
+| scenario | blocks | heights | broadcast | committed | planned | commit ratio | throughput (tx/s) | avg committed/block |
+|---|---:|---|---:|---:|---:|---:|---:|---:|
+{{- range .Scenarios}}
+| {{.Name}} | {{len .Blocks}} | {{.FirstHeight}}-{{.LastHeight}} | {{.Broadcast}} | {{.Committed}} | {{.Planned}} | {{percent .CommitRatio}} | {{float .Throughput}} | {{float .AvgCommittedPerBlock}} |
+{{- end}}
+{{if .GasPrices}}
+## Inclusion latency by gas price
+
+| gas price | txs | committed | p50 | p90 | p99 | max |
+|---|---:|---:|---:|---:|---:|---:|
+{{- range .GasPrices}}
+| {{.GasPrice}} | {{.Broadcast}} | {{.Committed}} | {{duration .P50}} | {{duration .P90}} | {{duration .P99}} | {{duration .Max}} |
+{{- end}}
+{{end}}
+{{- range .Scenarios}}
+## {{.Name}}
+
+### Block duration
+
+| measured blocks | min | avg | p50 | p90 | max |
+|---:|---:|---:|---:|---:|---:|
+{{with .BlockDurations}}| {{.Measured}} | {{duration .Min}} | {{duration .Avg}} | {{duration .P50}} | {{duration .P90}} | {{duration .Max}} |{{end}}
+{{if .Codes}}
+### Broadcast responses
+
+| code | count | share |
+|---|---:|---:|
+{{- $s := .}}
+{{- range .SortedCodes}}
+| {{code .}} | {{index $s.Codes .}} | {{share (index $s.Codes .) $s.Responses}} |
+{{- end}}
+{{end}}
+{{- if .Classes}}
+### Error classes
+
+| class | count | share |
+|---|---:|---:|
+{{- $s := .}}
+{{- range .SortedClasses}}
+| {{.}} | {{index $s.Classes .}} | {{share (index $s.Classes .) $s.ClassResponses}} |
+{{- end}}
+{{end}}
+### Charts
+
+{{chart "txs per block" (txsChart .)}}
+
+{{chart "block duration" (durationChart .)}}
+{{end -}}
+`
+
+const htmlTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Stress test report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+</style>
+</head>
+<body>
+<h1>Stress test report</h1>
+<table>
+<tr><th>scenario</th><th>blocks</th><th>heights</th><th>broadcast</th><th>committed</th><th>planned</th><th>commit ratio</th><th>throughput (tx/s)</th><th>avg committed/block</th></tr>
+{{- range .Scenarios}}
+<tr><td>{{.Name}}</td><td>{{len .Blocks}}</td><td>{{.FirstHeight}}-{{.LastHeight}}</td><td>{{.Broadcast}}</td><td>{{.Committed}}</td><td>{{.Planned}}</td><td>{{percent .CommitRatio}}</td><td>{{float .Throughput}}</td><td>{{float .AvgCommittedPerBlock}}</td></tr>
+{{- end}}
+</table>
+{{- if .GasPrices}}
+<h2>Inclusion latency by gas price</h2>
+<table>
+<tr><th>gas price</th><th>txs</th><th>committed</th><th>p50</th><th>p90</th><th>p99</th><th>max</th></tr>
+{{- range .GasPrices}}
+<tr><td>{{.GasPrice}}</td><td>{{.Broadcast}}</td><td>{{.Committed}}</td><td>{{duration .P50}}</td><td>{{duration .P90}}</td><td>{{duration .P99}}</td><td>{{duration .Max}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{range .Scenarios}}
+<h2>{{.Name}}</h2>
+<h3>Block duration</h3>
+<table>
+<tr><th>measured blocks</th><th>min</th><th>avg</th><th>p50</th><th>p90</th><th>max</th></tr>
+{{with .BlockDurations}}<tr><td>{{.Measured}}</td><td>{{duration .Min}}</td><td>{{duration .Avg}}</td><td>{{duration .P50}}</td><td>{{duration .P90}}</td><td>{{duration .Max}}</td></tr>{{end}}
+</table>
+{{- if .Codes}}
+<h3>Broadcast responses</h3>
+<table>
+<tr><th>code</th><th>count</th><th>share</th></tr>
+{{- $s := .}}
+{{- range .SortedCodes}}
+<tr><td>{{code .}}</td><td>{{index $s.Codes .}}</td><td>{{share (index $s.Codes .) $s.Responses}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Classes}}
+<h3>Error classes</h3>
+<table>
+<tr><th>class</th><th>count</th><th>share</th></tr>
+{{- $s := .}}
+{{- range .SortedClasses}}
+<tr><td>{{.}}</td><td>{{index $s.Classes .}}</td><td>{{share (index $s.Classes .) $s.ClassResponses}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+<h3>Charts</h3>
+<div>{{txsChart .}}</div>
+<div>{{durationChart .}}</div>
+{{end}}
+</body>
+</html>
+`
@@ -0,0 +1,167 @@
+package report
+
+import (
+	"sort"
+	"time"
+
+	"github.com/b-harvest/modules-test-tool/errclass"
+	"github.com/b-harvest/modules-test-tool/load"
+)
+
+// DurationStats are the stats of the measured block durations.
+type DurationStats struct {
+	Measured int
+	Min      time.Duration
+	Avg      time.Duration
+	P50      time.Duration
+	P90      time.Duration
+	Max      time.Duration
+}
+
+// Scenario sums up the blocks of a scenario.
+type Scenario struct {
+	Name           string
+	Blocks         []Block // in the order of their heights
+	FirstHeight    int64
+	LastHeight     int64
+	Broadcast      int
+	Committed      int
+	Planned        int
+	BlockDurations DurationStats
+	Codes          map[uint32]int // number of broadcast responses by code
+	Classes        map[string]int // number of broadcast responses by error class
+
+	measuredCommitted int           // committed txs of the blocks of a known duration
+	measuredDuration  time.Duration // total duration of the blocks of a known duration
+}
+
+// CommitRatio returns the ratio of the committed txs to the broadcast txs.
+func (s *Scenario) CommitRatio() float64 {
+	if s.Broadcast == 0 {
+		return 0
+	}
+	return float64(s.Committed) / float64(s.Broadcast)
+}
+
+// Throughput returns the committed txs per second over the blocks of a known duration.
+func (s *Scenario) Throughput() float64 {
+	if s.measuredDuration <= 0 {
+		return 0
+	}
+	return float64(s.measuredCommitted) / s.measuredDuration.Seconds()
+}
+
+// AvgCommittedPerBlock returns the average number of committed txs per block.
+func (s *Scenario) AvgCommittedPerBlock() float64 {
+	if len(s.Blocks) == 0 {
+		return 0
+	}
+	return float64(s.Committed) / float64(len(s.Blocks))
+}
+
+// Responses returns the total number of broadcast responses of every code.
+func (s *Scenario) Responses() int {
+	total := 0
+	for _, n := range s.Codes {
+		total += n
+	}
+	return total
+}
+
+// SortedCodes returns the codes in order.
+func (s *Scenario) SortedCodes() []uint32 {
+	codes := make([]uint32, 0, len(s.Codes))
+	for code := range s.Codes {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
+
+// ClassResponses returns the total number of broadcast responses of every error class.
+func (s *Scenario) ClassResponses() int {
+	total := 0
+	for _, n := range s.Classes {
+		total += n
+	}
+	return total
+}
+
+// SortedClasses returns the error classes in the order they are reported.
+func (s *Scenario) SortedClasses() []string {
+	return errclass.SortedClasses(s.Classes)
+}
+
+// Report is the summary of the results per scenario, in the order the scenarios were run,
+// along with the inclusion latencies of the txs by gas price.
+type Report struct {
+	Scenarios []*Scenario
+	GasPrices []load.GasPriceLatency
+}
+
+// Build builds the report of the results.
+func Build(res Results) *Report {
+	byName := make(map[string]*Scenario)
+	var scenarios []*Scenario
+	get := func(name string) *Scenario {
+		s, ok := byName[name]
+		if !ok {
+			s = &Scenario{Name: name, Codes: make(map[uint32]int), Classes: make(map[string]int)}
+			byName[name] = s
+			scenarios = append(scenarios, s)
+		}
+		return s
+	}
+
+	for _, b := range res.Blocks {
+		s := get(b.Scenario)
+		s.Blocks = append(s.Blocks, b)
+	}
+	for _, c := range res.Codes {
+		get(c.Scenario).Codes[c.Code] += c.Count
+	}
+	for _, c := range res.Classes {
+		get(c.Scenario).Classes[c.Class] += c.Count
+	}
+
+	for _, s := range scenarios {
+		s.summarize()
+	}
+	sort.SliceStable(scenarios, func(i, j int) bool {
+		return scenarios[i].FirstHeight < scenarios[j].FirstHeight
+	})
+	return &Report{Scenarios: scenarios, GasPrices: load.LatenciesByGasPrice(res.Inclusions)}
+}
+
+func (s *Scenario) summarize() {
+	sort.SliceStable(s.Blocks, func(i, j int) bool { return s.Blocks[i].Height < s.Blocks[j].Height })
+
+	var durations []time.Duration
+	for i, b := range s.Blocks {
+		if i == 0 {
+			s.FirstHeight = b.Height
+		}
+		s.LastHeight = b.Height
+		s.Broadcast += b.Broadcast
+		s.Committed += b.Committed
+		s.Planned += b.Planned
+		if b.BlockDuration > 0 {
+			durations = append(durations, b.BlockDuration)
+			s.measuredCommitted += b.Committed
+			s.measuredDuration += b.BlockDuration
+		}
+	}
+
+	if len(durations) == 0 {
+		return
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	s.BlockDurations = DurationStats{
+		Measured: len(durations),
+		Min:      durations[0],
+		Avg:      s.measuredDuration / time.Duration(len(durations)),
+		P50:      load.Percentile(durations, 50),
+		P90:      load.Percentile(durations, 90),
+		Max:      durations[len(durations)-1],
+	}
+}
@@ -0,0 +1,155 @@
+package report_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/report"
+)
+
+const resultCSV = `run_id,command,chain_id,scenario,msg_type,height,block_time,block_duration,num_broadcast_txs,num_failed_txs,num_committed_txs,planned_num_broadcast_txs
+run-1,stress-test,localnet,warmup,,10,2022-01-01T00:00:00Z,0s,100,0,100,100
+run-1,stress-test,localnet,warmup,swap,10,2022-01-01T00:00:00Z,0s,100,0,100,0
+run-1,stress-test,localnet,warmup,,11,2022-01-01T00:00:01Z,1s,100,5,90,100
+run-1,stress-test,localnet,steady,,12,2022-01-01T00:00:04Z,3s,200,0,200,200
+run-1,stress-test,localnet,steady,,13,2022-01-01T00:00:06Z,2s,200,0,100,200
+`
+
+const codesCSV = `run_id,chain_id,scenario,msg_type,height,code,count
+run-1,localnet,warmup,,10,0,100
+run-1,localnet,warmup,,11,0,95
+run-1,localnet,warmup,,11,20,5
+`
+
+const classesCSV = `run_id,chain_id,scenario,msg_type,height,class,count
+run-1,localnet,warmup,,10,ok,100
+run-1,localnet,warmup,,11,ok,95
+run-1,localnet,warmup,,11,mempool_full,5
+`
+
+// legacyCSV is a result.csv written before the scenario column was added.
+const legacyCSV = `height,block_time,block_duration,num_broadcast_txs,num_committed_txs,planned_num_broadcast_txs
+20,2022-01-01T00:00:00Z,1s,10,10,10
+`
+
+func readResults(t *testing.T) report.Results {
+	var res report.Results
+	require.NoError(t, res.Read(strings.NewReader(resultCSV), "result.csv"))
+	require.NoError(t, res.Read(strings.NewReader(codesCSV), "result_codes.csv"))
+	require.NoError(t, res.Read(strings.NewReader(classesCSV), "result_classes.csv"))
+	require.NoError(t, res.Read(strings.NewReader(legacyCSV), "old/result.csv"))
+	return res
+}
+
+func TestRead(t *testing.T) {
+	res := readResults(t)
+	require.Len(t, res.Blocks, 5)
+	require.Len(t, res.Codes, 3)
+	require.Len(t, res.Classes, 3)
+	require.Equal(t, report.Block{
+		RunID:         "run-1",
+		Scenario:      "warmup",
+		Height:        11,
+		BlockTime:     time.Date(2022, 1, 1, 0, 0, 1, 0, time.UTC),
+		BlockDuration: time.Second,
+		Broadcast:     100,
+		Committed:     90,
+		Planned:       100,
+	}, res.Blocks[1])
+	require.Equal(t, "old/result.csv", res.Blocks[4].Scenario)
+
+	err := res.Read(strings.NewReader("height,num_broadcast_txs,num_committed_txs\nx,1,1\n"), "bad.csv")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "row 2")
+	require.Error(t, res.Read(strings.NewReader("foo,bar\n1,2\n"), "bad.csv"))
+}
+
+func TestBuild(t *testing.T) {
+	r := report.Build(readResults(t))
+	require.Len(t, r.Scenarios, 3)
+
+	warmup := r.Scenarios[0]
+	require.Equal(t, "warmup", warmup.Name)
+	require.Equal(t, int64(10), warmup.FirstHeight)
+	require.Equal(t, int64(11), warmup.LastHeight)
+	require.InDelta(t, 0.95, warmup.CommitRatio(), 1e-9)
+	require.InDelta(t, 90, warmup.Throughput(), 1e-9)
+	require.Equal(t, map[uint32]int{0: 195, 20: 5}, warmup.Codes)
+	require.Equal(t, []uint32{0, 20}, warmup.SortedCodes())
+	require.Equal(t, []string{"ok", "mempool_full"}, warmup.SortedClasses())
+	require.Equal(t, 200, warmup.ClassResponses())
+	require.Equal(t, 1, warmup.BlockDurations.Measured)
+
+	steady := r.Scenarios[1]
+	require.Equal(t, "steady", steady.Name)
+	require.InDelta(t, 60, steady.Throughput(), 1e-9)
+	require.InDelta(t, 150, steady.AvgCommittedPerBlock(), 1e-9)
+	require.Equal(t, report.DurationStats{
+		Measured: 2,
+		Min:      2 * time.Second,
+		Avg:      2500 * time.Millisecond,
+		P50:      2 * time.Second,
+		P90:      3 * time.Second,
+		Max:      3 * time.Second,
+	}, steady.BlockDurations)
+	require.Empty(t, steady.Codes)
+
+	require.Equal(t, "old/result.csv", r.Scenarios[2].Name)
+}
+
+func TestWrite(t *testing.T) {
+	r := report.Build(readResults(t))
+
+	var md bytes.Buffer
+	require.NoError(t, r.Write(&md, report.FormatMarkdown))
+	require.Contains(t, md.String(), "| warmup | 2 | 10-11 | 200 | 190 | 200 | 95.00% | 90.00 | 95.00 |")
+	require.Contains(t, md.String(), "| 0x14 | 5 | 2.50% |")
+	require.Contains(t, md.String(), "| mempool_full | 5 | 2.50% |")
+	require.Contains(t, md.String(), "](data:image/svg+xml;base64,")
+
+	var html bytes.Buffer
+	require.NoError(t, r.Write(&html, report.FormatHTML))
+	require.Contains(t, html.String(), "<td>steady</td>")
+	require.Contains(t, html.String(), "<td>mempool_full</td><td>5</td><td>2.50%</td>")
+	require.Contains(t, html.String(), "<svg ")
+	require.Equal(t, 6, strings.Count(html.String(), "<svg "))
+
+	require.Error(t, r.Write(&html, "pdf"))
+}
+
+const inclusionCSV = `tx_hash,account,broadcast_time,height,commit_time,latency,committed,gas_price
+A,addr,2022-01-01T00:00:00Z,10,2022-01-01T00:00:02Z,2s,true,0.01uatom
+B,addr,2022-01-01T00:00:00Z,11,2022-01-01T00:00:01Z,1s,true,0.1uatom
+C,addr,2022-01-01T00:00:00Z,,,,false,0.01uatom
+`
+
+func TestGasPrices(t *testing.T) {
+	res := readResults(t)
+	require.NoError(t, res.Read(strings.NewReader(inclusionCSV), "inclusion.csv"))
+	require.Len(t, res.Inclusions, 3)
+	require.Equal(t, int64(10), res.Inclusions[0].Height)
+	require.False(t, res.Inclusions[2].Committed())
+
+	r := report.Build(res)
+	require.Len(t, r.GasPrices, 2)
+	require.Equal(t, "0.01uatom", r.GasPrices[0].GasPrice)
+	require.Equal(t, 2, r.GasPrices[0].Broadcast)
+	require.Equal(t, 1, r.GasPrices[0].Committed)
+
+	var md bytes.Buffer
+	require.NoError(t, r.Write(&md, report.FormatMarkdown))
+	require.Contains(t, md.String(), "| 0.01uatom | 2 | 1 | 2s | 2s | 2s | 2s |")
+	require.Contains(t, md.String(), "| 0.1uatom | 1 | 1 | 1s | 1s | 1s | 1s |")
+
+	var html bytes.Buffer
+	require.NoError(t, r.Write(&html, report.FormatHTML))
+	require.Contains(t, html.String(), "<td>0.1uatom</td><td>1</td><td>1</td>")
+
+	md.Reset()
+	require.NoError(t, report.Build(readResults(t)).Write(&md, report.FormatMarkdown))
+	require.NotContains(t, md.String(), "gas price")
+}
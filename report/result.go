@@ -0,0 +1,313 @@
+// Package report reads the result files of the workloads and renders them as self-contained
+// Markdown or HTML reports.
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/b-harvest/modules-test-tool/load"
+)
+
+// Block is a row of result.csv.
+type Block struct {
+	RunID         string
+	Scenario      string
+	Height        int64
+	BlockTime     time.Time
+	BlockDuration time.Duration // zero if unknown
+	Broadcast     int
+	Committed     int
+	Planned       int
+}
+
+// Code is a row of result_codes.csv, the number of broadcast responses of a code in a block.
+type Code struct {
+	RunID    string
+	Scenario string
+	Height   int64
+	Code     uint32
+	Count    int
+}
+
+// ClassCount is a row of result_classes.csv, the number of broadcast responses of an error class in a block.
+type ClassCount struct {
+	RunID    string
+	Scenario string
+	Height   int64
+	Class    string
+	Count    int
+}
+
+// Results are the blocks, the codes and the error classes read from one or more result files,
+// and the inclusions of the txs read from inclusion.csv.
+type Results struct {
+	Blocks     []Block
+	Codes      []Code
+	Classes    []ClassCount
+	Inclusions []load.Inclusion
+}
+
+// Read reads a result file, either result.csv, result_codes.csv, result_classes.csv or inclusion.csv,
+// which is told apart by its header.
+// The rows without a scenario are given the name of the source. The rows breaking the blocks down
+// by message type are skipped.
+func (res *Results) Read(r io.Reader, source string) error {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read header: %w", err)
+	}
+	cols := make(map[string]int)
+	for i, name := range header {
+		cols[name] = i
+	}
+
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return fmt.Errorf("read rows: %w", err)
+	}
+	if _, ok := cols["code"]; ok {
+		return res.readCodes(rows, cols, source)
+	}
+	if _, ok := cols["class"]; ok {
+		return res.readClasses(rows, cols, source)
+	}
+	if _, ok := cols["latency"]; ok {
+		return res.readInclusions(rows, cols)
+	}
+	return res.readBlocks(rows, cols, source)
+}
+
+func (res *Results) readBlocks(rows [][]string, cols map[string]int, source string) error {
+	for _, name := range []string{"height", "num_broadcast_txs", "num_committed_txs"} {
+		if _, ok := cols[name]; !ok {
+			return fmt.Errorf("missing column %s", name)
+		}
+	}
+	for i, row := range rows {
+		p := parser{row: row, cols: cols}
+		if p.value("msg_type") != "" {
+			continue
+		}
+		b := Block{
+			RunID:         p.value("run_id"),
+			Scenario:      p.str("scenario", source),
+			Height:        p.int64("height"),
+			BlockTime:     p.time("block_time"),
+			BlockDuration: p.duration("block_duration"),
+			Broadcast:     int(p.int64("num_broadcast_txs")),
+			Committed:     int(p.int64("num_committed_txs")),
+			Planned:       int(p.int64("planned_num_broadcast_txs")),
+		}
+		if p.err != nil {
+			return fmt.Errorf("row %d: %w", i+2, p.err)
+		}
+		res.Blocks = append(res.Blocks, b)
+	}
+	return nil
+}
+
+func (res *Results) readCodes(rows [][]string, cols map[string]int, source string) error {
+	for _, name := range []string{"height", "code", "count"} {
+		if _, ok := cols[name]; !ok {
+			return fmt.Errorf("missing column %s", name)
+		}
+	}
+	for i, row := range rows {
+		p := parser{row: row, cols: cols}
+		if p.value("msg_type") != "" {
+			continue
+		}
+		c := Code{
+			RunID:    p.value("run_id"),
+			Scenario: p.str("scenario", source),
+			Height:   p.int64("height"),
+			Code:     uint32(p.int64("code")),
+			Count:    int(p.int64("count")),
+		}
+		if p.err != nil {
+			return fmt.Errorf("row %d: %w", i+2, p.err)
+		}
+		res.Codes = append(res.Codes, c)
+	}
+	return nil
+}
+
+func (res *Results) readClasses(rows [][]string, cols map[string]int, source string) error {
+	for _, name := range []string{"height", "count"} {
+		if _, ok := cols[name]; !ok {
+			return fmt.Errorf("missing column %s", name)
+		}
+	}
+	for i, row := range rows {
+		p := parser{row: row, cols: cols}
+		if p.value("msg_type") != "" {
+			continue
+		}
+		c := ClassCount{
+			RunID:    p.value("run_id"),
+			Scenario: p.str("scenario", source),
+			Height:   p.int64("height"),
+			Class:    p.value("class"),
+			Count:    int(p.int64("count")),
+		}
+		if p.err != nil {
+			return fmt.Errorf("row %d: %w", i+2, p.err)
+		}
+		res.Classes = append(res.Classes, c)
+	}
+	return nil
+}
+
+func (res *Results) readInclusions(rows [][]string, cols map[string]int) error {
+	for _, name := range []string{"tx_hash", "broadcast_time", "committed"} {
+		if _, ok := cols[name]; !ok {
+			return fmt.Errorf("missing column %s", name)
+		}
+	}
+	for i, row := range rows {
+		p := parser{row: row, cols: cols}
+		in := load.Inclusion{
+			Hash:        p.value("tx_hash"),
+			Account:     p.value("account"),
+			GasPrice:    p.value("gas_price"),
+			BroadcastAt: p.time("broadcast_time"),
+		}
+		if p.value("committed") == "true" {
+			in.Height = p.int64("height")
+			in.CommittedAt = p.time("commit_time")
+		}
+		if p.err != nil {
+			return fmt.Errorf("row %d: %w", i+2, p.err)
+		}
+		res.Inclusions = append(res.Inclusions, in)
+	}
+	return nil
+}
+
+// Run returns the blocks, the codes and the error classes of the run of the given id.
+func (res Results) Run(id string) Results {
+	var run Results
+	for _, b := range res.Blocks {
+		if b.RunID == id {
+			run.Blocks = append(run.Blocks, b)
+		}
+	}
+	for _, c := range res.Codes {
+		if c.RunID == id {
+			run.Codes = append(run.Codes, c)
+		}
+	}
+	for _, c := range res.Classes {
+		if c.RunID == id {
+			run.Classes = append(run.Classes, c)
+		}
+	}
+	return run
+}
+
+// ReadLatencies reads the latencies of the committed txs of the run of the given id from inclusion.csv,
+// which has the txs of every run appended to it.
+func ReadLatencies(r io.Reader, runID string) ([]time.Duration, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	cols := make(map[string]int)
+	for i, name := range header {
+		cols[name] = i
+	}
+	for _, name := range []string{"run_id", "latency", "committed"} {
+		if _, ok := cols[name]; !ok {
+			return nil, fmt.Errorf("missing column %s", name)
+		}
+	}
+
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("read rows: %w", err)
+	}
+	var latencies []time.Duration
+	for i, row := range rows {
+		p := parser{row: row, cols: cols}
+		if p.value("run_id") != runID || p.value("committed") != "true" {
+			continue
+		}
+		latency := p.duration("latency")
+		if p.err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+2, p.err)
+		}
+		latencies = append(latencies, latency)
+	}
+	return latencies, nil
+}
+
+// parser parses the columns of a row by name and keeps the first error.
+// Missing and empty columns are parsed as zero values.
+type parser struct {
+	row  []string
+	cols map[string]int
+	err  error
+}
+
+func (p *parser) value(name string) string {
+	i, ok := p.cols[name]
+	if !ok || i >= len(p.row) {
+		return ""
+	}
+	return p.row[i]
+}
+
+func (p *parser) str(name, def string) string {
+	if v := p.value(name); v != "" {
+		return v
+	}
+	return def
+}
+
+func (p *parser) int64(name string) int64 {
+	v := p.value(name)
+	if v == "" || p.err != nil {
+		return 0
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		p.err = fmt.Errorf("parse %s: %w", name, err)
+	}
+	return n
+}
+
+func (p *parser) time(name string) time.Time {
+	v := p.value(name)
+	if v == "" || p.err != nil {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		p.err = fmt.Errorf("parse %s: %w", name, err)
+	}
+	return t
+}
+
+func (p *parser) duration(name string) time.Duration {
+	v := p.value(name)
+	if v == "" || p.err != nil {
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		p.err = fmt.Errorf("parse %s: %w", name, err)
+	}
+	return d
+}
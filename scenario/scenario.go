@@ -0,0 +1,368 @@
+package scenario
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/pelletier/go-toml"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/b-harvest/modules-test-tool/load"
+)
+
+var (
+	DefaultScenarioPath = "./scenario.toml"
+)
+
+// Supported message types of a scenario.
+const (
+	MsgTypeSwap     = "swap"
+	MsgTypeDeposit  = "deposit"
+	MsgTypeWithdraw = "withdraw"
+	MsgTypeTransfer = "transfer"
+)
+
+var (
+	DefaultTransferPort = "transfer"
+)
+
+// Supported load profile types of a scenario.
+const (
+	ProfileFlat  = "flat"
+	ProfileRamp  = "ramp"
+	ProfileStep  = "step"
+	ProfileSpike = "spike"
+	ProfileSine  = "sine"
+)
+
+// File is a versioned test plan which consists of a list of scenarios run in order.
+type File struct {
+	Version   int        `toml:"version"`
+	Scenarios []Scenario `toml:"scenarios"`
+}
+
+// Scenario describes a single phase of the stress test.
+type Scenario struct {
+	Name           string        `toml:"name"`
+	Rounds         int           `toml:"rounds"`
+	NumTxsPerBlock int           `toml:"txs_per_block"`
+	MsgType        string        `toml:"msg_type"`
+	PoolID         uint64        `toml:"pool_id"`
+	OfferCoin      string        `toml:"offer_coin"`
+	CoolDown       time.Duration `toml:"cool_down"`
+	// Accounts holds indexes of the accounts in the config to be used, of its key names or mnemonics.
+	// All accounts are used when it is empty.
+	Accounts []int         `toml:"accounts"`
+	Profile  ProfileConfig `toml:"profile"`
+	// Mix holds the weights per message type of a mixed workload.
+	// Only MsgType is sent when it is empty.
+	Mix          map[string]int `toml:"mix"`
+	DepositCoins string         `toml:"deposit_coins"`
+	PoolCoin     string         `toml:"pool_coin"`
+	Transfer     TransferConfig `toml:"transfer"`
+}
+
+// TransferConfig contains the parameters of the IBC transfer messages of a scenario.
+type TransferConfig struct {
+	Port     string `toml:"port"`
+	Channel  string `toml:"channel"`
+	Receiver string `toml:"receiver"`
+	Amount   string `toml:"amount"`
+}
+
+// ProfileConfig shapes the number of transactions per block over the rounds of a scenario.
+// The txs per block of the scenario is the base of the step, spike and sine profiles.
+type ProfileConfig struct {
+	Type      string `toml:"type"`
+	From      int    `toml:"from"`
+	To        int    `toml:"to"`
+	Increment int    `toml:"increment"`
+	Every     int    `toml:"every"`
+	Peak      int    `toml:"peak"`
+	At        int    `toml:"at"`
+	Length    int    `toml:"length"`
+	Amplitude int    `toml:"amplitude"`
+	Period    int    `toml:"period"`
+}
+
+// ParseProfile parses a profile given as its type followed by its parameters, e.g. "ramp:from=10,to=50"
+// or "spike:peak=100,at=30,length=10". The parameters are named as in a scenario file.
+func ParseProfile(spec string) (ProfileConfig, error) {
+	typ, params := spec, ""
+	if i := strings.Index(spec, ":"); i >= 0 {
+		typ, params = spec[:i], spec[i+1:]
+	}
+	p := ProfileConfig{Type: typ}
+	if params == "" {
+		return p, nil
+	}
+	for _, param := range strings.Split(params, ",") {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) != 2 {
+			return ProfileConfig{}, fmt.Errorf("invalid profile parameter: %s", param)
+		}
+		v, err := strconv.Atoi(kv[1])
+		if err != nil {
+			return ProfileConfig{}, fmt.Errorf("invalid profile parameter %s: %w", kv[0], err)
+		}
+		switch kv[0] {
+		case "from":
+			p.From = v
+		case "to":
+			p.To = v
+		case "increment":
+			p.Increment = v
+		case "every":
+			p.Every = v
+		case "peak":
+			p.Peak = v
+		case "at":
+			p.At = v
+		case "length":
+			p.Length = v
+		case "amplitude":
+			p.Amplitude = v
+		case "period":
+			p.Period = v
+		default:
+			return ProfileConfig{}, fmt.Errorf("unknown profile parameter: %s", kv[0])
+		}
+	}
+	return p, nil
+}
+
+// Read reads and parses the scenario file of the given path.
+func Read(scenarioPath string) (*File, error) {
+	if scenarioPath == "" {
+		return nil, fmt.Errorf("empty scenario path")
+	}
+
+	log.Debug().Msg("reading scenario file")
+
+	scenarioData, err := ioutil.ReadFile(scenarioPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario: %s", err)
+	}
+
+	return ParseString(scenarioData)
+}
+
+// ParseString attempts to parse scenarios from the given string bytes.
+func ParseString(scenarioData []byte) (*File, error) {
+	var f File
+
+	err := toml.Unmarshal(scenarioData, &f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode scenario: %s", err)
+	}
+
+	for i := range f.Scenarios {
+		if f.Scenarios[i].MsgType == "" {
+			f.Scenarios[i].MsgType = MsgTypeSwap
+		}
+		if f.Scenarios[i].Profile.Type == "" {
+			f.Scenarios[i].Profile.Type = ProfileFlat
+		}
+		if f.Scenarios[i].Transfer.Port == "" {
+			f.Scenarios[i].Transfer.Port = DefaultTransferPort
+		}
+	}
+
+	return &f, nil
+}
+
+// Validate validates all scenarios of the file against the number of the configured accounts.
+func (f *File) Validate(numAccounts int) error {
+	if len(f.Scenarios) == 0 {
+		return fmt.Errorf("no scenarios")
+	}
+	for i, s := range f.Scenarios {
+		if err := s.Validate(numAccounts); err != nil {
+			return fmt.Errorf("scenario #%d(%s): %w", i+1, s.Name, err)
+		}
+	}
+	return nil
+}
+
+// Validate validates the scenario against the number of the configured accounts.
+func (s Scenario) Validate(numAccounts int) error {
+	if s.Rounds <= 0 {
+		return fmt.Errorf("rounds must be positive: %d", s.Rounds)
+	}
+	if _, err := s.LoadProfile(); err != nil {
+		return err
+	}
+	if s.CoolDown < 0 {
+		return fmt.Errorf("cool down must not be negative: %s", s.CoolDown)
+	}
+
+	mix, err := s.MsgMix()
+	if err != nil {
+		return err
+	}
+	for _, msgType := range mix.Types() {
+		if err := s.validateMsgType(msgType); err != nil {
+			return fmt.Errorf("%s: %w", msgType, err)
+		}
+	}
+
+	if numAccounts == 0 {
+		return fmt.Errorf("no accounts available")
+	}
+	for _, idx := range s.Accounts {
+		if idx < 0 || idx >= numAccounts {
+			return fmt.Errorf("account index %d out of range [0, %d)", idx, numAccounts)
+		}
+	}
+
+	return nil
+}
+
+// validateMsgType validates the parameters of the given message type.
+func (s Scenario) validateMsgType(msgType string) error {
+	switch msgType {
+	case MsgTypeSwap:
+		if s.PoolID == 0 {
+			return fmt.Errorf("pool id must be specified")
+		}
+		if _, err := s.ParseOfferCoin(); err != nil {
+			return err
+		}
+	case MsgTypeDeposit:
+		if s.PoolID == 0 {
+			return fmt.Errorf("pool id must be specified")
+		}
+		if _, err := s.ParseDepositCoins(); err != nil {
+			return err
+		}
+	case MsgTypeWithdraw:
+		if s.PoolID == 0 {
+			return fmt.Errorf("pool id must be specified")
+		}
+		if _, err := s.ParsePoolCoin(); err != nil {
+			return err
+		}
+	case MsgTypeTransfer:
+		if s.Transfer.Channel == "" || s.Transfer.Receiver == "" {
+			return fmt.Errorf("transfer channel and receiver must be specified")
+		}
+		if _, err := s.ParseTransferAmount(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported msg type")
+	}
+	return nil
+}
+
+// MsgMix returns the mix of message types to be sent. It consists of MsgType alone
+// when no weights are given.
+func (s Scenario) MsgMix() (*load.Mix, error) {
+	if len(s.Mix) == 0 {
+		return load.NewMix(map[string]int{s.MsgType: 1})
+	}
+	return load.NewMix(s.Mix)
+}
+
+// ParseOfferCoin parses and validates the offer coin of the scenario.
+func (s Scenario) ParseOfferCoin() (sdktypes.Coin, error) {
+	offerCoin, err := sdktypes.ParseCoinNormalized(s.OfferCoin)
+	if err != nil {
+		return sdktypes.Coin{}, fmt.Errorf("invalid offer coin: %w", err)
+	}
+	if err := offerCoin.Validate(); err != nil {
+		return sdktypes.Coin{}, fmt.Errorf("invalid offer coin: %w", err)
+	}
+	return offerCoin, nil
+}
+
+// ParseDepositCoins parses and validates the deposit coins of the scenario.
+func (s Scenario) ParseDepositCoins() (sdktypes.Coins, error) {
+	depositCoins, err := sdktypes.ParseCoinsNormalized(s.DepositCoins)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deposit coins: %w", err)
+	}
+	if err := depositCoins.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid deposit coins: %w", err)
+	}
+	if depositCoins.Len() != 2 {
+		return nil, fmt.Errorf("the number of deposit coins must be two in the pool-type 1")
+	}
+	return depositCoins, nil
+}
+
+// ParsePoolCoin parses and validates the pool coin to withdraw of the scenario.
+func (s Scenario) ParsePoolCoin() (sdktypes.Coin, error) {
+	poolCoin, err := sdktypes.ParseCoinNormalized(s.PoolCoin)
+	if err != nil {
+		return sdktypes.Coin{}, fmt.Errorf("invalid pool coin: %w", err)
+	}
+	if err := poolCoin.Validate(); err != nil {
+		return sdktypes.Coin{}, fmt.Errorf("invalid pool coin: %w", err)
+	}
+	return poolCoin, nil
+}
+
+// ParseTransferAmount parses and validates the amount of the IBC transfer of the scenario.
+func (s Scenario) ParseTransferAmount() (sdktypes.Coin, error) {
+	amount, err := sdktypes.ParseCoinNormalized(s.Transfer.Amount)
+	if err != nil {
+		return sdktypes.Coin{}, fmt.Errorf("invalid transfer amount: %w", err)
+	}
+	if err := amount.Validate(); err != nil {
+		return sdktypes.Coin{}, fmt.Errorf("invalid transfer amount: %w", err)
+	}
+	return amount, nil
+}
+
+// SelectAccounts returns the indexes of the scenario's account set out of the given number of accounts.
+func (s Scenario) SelectAccounts(numAccounts int) []int {
+	if len(s.Accounts) > 0 {
+		return s.Accounts
+	}
+	indexes := make([]int, numAccounts)
+	for i := range indexes {
+		indexes[i] = i
+	}
+	return indexes
+}
+
+// LoadProfile returns the load profile which plans the number of transactions per round.
+func (s Scenario) LoadProfile() (load.Profile, error) {
+	p := s.Profile
+	if p.Type != ProfileRamp && s.NumTxsPerBlock <= 0 {
+		return nil, fmt.Errorf("txs per block must be positive: %d", s.NumTxsPerBlock)
+	}
+
+	switch p.Type {
+	case ProfileFlat, "":
+		return load.Flat{N: s.NumTxsPerBlock}, nil
+	case ProfileRamp:
+		if p.From < 0 || p.To < 0 || (p.From == 0 && p.To == 0) {
+			return nil, fmt.Errorf("ramp profile must have non-negative from and to: %d, %d", p.From, p.To)
+		}
+		return load.Ramp{From: p.From, To: p.To, Steps: s.Rounds}, nil
+	case ProfileStep:
+		if p.Every <= 0 {
+			return nil, fmt.Errorf("step profile must have positive every: %d", p.Every)
+		}
+		return load.Step{Base: s.NumTxsPerBlock, Increment: p.Increment, Every: p.Every}, nil
+	case ProfileSpike:
+		if p.Peak <= 0 || p.At < 0 || p.Length <= 0 {
+			return nil, fmt.Errorf("spike profile must have positive peak and length, and non-negative at: %d, %d, %d", p.Peak, p.Length, p.At)
+		}
+		return load.Spike{Base: s.NumTxsPerBlock, Peak: p.Peak, At: p.At, Length: p.Length}, nil
+	case ProfileSine:
+		if p.Period <= 0 || p.Amplitude < 0 {
+			return nil, fmt.Errorf("sine profile must have positive period and non-negative amplitude: %d, %d", p.Period, p.Amplitude)
+		}
+		return load.Sine{Base: s.NumTxsPerBlock, Amplitude: p.Amplitude, Period: p.Period}, nil
+	default:
+		return nil, fmt.Errorf("unsupported profile type: %s", p.Type)
+	}
+}
@@ -0,0 +1,208 @@
+package scenario_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/load"
+	"github.com/b-harvest/modules-test-tool/scenario"
+)
+
+func TestReadScenarioFile(t *testing.T) {
+	f, err := scenario.Read("../scenario.toml")
+	require.NoError(t, err)
+
+	require.Equal(t, 1, f.Version)
+	require.Len(t, f.Scenarios, 5)
+	require.Equal(t, 100, f.Scenarios[0].Rounds)
+	require.Equal(t, 100, f.Scenarios[0].NumTxsPerBlock)
+	require.Equal(t, time.Minute, f.Scenarios[0].CoolDown)
+	require.NoError(t, f.Validate(1))
+}
+
+func TestParseScenarioString(t *testing.T) {
+	var sampleScenario = `
+version = 2
+
+[[scenarios]]
+name = "warm-up"
+rounds = 5
+txs_per_block = 10
+pool_id = 1
+offer_coin = "1000000uatom"
+cool_down = "30s"
+accounts = [0, 2]
+`
+	f, err := scenario.ParseString([]byte(sampleScenario))
+	require.NoError(t, err)
+
+	require.Equal(t, 2, f.Version)
+	require.Len(t, f.Scenarios, 1)
+
+	s := f.Scenarios[0]
+	require.Equal(t, "warm-up", s.Name)
+	require.Equal(t, scenario.MsgTypeSwap, s.MsgType)
+	require.Equal(t, uint64(1), s.PoolID)
+	require.Equal(t, 30*time.Second, s.CoolDown)
+	require.Equal(t, []int{0, 2}, s.SelectAccounts(3))
+	require.NoError(t, f.Validate(3))
+	require.Error(t, f.Validate(2))
+}
+
+func TestValidateScenario(t *testing.T) {
+	valid := scenario.Scenario{
+		Rounds:         1,
+		NumTxsPerBlock: 1,
+		MsgType:        scenario.MsgTypeSwap,
+		PoolID:         1,
+		OfferCoin:      "1000000uatom",
+	}
+	require.NoError(t, valid.Validate(1))
+
+	for _, tc := range []struct {
+		name     string
+		malleate func(s *scenario.Scenario)
+	}{
+		{"zero rounds", func(s *scenario.Scenario) { s.Rounds = 0 }},
+		{"zero txs per block", func(s *scenario.Scenario) { s.NumTxsPerBlock = 0 }},
+		{"negative cool down", func(s *scenario.Scenario) { s.CoolDown = -time.Second }},
+		{"unknown msg type", func(s *scenario.Scenario) { s.MsgType = "unknown" }},
+		{"missing pool id", func(s *scenario.Scenario) { s.PoolID = 0 }},
+		{"invalid offer coin", func(s *scenario.Scenario) { s.OfferCoin = "uatom" }},
+		{"account out of range", func(s *scenario.Scenario) { s.Accounts = []int{1} }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := valid
+			tc.malleate(&s)
+			require.Error(t, s.Validate(1))
+		})
+	}
+
+	require.Error(t, (&scenario.File{}).Validate(1))
+}
+
+func TestScenarioLoadProfile(t *testing.T) {
+	var sampleScenario = `
+[[scenarios]]
+rounds = 5
+pool_id = 1
+offer_coin = "1000000uatom"
+[scenarios.profile]
+type = "ramp"
+from = 10
+to = 50
+
+[[scenarios]]
+rounds = 5
+txs_per_block = 10
+pool_id = 1
+offer_coin = "1000000uatom"
+[scenarios.profile]
+type = "spike"
+peak = 100
+at = 2
+length = 1
+`
+	f, err := scenario.ParseString([]byte(sampleScenario))
+	require.NoError(t, err)
+	require.NoError(t, f.Validate(1))
+
+	p, err := f.Scenarios[0].LoadProfile()
+	require.NoError(t, err)
+	require.Equal(t, load.Ramp{From: 10, To: 50, Steps: 5}, p)
+
+	p, err = f.Scenarios[1].LoadProfile()
+	require.NoError(t, err)
+	require.Equal(t, 100, p.Count(2))
+	require.Equal(t, 10, p.Count(3))
+
+	for _, pc := range []scenario.ProfileConfig{
+		{Type: scenario.ProfileRamp},
+		{Type: scenario.ProfileStep},
+		{Type: scenario.ProfileSpike, Peak: 10},
+		{Type: scenario.ProfileSine},
+		{Type: "unknown"},
+	} {
+		s := f.Scenarios[1]
+		s.Profile = pc
+		_, err := s.LoadProfile()
+		require.Error(t, err, pc.Type)
+	}
+}
+
+func TestScenarioMix(t *testing.T) {
+	var sampleScenario = `
+[[scenarios]]
+rounds = 5
+txs_per_block = 100
+pool_id = 1
+offer_coin = "1000000uatom"
+deposit_coins = "1000000uatom,1000000uusd"
+pool_coin = "10pool94720F40B38D6DD93DCE184D264D4BE089EDF124A9C0658CDBED6CA18CF27752"
+[scenarios.mix]
+swap = 70
+deposit = 15
+withdraw = 10
+transfer = 5
+[scenarios.transfer]
+channel = "channel-0"
+receiver = "cosmos18zh6zd2kwtekjeg0ns5xvn2x28hgj8n6gxhe8c"
+amount = "1uatom"
+`
+	f, err := scenario.ParseString([]byte(sampleScenario))
+	require.NoError(t, err)
+	require.NoError(t, f.Validate(1))
+
+	s := f.Scenarios[0]
+	require.Equal(t, scenario.DefaultTransferPort, s.Transfer.Port)
+
+	mix, err := s.MsgMix()
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		scenario.MsgTypeDeposit,
+		scenario.MsgTypeSwap,
+		scenario.MsgTypeTransfer,
+		scenario.MsgTypeWithdraw,
+	}, mix.Types())
+
+	for _, tc := range []struct {
+		name     string
+		malleate func(s *scenario.Scenario)
+	}{
+		{"unknown msg type", func(s *scenario.Scenario) { s.Mix = map[string]int{"unknown": 1} }},
+		{"no weights", func(s *scenario.Scenario) { s.Mix = map[string]int{"swap": 0} }},
+		{"single deposit coin", func(s *scenario.Scenario) { s.DepositCoins = "1000000uatom" }},
+		{"invalid pool coin", func(s *scenario.Scenario) { s.PoolCoin = "pool" }},
+		{"missing transfer channel", func(s *scenario.Scenario) { s.Transfer.Channel = "" }},
+		{"invalid transfer amount", func(s *scenario.Scenario) { s.Transfer.Amount = "" }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := f.Scenarios[0]
+			tc.malleate(&s)
+			require.Error(t, s.Validate(1))
+		})
+	}
+
+	// parameters of the types not in the mix are not required
+	s.Mix = map[string]int{scenario.MsgTypeDeposit: 1}
+	s.OfferCoin = ""
+	s.Transfer = scenario.TransferConfig{}
+	require.NoError(t, s.Validate(1))
+}
+
+func TestParseProfile(t *testing.T) {
+	p, err := scenario.ParseProfile("spike:peak=100,at=30,length=10")
+	require.NoError(t, err)
+	require.Equal(t, scenario.ProfileConfig{Type: scenario.ProfileSpike, Peak: 100, At: 30, Length: 10}, p)
+
+	p, err = scenario.ParseProfile("flat")
+	require.NoError(t, err)
+	require.Equal(t, scenario.ProfileConfig{Type: scenario.ProfileFlat}, p)
+
+	for _, spec := range []string{"ramp:from", "ramp:from=x", "ramp:height=1"} {
+		_, err := scenario.ParseProfile(spec)
+		require.Error(t, err, spec)
+	}
+}
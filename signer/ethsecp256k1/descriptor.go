@@ -0,0 +1,54 @@
+package ethsecp256k1
+
+import (
+	"bytes"
+	"compress/gzip"
+
+	"github.com/gogo/protobuf/proto"
+	descpb "github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+)
+
+// fileDescriptor is the gzipped descriptor of the keys.proto file of Ethermint, which the decoder of the txs
+// checks the fields of the public keys against. It is built here instead of being generated by protoc.
+var fileDescriptor = func() []byte {
+	keyMessage := func(name string) *descpb.DescriptorProto {
+		return &descpb.DescriptorProto{
+			Name: proto.String(name),
+			Field: []*descpb.FieldDescriptorProto{{
+				Name:     proto.String("key"),
+				JsonName: proto.String("key"),
+				Number:   proto.Int32(1),
+				Label:    descpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     descpb.FieldDescriptorProto_TYPE_BYTES.Enum(),
+			}},
+		}
+	}
+	fd := &descpb.FileDescriptorProto{
+		Name:        proto.String(descriptorName),
+		Package:     proto.String("ethermint.crypto.v1.ethsecp256k1"),
+		MessageType: []*descpb.DescriptorProto{keyMessage("PubKey"), keyMessage("PrivKey")},
+		Syntax:      proto.String("proto3"),
+	}
+	bz, err := proto.Marshal(fd)
+	if err != nil {
+		panic(err)
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(bz); err != nil {
+		panic(err)
+	}
+	if err := zw.Close(); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}()
+
+const descriptorName = "ethermint/crypto/v1/ethsecp256k1/keys.proto"
+
+func init() {
+	proto.RegisterFile(descriptorName, fileDescriptor)
+}
+
+func (*PubKey) Descriptor() ([]byte, []int)  { return fileDescriptor, []int{0} }
+func (*PrivKey) Descriptor() ([]byte, []int) { return fileDescriptor, []int{1} }
@@ -0,0 +1,200 @@
+// Package ethsecp256k1 implements the Ethereum flavor of secp256k1 keys used by Ethermint based chains,
+// which derive their keys with coin type 60, sign the keccak256 hashes of the sign bytes and take their
+// addresses from the keccak256 hashes of the uncompressed public keys.
+package ethsecp256k1
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	"github.com/gogo/protobuf/proto"
+	tmcrypto "github.com/tendermint/tendermint/crypto"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	// KeyType is the type of the keys.
+	KeyType = "eth_secp256k1"
+	// PrivKeySize is the size of a private key in bytes.
+	PrivKeySize = 32
+	// PubKeySize is the size of a compressed public key in bytes.
+	PubKeySize = 33
+
+	// the proto names of the keys of Ethermint, which the chains decode the public keys of the txs by
+	pubKeyName  = "ethermint.crypto.v1.ethsecp256k1.PubKey"
+	privKeyName = "ethermint.crypto.v1.ethsecp256k1.PrivKey"
+)
+
+func init() {
+	proto.RegisterType((*PubKey)(nil), pubKeyName)
+	proto.RegisterType((*PrivKey)(nil), privKeyName)
+}
+
+// RegisterInterfaces registers the keys as implementations of the key interfaces, so that the txs signed by them can be decoded.
+func RegisterInterfaces(registry codectypes.InterfaceRegistry) {
+	registry.RegisterImplementations((*cryptotypes.PubKey)(nil), &PubKey{})
+	registry.RegisterImplementations((*cryptotypes.PrivKey)(nil), &PrivKey{})
+}
+
+// Keccak256 returns the keccak256 hash of the data.
+func Keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, b := range data {
+		h.Write(b)
+	}
+	return h.Sum(nil)
+}
+
+var (
+	_ cryptotypes.PrivKey = &PrivKey{}
+	_ cryptotypes.PubKey  = &PubKey{}
+)
+
+// PrivKey is an Ethereum secp256k1 private key.
+type PrivKey struct {
+	Key []byte
+}
+
+// NewPrivKey returns the private key of the given bytes.
+func NewPrivKey(key []byte) (*PrivKey, error) {
+	if len(key) != PrivKeySize {
+		return nil, fmt.Errorf("private key must be %d bytes, got %d", PrivKeySize, len(key))
+	}
+	return &PrivKey{Key: key}, nil
+}
+
+func (k *PrivKey) Bytes() []byte { return k.Key }
+
+func (k *PrivKey) Type() string { return KeyType }
+
+// PubKey returns the compressed public key.
+func (k *PrivKey) PubKey() cryptotypes.PubKey {
+	_, pub := btcec.PrivKeyFromBytes(btcec.S256(), k.Key)
+	return &PubKey{Key: pub.SerializeCompressed()}
+}
+
+func (k *PrivKey) Equals(other cryptotypes.LedgerPrivKey) bool {
+	return k.Type() == other.Type() && subtle.ConstantTimeCompare(k.Bytes(), other.Bytes()) == 1
+}
+
+// Sign signs the keccak256 hash of the message, returning the signature in the [R || S || V] format of Ethereum.
+func (k *PrivKey) Sign(msg []byte) ([]byte, error) {
+	priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), k.Key)
+	compact, err := btcec.SignCompact(btcec.S256(), priv, Keccak256(msg), false)
+	if err != nil {
+		return nil, err
+	}
+	// the compact signature is [27 + V || R || S]
+	sig := append(compact[1:], compact[0]-27)
+	return sig, nil
+}
+
+func (k *PrivKey) Reset()         { *k = PrivKey{} }
+func (k *PrivKey) String() string { return "ethsecp256k1.PrivKey{***}" }
+func (*PrivKey) ProtoMessage()    {}
+
+func (*PrivKey) XXX_MessageName() string { return privKeyName }
+
+func (k *PrivKey) Marshal() ([]byte, error) { return marshalKey(k.Key), nil }
+
+func (k *PrivKey) Unmarshal(b []byte) error {
+	key, err := unmarshalKey(b)
+	k.Key = key
+	return err
+}
+
+// PubKey is a compressed Ethereum secp256k1 public key.
+type PubKey struct {
+	Key []byte
+}
+
+// Address returns the last 20 bytes of the keccak256 hash of the uncompressed public key, without its prefix.
+func (k *PubKey) Address() tmcrypto.Address {
+	pub, err := btcec.ParsePubKey(k.Key, btcec.S256())
+	if err != nil {
+		panic(fmt.Errorf("invalid public key: %w", err))
+	}
+	return tmcrypto.Address(Keccak256(pub.SerializeUncompressed()[1:])[12:])
+}
+
+func (k *PubKey) Bytes() []byte { return k.Key }
+
+func (k *PubKey) Type() string { return KeyType }
+
+func (k *PubKey) Equals(other cryptotypes.PubKey) bool {
+	return k.Type() == other.Type() && bytes.Equal(k.Bytes(), other.Bytes())
+}
+
+// VerifySignature verifies a signature of the keccak256 hash of the message, in the [R || S || V] format.
+func (k *PubKey) VerifySignature(msg, sig []byte) bool {
+	if len(sig) != 65 {
+		return false
+	}
+	pub, err := btcec.ParsePubKey(k.Key, btcec.S256())
+	if err != nil {
+		return false
+	}
+	s := &btcec.Signature{R: new(big.Int).SetBytes(sig[:32]), S: new(big.Int).SetBytes(sig[32:64])}
+	// reject the malleable signatures of a high S as Ethereum does
+	if s.S.Cmp(new(big.Int).Rsh(btcec.S256().N, 1)) > 0 {
+		return false
+	}
+	return s.Verify(Keccak256(msg), pub)
+}
+
+func (k *PubKey) Reset()         { *k = PubKey{} }
+func (k *PubKey) String() string { return fmt.Sprintf("EthPubKeySecp256k1{%X}", k.Key) }
+func (*PubKey) ProtoMessage()    {}
+
+func (*PubKey) XXX_MessageName() string { return pubKeyName }
+
+func (k *PubKey) Marshal() ([]byte, error) { return marshalKey(k.Key), nil }
+
+func (k *PubKey) Unmarshal(b []byte) error {
+	key, err := unmarshalKey(b)
+	k.Key = key
+	return err
+}
+
+// marshalKey encodes the key as the only field of the proto messages of the keys, `bytes key = 1`.
+func marshalKey(key []byte) []byte {
+	b := proto.EncodeVarint(uint64(1<<3 | proto.WireBytes))
+	b = append(b, proto.EncodeVarint(uint64(len(key)))...)
+	return append(b, key...)
+}
+
+func unmarshalKey(b []byte) ([]byte, error) {
+	var key []byte
+	for len(b) > 0 {
+		tag, n := proto.DecodeVarint(b)
+		if n == 0 {
+			return nil, fmt.Errorf("invalid tag")
+		}
+		b = b[n:]
+		field, wire := tag>>3, tag&7
+		switch wire {
+		case proto.WireVarint:
+			if _, n = proto.DecodeVarint(b); n == 0 {
+				return nil, fmt.Errorf("invalid varint of field %d", field)
+			}
+			b = b[n:]
+		case proto.WireBytes:
+			l, n := proto.DecodeVarint(b)
+			if n == 0 || uint64(len(b)-n) < l {
+				return nil, fmt.Errorf("invalid length of field %d", field)
+			}
+			if field == 1 {
+				key = append([]byte(nil), b[n:n+int(l)]...)
+			}
+			b = b[n+int(l):]
+		default:
+			return nil, fmt.Errorf("unexpected wire type %d of field %d", wire, field)
+		}
+	}
+	return key, nil
+}
@@ -0,0 +1,65 @@
+package ethsecp256k1_test
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	"github.com/test-go/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/signer/ethsecp256k1"
+)
+
+func testKey(t *testing.T) *ethsecp256k1.PrivKey {
+	bz, err := hex.DecodeString("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	require.NoError(t, err)
+	key, err := ethsecp256k1.NewPrivKey(bz)
+	require.NoError(t, err)
+	return key
+}
+
+func TestAddress(t *testing.T) {
+	key := testKey(t)
+	require.Equal(t, strings.ToLower("2c7536E3605D9C16a7a3D7b1898e529396a65c23"), hex.EncodeToString(key.PubKey().Address()))
+	require.Len(t, key.PubKey().Bytes(), ethsecp256k1.PubKeySize)
+	require.Equal(t, ethsecp256k1.KeyType, key.PubKey().Type())
+
+	_, err := ethsecp256k1.NewPrivKey([]byte{1, 2, 3})
+	require.Error(t, err)
+}
+
+func TestSignAndVerify(t *testing.T) {
+	key := testKey(t)
+	msg := []byte("sign bytes")
+
+	sig, err := key.Sign(msg)
+	require.NoError(t, err)
+	require.Len(t, sig, 65)
+	require.Contains(t, []byte{0, 1}, sig[64])
+
+	pub := key.PubKey()
+	require.True(t, pub.VerifySignature(msg, sig))
+	require.False(t, pub.VerifySignature([]byte("other bytes"), sig))
+	require.False(t, pub.VerifySignature(msg, sig[:64]))
+}
+
+func TestAny(t *testing.T) {
+	registry := codectypes.NewInterfaceRegistry()
+	ethsecp256k1.RegisterInterfaces(registry)
+
+	pub := testKey(t).PubKey()
+	any, err := codectypes.NewAnyWithValue(pub)
+	require.NoError(t, err)
+	require.Equal(t, "/ethermint.crypto.v1.ethsecp256k1.PubKey", any.TypeUrl)
+
+	bz, err := any.Marshal()
+	require.NoError(t, err)
+	var decoded codectypes.Any
+	require.NoError(t, decoded.Unmarshal(bz))
+
+	var got cryptotypes.PubKey
+	require.NoError(t, registry.UnpackAny(&decoded, &got))
+	require.True(t, pub.Equals(got))
+}
@@ -0,0 +1,114 @@
+// Package signer provides the signers of the txs, which hold the keys of the accounts of the workloads.
+package signer
+
+import (
+	"fmt"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	bip39 "github.com/cosmos/go-bip39"
+
+	"github.com/b-harvest/modules-test-tool/signer/ethsecp256k1"
+)
+
+// The types of the keys.
+const (
+	KeyTypeSecp256k1    = "secp256k1"
+	KeyTypeEthSecp256k1 = ethsecp256k1.KeyType
+)
+
+// Signer signs the sign bytes of the txs of an account.
+type Signer interface {
+	Address() sdktypes.AccAddress
+	PubKey() cryptotypes.PubKey
+	Sign(msg []byte) ([]byte, error)
+}
+
+type privKeySigner struct {
+	key cryptotypes.PrivKey
+}
+
+// NewPrivKeySigner returns a signer of a private key.
+func NewPrivKeySigner(key cryptotypes.PrivKey) Signer {
+	return privKeySigner{key: key}
+}
+
+// NewSecp256k1 returns a signer of a secp256k1 private key.
+func NewSecp256k1(key *secp256k1.PrivKey) Signer {
+	return NewPrivKeySigner(key)
+}
+
+// NewEthSecp256k1 returns a signer of an Ethereum secp256k1 private key.
+func NewEthSecp256k1(key *ethsecp256k1.PrivKey) Signer {
+	return NewPrivKeySigner(key)
+}
+
+func (s privKeySigner) Address() sdktypes.AccAddress {
+	return sdktypes.AccAddress(s.key.PubKey().Address())
+}
+
+func (s privKeySigner) PubKey() cryptotypes.PubKey {
+	return s.key.PubKey()
+}
+
+func (s privKeySigner) Sign(msg []byte) ([]byte, error) {
+	return s.key.Sign(msg)
+}
+
+// FromMnemonic derives the key of the given type at the HD path from a mnemonic and returns its signer.
+func FromMnemonic(mnemonic, password, hdPath, keyType string) (Signer, error) {
+	seed := bip39.NewSeed(mnemonic, password)
+	masterKey, ch := hd.ComputeMastersFromSeed(seed)
+	priv, err := hd.DerivePrivateKeyForPath(masterKey, ch, hdPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive private key for path: %w", err)
+	}
+	switch keyType {
+	case "", KeyTypeSecp256k1:
+		return NewSecp256k1(&secp256k1.PrivKey{Key: priv}), nil
+	case KeyTypeEthSecp256k1:
+		return NewEthSecp256k1(&ethsecp256k1.PrivKey{Key: priv}), nil
+	default:
+		return nil, fmt.Errorf("unknown key type %q", keyType)
+	}
+}
+
+type keyringSigner struct {
+	kr   keyring.Keyring
+	uid  string
+	info keyring.Info
+}
+
+// NewKeyring returns a signer of the key of the given name in a keyring.
+func NewKeyring(kr keyring.Keyring, uid string) (Signer, error) {
+	info, err := kr.Key(uid)
+	if err != nil {
+		return nil, fmt.Errorf("get key %s: %w", uid, err)
+	}
+	return &keyringSigner{kr: kr, uid: uid, info: info}, nil
+}
+
+// OpenKeyring returns a signer of the key of the given name in the keyring of the backend in the directory.
+func OpenKeyring(backend, dir, uid string) (Signer, error) {
+	kr, err := keyring.New(sdktypes.KeyringServiceName(), backend, dir, nil)
+	if err != nil {
+		return nil, fmt.Errorf("open keyring: %w", err)
+	}
+	return NewKeyring(kr, uid)
+}
+
+func (s *keyringSigner) Address() sdktypes.AccAddress {
+	return s.info.GetAddress()
+}
+
+func (s *keyringSigner) PubKey() cryptotypes.PubKey {
+	return s.info.GetPubKey()
+}
+
+func (s *keyringSigner) Sign(msg []byte) ([]byte, error) {
+	sig, _, err := s.kr.Sign(s.uid, msg)
+	return sig, err
+}
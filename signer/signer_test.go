@@ -0,0 +1,66 @@
+package signer_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/test-go/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/signer"
+)
+
+const testMnemonic = "test test test test test test test test test test test junk"
+
+func TestFromMnemonic(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		hdPath  string
+		keyType string
+		expAddr string
+	}{
+		{"secp256k1", "44'/118'/0'/0/0", signer.KeyTypeSecp256k1, ""},
+		{"default", "44'/118'/0'/0/0", "", ""},
+		{"eth_secp256k1", "44'/60'/0'/0/0", signer.KeyTypeEthSecp256k1, "f39fd6e51aad88f6f4ce6ab8827279cfffb92266"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := signer.FromMnemonic(testMnemonic, "", tc.hdPath, tc.keyType)
+			require.NoError(t, err)
+			if tc.expAddr != "" {
+				require.Equal(t, tc.expAddr, hex.EncodeToString(s.Address()))
+			}
+			require.Equal(t, s.PubKey().Address().Bytes(), s.Address().Bytes())
+
+			msg := []byte("sign bytes")
+			sig, err := s.Sign(msg)
+			require.NoError(t, err)
+			require.True(t, s.PubKey().VerifySignature(msg, sig))
+		})
+	}
+
+	_, err := signer.FromMnemonic(testMnemonic, "", "44'/118'/0'/0/0", "ed25519")
+	require.Error(t, err)
+}
+
+func TestKeyring(t *testing.T) {
+	kr := keyring.NewInMemory()
+	info, err := kr.NewAccount("tester", testMnemonic, "", "44'/118'/0'/0/0", hd.Secp256k1)
+	require.NoError(t, err)
+
+	s, err := signer.NewKeyring(kr, "tester")
+	require.NoError(t, err)
+	require.Equal(t, info.GetAddress(), s.Address())
+
+	fromMnemonic, err := signer.FromMnemonic(testMnemonic, "", "44'/118'/0'/0/0", signer.KeyTypeSecp256k1)
+	require.NoError(t, err)
+	require.Equal(t, fromMnemonic.Address(), s.Address())
+
+	msg := []byte("sign bytes")
+	sig, err := s.Sign(msg)
+	require.NoError(t, err)
+	require.True(t, s.PubKey().VerifySignature(msg, sig))
+
+	_, err = signer.NewKeyring(kr, "unknown")
+	require.Error(t, err)
+}
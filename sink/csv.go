@@ -0,0 +1,263 @@
+package sink
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Header is the header of the csv file of the records.
+var Header = []string{
+	"run_id",
+	"command",
+	"chain_id",
+	"scenario",
+	"msg_type",
+	"height",
+	"block_time",
+	"block_duration",
+	"num_broadcast_txs",
+	"num_failed_txs",
+	"num_committed_txs",
+	"planned_num_broadcast_txs",
+}
+
+// CodesHeader is the header of the csv file which breaks the broadcast responses of every record down by code.
+var CodesHeader = []string{
+	"run_id",
+	"chain_id",
+	"scenario",
+	"msg_type",
+	"height",
+	"code",
+	"count",
+}
+
+// ClassesHeader is the header of the csv file which breaks the broadcast responses of every record down by error class.
+var ClassesHeader = []string{
+	"run_id",
+	"chain_id",
+	"scenario",
+	"msg_type",
+	"height",
+	"class",
+	"count",
+}
+
+// CodesPath returns the path of the csv file of the codes next to the csv file of the records,
+// e.g. result_codes.csv for result.csv.
+func CodesPath(path string) string {
+	return SidePath(path, "codes")
+}
+
+// ClassesPath returns the path of the csv file of the error classes next to the csv file of the records,
+// e.g. result_classes.csv for result.csv.
+func ClassesPath(path string) string {
+	return SidePath(path, "classes")
+}
+
+// SidePath returns the path of a csv file of the given name next to the output of the given path,
+// e.g. result_mempool.csv for result.db.
+func SidePath(path, name string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + "_" + name + ".csv"
+}
+
+// CSVSink writes the records to a csv file, and their codes and error classes to other ones next to it.
+type CSVSink struct {
+	f  *os.File
+	w  *csv.Writer
+	cf *os.File
+	cw *csv.Writer
+	lf *os.File
+	lw *csv.Writer
+}
+
+// OpenCSVSink opens a CSVSink which appends to the csv file of the given path and to its codes and classes files.
+func OpenCSVSink(path string) (*CSVSink, error) {
+	f, w, err := OpenCSV(path, Header)
+	if err != nil {
+		return nil, err
+	}
+	cf, cw, err := OpenCSV(CodesPath(path), CodesHeader)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	lf, lw, err := OpenCSV(ClassesPath(path), ClassesHeader)
+	if err != nil {
+		f.Close()
+		cf.Close()
+		return nil, err
+	}
+	return &CSVSink{f: f, w: w, cf: cf, cw: cw, lf: lf, lw: lw}, nil
+}
+
+func (s *CSVSink) Write(rec Record) error {
+	if err := WriteCSVRow(s.w, []string{
+		rec.RunID,
+		rec.Command,
+		rec.ChainID,
+		rec.Scenario,
+		rec.MsgType,
+		strconv.FormatInt(rec.Height, 10),
+		rec.BlockTime.Format(time.RFC3339Nano),
+		rec.BlockDuration.String(),
+		strconv.Itoa(rec.Broadcast),
+		strconv.Itoa(rec.Failed),
+		strconv.Itoa(rec.Committed),
+		strconv.Itoa(rec.Planned),
+	}); err != nil {
+		return err
+	}
+	for _, code := range SortedCodes(rec.Codes) {
+		if err := WriteCSVRow(s.cw, []string{
+			rec.RunID,
+			rec.ChainID,
+			rec.Scenario,
+			rec.MsgType,
+			strconv.FormatInt(rec.Height, 10),
+			strconv.FormatUint(uint64(code), 10),
+			strconv.Itoa(rec.Codes[code]),
+		}); err != nil {
+			return err
+		}
+	}
+	for _, class := range SortedClasses(rec.Classes) {
+		if err := WriteCSVRow(s.lw, []string{
+			rec.RunID,
+			rec.ChainID,
+			rec.Scenario,
+			rec.MsgType,
+			strconv.FormatInt(rec.Height, 10),
+			class,
+			strconv.Itoa(rec.Classes[class]),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *CSVSink) Close() error {
+	err := s.f.Close()
+	for _, f := range []*os.File{s.cf, s.lf} {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// SortedCodes returns the codes in order.
+func SortedCodes(codes map[uint32]int) []uint32 {
+	keys := make([]uint32, 0, len(codes))
+	for code := range codes {
+		keys = append(keys, code)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// SortedClasses returns the error classes in alphabetical order.
+func SortedClasses(classes map[string]int) []string {
+	keys := make([]string, 0, len(classes))
+	for class := range classes {
+		keys = append(keys, class)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// OpenCSV opens the csv file of the given path for appending and writes the header when the file is empty.
+// A file of another header, e.g. written by an older version, is not appended to; it is moved aside to its
+// archive path and a new file is started.
+func OpenCSV(path string, header []string) (*os.File, *csv.Writer, error) {
+	if err := archiveCSV(path, header); err != nil {
+		return nil, nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open file: %w", err)
+	}
+	w := csv.NewWriter(f)
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("stat file: %w", err)
+	}
+	if fi.Size() == 0 {
+		if err := w.Write(header); err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("emit header: %w", err)
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("write header: %w", err)
+		}
+	}
+	return f, w, nil
+}
+
+// ArchivePath returns the path a csv file last modified at the given time is moved to when its header
+// is out of date, e.g. result.20220101T000000.csv for result.csv.
+func ArchivePath(path string, modTime time.Time) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + modTime.UTC().Format("20060102T150405") + ext
+}
+
+// archiveCSV moves the csv file of the given path to its archive path if it exists with another header.
+func archiveCSV(path string, header []string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+	got, err := csv.NewReader(f).Read()
+	f.Close()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read header of %s: %w", path, err)
+	}
+	if strings.Join(got, ",") == strings.Join(header, ",") {
+		return nil
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("stat file: %w", err)
+	}
+	archive := ArchivePath(path, fi.ModTime())
+	if _, err := os.Stat(archive); err == nil {
+		return fmt.Errorf("%s has another header %v and %s exists already; move it away to start a new one", path, got, archive)
+	}
+	if err := os.Rename(path, archive); err != nil {
+		return fmt.Errorf("archive %s: %w", path, err)
+	}
+	log.Warn().Str("path", path).Str("archive", archive).Strs("header", got).Msg("moved away a csv file of an older header")
+	return nil
+}
+
+// WriteCSVRow writes a single row and flushes it right away.
+func WriteCSVRow(w *csv.Writer, row []string) error {
+	if err := w.Write(row); err != nil {
+		return fmt.Errorf("emit row: %w", err)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("write row: %w", err)
+	}
+	return nil
+}
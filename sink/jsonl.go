@@ -0,0 +1,33 @@
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// JSONLSink writes the records as JSON Lines, one record per line.
+type JSONLSink struct {
+	f   *os.File
+	enc *json.Encoder
+}
+
+// OpenJSONLSink opens a JSONLSink which appends to the file of the given path.
+func OpenJSONLSink(path string) (*JSONLSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+	return &JSONLSink{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (s *JSONLSink) Write(rec Record) error {
+	if err := s.enc.Encode(rec); err != nil {
+		return fmt.Errorf("write record: %w", err)
+	}
+	return nil
+}
+
+func (s *JSONLSink) Close() error {
+	return s.f.Close()
+}
@@ -0,0 +1,133 @@
+// Package sink writes the results of the workloads as CSV, JSON Lines or SQLite.
+//
+// Every record carries the run, the command, the chain and the scenario it belongs to,
+// so that the results of many runs can be written to the same output and queried together.
+package sink
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Formats of the result sinks.
+const (
+	FormatCSV    = "csv"
+	FormatJSONL  = "jsonl"
+	FormatSQLite = "sqlite"
+)
+
+// Record is the result of a block, or of the txs of a single message type in a block.
+type Record struct {
+	RunID    string `json:"run_id"`
+	Command  string `json:"command"`
+	ChainID  string `json:"chain_id"`
+	Scenario string `json:"scenario"`
+	// MsgType is empty for the record of the whole block.
+	MsgType       string        `json:"msg_type,omitempty"`
+	Height        int64         `json:"height"`
+	BlockTime     time.Time     `json:"block_time"`
+	BlockDuration time.Duration `json:"block_duration_ns"`
+	Broadcast     int           `json:"num_broadcast_txs"`
+	Failed        int           `json:"num_failed_txs"`
+	Committed     int           `json:"num_committed_txs"`
+	Planned       int           `json:"planned_num_broadcast_txs"`
+	// Codes is the number of broadcast responses by code.
+	Codes map[uint32]int `json:"codes,omitempty"`
+	// Classes is the number of broadcast responses by error class.
+	Classes map[string]int `json:"classes,omitempty"`
+}
+
+// ResultSink writes records. It is not safe for concurrent use unless it is wrapped by WithRun.
+type ResultSink interface {
+	Write(rec Record) error
+	Close() error
+}
+
+// Open opens a result sink of the given format which appends to the given path.
+func Open(format, path string) (ResultSink, error) {
+	switch format {
+	case FormatCSV:
+		return OpenCSVSink(path)
+	case FormatJSONL:
+		return OpenJSONLSink(path)
+	case FormatSQLite:
+		return OpenSQLiteSink(path)
+	default:
+		return nil, CheckFormat(format)
+	}
+}
+
+// CheckFormat returns an error if the output format is unknown or not built in.
+func CheckFormat(format string) error {
+	switch format {
+	case FormatCSV, FormatJSONL:
+		return nil
+	case FormatSQLite:
+		if !SQLiteSupported {
+			return fmt.Errorf("the %s output needs the tester to be built with cgo (CGO_ENABLED=1)", FormatSQLite)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q; must be one of %s, %s or %s", format, FormatCSV, FormatJSONL, FormatSQLite)
+	}
+}
+
+// DefaultPath returns the default path of the output of the given format.
+func DefaultPath(format string) string {
+	switch format {
+	case FormatJSONL:
+		return "result.jsonl"
+	case FormatSQLite:
+		return "result.db"
+	default:
+		return "result.csv"
+	}
+}
+
+// NewRunID returns a new run id, which starts with the given time so that run ids sort by their start.
+func NewRunID(now time.Time) string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return fmt.Sprintf("%s-%s", now.UTC().Format("20060102T150405Z"), hex.EncodeToString(b))
+}
+
+// runSink fills in the run of the records and serializes the writes.
+type runSink struct {
+	mu      sync.Mutex
+	s       ResultSink
+	runID   string
+	command string
+	chainID string
+}
+
+// WithRun wraps the sink so that it is safe for concurrent use and fills in the run id, the command
+// and the chain id of the records which do not have them.
+func WithRun(s ResultSink, runID, command, chainID string) ResultSink {
+	return &runSink{s: s, runID: runID, command: command, chainID: chainID}
+}
+
+func (rs *runSink) Write(rec Record) error {
+	if rec.RunID == "" {
+		rec.RunID = rs.runID
+	}
+	if rec.Command == "" {
+		rec.Command = rs.command
+	}
+	if rec.ChainID == "" {
+		rec.ChainID = rs.chainID
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.s.Write(rec)
+}
+
+func (rs *runSink) Close() error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.s.Close()
+}
@@ -0,0 +1,154 @@
+package sink_test
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/sink"
+)
+
+var records = []sink.Record{
+	{
+		Scenario:      "steady",
+		Height:        12,
+		BlockTime:     time.Date(2022, 1, 1, 0, 0, 4, 0, time.UTC),
+		BlockDuration: 3 * time.Second,
+		Broadcast:     195,
+		Committed:     190,
+		Planned:       200,
+		Codes:         map[uint32]int{20: 5, 0: 195},
+		Classes:       map[string]int{"ok": 195, "mempool_full": 5},
+	},
+	{
+		Scenario:  "steady",
+		MsgType:   "swap",
+		Height:    12,
+		BlockTime: time.Date(2022, 1, 1, 0, 0, 4, 0, time.UTC),
+		Broadcast: 195,
+		Failed:    5,
+		Committed: 190,
+	},
+}
+
+func writeRecords(t *testing.T, format, path string) {
+	s, err := sink.Open(format, path)
+	require.NoError(t, err)
+	s = sink.WithRun(s, "run-1", "stress-test", "localnet")
+	for _, rec := range records {
+		require.NoError(t, s.Write(rec))
+	}
+	require.NoError(t, s.Close())
+}
+
+func TestCSVSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "result.csv")
+	writeRecords(t, sink.FormatCSV, path)
+	writeRecords(t, sink.FormatCSV, path)
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, `run_id,command,chain_id,scenario,msg_type,height,block_time,block_duration,num_broadcast_txs,num_failed_txs,num_committed_txs,planned_num_broadcast_txs
+run-1,stress-test,localnet,steady,,12,2022-01-01T00:00:04Z,3s,195,0,190,200
+run-1,stress-test,localnet,steady,swap,12,2022-01-01T00:00:04Z,0s,195,5,190,0
+run-1,stress-test,localnet,steady,,12,2022-01-01T00:00:04Z,3s,195,0,190,200
+run-1,stress-test,localnet,steady,swap,12,2022-01-01T00:00:04Z,0s,195,5,190,0
+`, string(b))
+
+	b, err = os.ReadFile(sink.CodesPath(path))
+	require.NoError(t, err)
+	require.Equal(t, `run_id,chain_id,scenario,msg_type,height,code,count
+run-1,localnet,steady,,12,0,195
+run-1,localnet,steady,,12,20,5
+run-1,localnet,steady,,12,0,195
+run-1,localnet,steady,,12,20,5
+`, string(b))
+
+	b, err = os.ReadFile(sink.ClassesPath(path))
+	require.NoError(t, err)
+	require.Equal(t, `run_id,chain_id,scenario,msg_type,height,class,count
+run-1,localnet,steady,,12,mempool_full,5
+run-1,localnet,steady,,12,ok,195
+run-1,localnet,steady,,12,mempool_full,5
+run-1,localnet,steady,,12,ok,195
+`, string(b))
+
+	// a file of an older header is archived and a new one is started
+	legacy := "height,num_broadcast_txs\n12,195\n"
+	require.NoError(t, os.WriteFile(path, []byte(legacy), 0644))
+	modTime := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+	writeRecords(t, sink.FormatCSV, path)
+
+	archive := sink.ArchivePath(path, modTime)
+	require.Equal(t, filepath.Join(filepath.Dir(path), "result.20220102T030405.csv"), archive)
+	b, err = os.ReadFile(archive)
+	require.NoError(t, err)
+	require.Equal(t, legacy, string(b))
+	b, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(b), "run_id,command,chain_id,")
+}
+
+func TestJSONLSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "result.jsonl")
+	writeRecords(t, sink.FormatJSONL, path)
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var got []sink.Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec sink.Record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+		got = append(got, rec)
+	}
+	require.NoError(t, scanner.Err())
+	require.Len(t, got, 2)
+	exp := records[0]
+	exp.RunID, exp.Command, exp.ChainID = "run-1", "stress-test", "localnet"
+	require.Equal(t, exp, got[0])
+	require.Equal(t, "swap", got[1].MsgType)
+}
+
+func TestSQLiteSink(t *testing.T) {
+	if !sink.SQLiteSupported {
+		t.Skip("built without cgo")
+	}
+	path := filepath.Join(t.TempDir(), "result.db")
+	writeRecords(t, sink.FormatSQLite, path)
+	writeRecords(t, sink.FormatSQLite, path)
+
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var n, committed int
+	var duration int64
+	require.NoError(t, db.QueryRow(
+		`SELECT COUNT(*), SUM(num_committed_txs), MAX(block_duration_ns) FROM results WHERE run_id = ? AND chain_id = ? AND msg_type = ''`,
+		"run-1", "localnet",
+	).Scan(&n, &committed, &duration))
+	require.Equal(t, 2, n)
+	require.Equal(t, 380, committed)
+	require.Equal(t, int64(3*time.Second), duration)
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT SUM(count) FROM result_codes WHERE code = 20`).Scan(&count))
+	require.Equal(t, 10, count)
+	require.NoError(t, db.QueryRow(`SELECT SUM(count) FROM result_classes WHERE class = 'mempool_full'`).Scan(&count))
+	require.Equal(t, 10, count)
+}
+
+func TestUnknownFormat(t *testing.T) {
+	_, err := sink.Open("parquet", filepath.Join(t.TempDir(), "result.parquet"))
+	require.Error(t, err)
+}
@@ -0,0 +1,126 @@
+package sink
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS results (
+	run_id TEXT NOT NULL,
+	command TEXT NOT NULL,
+	chain_id TEXT NOT NULL,
+	scenario TEXT NOT NULL,
+	msg_type TEXT NOT NULL,
+	height INTEGER NOT NULL,
+	block_time TEXT NOT NULL,
+	block_duration_ns INTEGER NOT NULL,
+	num_broadcast_txs INTEGER NOT NULL,
+	num_failed_txs INTEGER NOT NULL,
+	num_committed_txs INTEGER NOT NULL,
+	planned_num_broadcast_txs INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS results_run_id ON results (run_id, scenario, height);
+CREATE TABLE IF NOT EXISTS result_codes (
+	run_id TEXT NOT NULL,
+	chain_id TEXT NOT NULL,
+	scenario TEXT NOT NULL,
+	msg_type TEXT NOT NULL,
+	height INTEGER NOT NULL,
+	code INTEGER NOT NULL,
+	count INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS result_codes_run_id ON result_codes (run_id, scenario, height);
+CREATE TABLE IF NOT EXISTS result_classes (
+	run_id TEXT NOT NULL,
+	chain_id TEXT NOT NULL,
+	scenario TEXT NOT NULL,
+	msg_type TEXT NOT NULL,
+	height INTEGER NOT NULL,
+	class TEXT NOT NULL,
+	count INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS result_classes_run_id ON result_classes (run_id, scenario, height);
+`
+
+// SQLiteSink writes the records to the results table of an SQLite database, their codes to the result_codes table
+// and their error classes to the result_classes table.
+type SQLiteSink struct {
+	db *sql.DB
+}
+
+// OpenSQLiteSink opens the SQLite database of the given path, creating it and its tables if they do not exist.
+func OpenSQLiteSink(path string) (*SQLiteSink, error) {
+	if err := CheckFormat(FormatSQLite); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create tables: %w", err)
+	}
+	return &SQLiteSink{db: db}, nil
+}
+
+func (s *SQLiteSink) Write(rec Record) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	if _, err := tx.Exec(`INSERT INTO results VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rec.RunID,
+		rec.Command,
+		rec.ChainID,
+		rec.Scenario,
+		rec.MsgType,
+		rec.Height,
+		rec.BlockTime.Format(time.RFC3339Nano),
+		int64(rec.BlockDuration),
+		rec.Broadcast,
+		rec.Failed,
+		rec.Committed,
+		rec.Planned,
+	); err != nil {
+		return fmt.Errorf("insert record: %w", err)
+	}
+	for _, code := range SortedCodes(rec.Codes) {
+		if _, err := tx.Exec(`INSERT INTO result_codes VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			rec.RunID,
+			rec.ChainID,
+			rec.Scenario,
+			rec.MsgType,
+			rec.Height,
+			code,
+			rec.Codes[code],
+		); err != nil {
+			return fmt.Errorf("insert code: %w", err)
+		}
+	}
+	for _, class := range SortedClasses(rec.Classes) {
+		if _, err := tx.Exec(`INSERT INTO result_classes VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			rec.RunID,
+			rec.ChainID,
+			rec.Scenario,
+			rec.MsgType,
+			rec.Height,
+			class,
+			rec.Classes[class],
+		); err != nil {
+			return fmt.Errorf("insert class: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteSink) Close() error {
+	return s.db.Close()
+}
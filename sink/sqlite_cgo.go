@@ -0,0 +1,11 @@
+//go:build cgo
+// +build cgo
+
+package sink
+
+import (
+	_ "github.com/mattn/go-sqlite3" // registers the sqlite3 driver
+)
+
+// SQLiteSupported is whether the sqlite output is built in, as the sqlite3 driver needs cgo.
+const SQLiteSupported = true
@@ -0,0 +1,7 @@
+//go:build !cgo
+// +build !cgo
+
+package sink
+
+// SQLiteSupported is whether the sqlite output is built in, as the sqlite3 driver needs cgo.
+const SQLiteSupported = false
@@ -0,0 +1,295 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// The types below are the OTLP/JSON encoding of an ExportTraceServiceRequest, as accepted by the
+// OTLP/HTTP receiver of a collector and read by its otlpjsonfile receiver. The upstream otlptracehttp
+// exporter is not used as it imports google.golang.org/grpc/credentials/insecure, which the grpc
+// v1.33.2 pinned by the replace of the Cosmos SDK does not have.
+
+// ExportRequest is an OTLP/JSON export request of spans.
+type ExportRequest struct {
+	ResourceSpans []ResourceSpans `json:"resourceSpans"`
+}
+
+type ResourceSpans struct {
+	Resource   Resource     `json:"resource"`
+	ScopeSpans []ScopeSpans `json:"scopeSpans"`
+}
+
+type Resource struct {
+	Attributes []KeyValue `json:"attributes,omitempty"`
+}
+
+type ScopeSpans struct {
+	Scope Scope  `json:"scope"`
+	Spans []Span `json:"spans"`
+}
+
+type Scope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type Span struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []KeyValue `json:"attributes,omitempty"`
+	Events            []Event    `json:"events,omitempty"`
+	Status            Status     `json:"status"`
+}
+
+type Event struct {
+	TimeUnixNano string     `json:"timeUnixNano"`
+	Name         string     `json:"name"`
+	Attributes   []KeyValue `json:"attributes,omitempty"`
+}
+
+// The OTLP status codes, which differ from the ones of the API.
+const (
+	StatusCodeUnset = 0
+	StatusCodeOK    = 1
+	StatusCodeError = 2
+)
+
+type Status struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type KeyValue struct {
+	Key   string   `json:"key"`
+	Value AnyValue `json:"value"`
+}
+
+type AnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"` // int64 is a string in OTLP/JSON
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+// Attribute returns the attribute of the key, if any.
+func (s Span) Attribute(key attribute.Key) (AnyValue, bool) {
+	for _, kv := range s.Attributes {
+		if kv.Key == string(key) {
+			return kv.Value, true
+		}
+	}
+	return AnyValue{}, false
+}
+
+// String returns the value as a string, whatever its type.
+func (v AnyValue) String() string {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return strconv.FormatBool(*v.BoolValue)
+	case v.IntValue != nil:
+		return *v.IntValue
+	case v.DoubleValue != nil:
+		return strconv.FormatFloat(*v.DoubleValue, 'g', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// Spans returns all the spans of the request.
+func (r ExportRequest) Spans() []Span {
+	var spans []Span
+	for _, rs := range r.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			spans = append(spans, ss.Spans...)
+		}
+	}
+	return spans
+}
+
+// NewExportRequest encodes the spans, grouped by their resource and instrumentation scope.
+func NewExportRequest(spans []sdktrace.ReadOnlySpan) ExportRequest {
+	var req ExportRequest
+	resources := make(map[attribute.Distinct]int)
+	scopes := make(map[attribute.Distinct]map[string]int)
+	for _, s := range spans {
+		res := s.Resource().Equivalent()
+		ri, ok := resources[res]
+		if !ok {
+			ri = len(req.ResourceSpans)
+			resources[res] = ri
+			scopes[res] = make(map[string]int)
+			req.ResourceSpans = append(req.ResourceSpans, ResourceSpans{
+				Resource: Resource{Attributes: keyValues(s.Resource().Attributes())},
+			})
+		}
+		rs := &req.ResourceSpans[ri]
+		scope := s.InstrumentationScope()
+		si, ok := scopes[res][scope.Name+"@"+scope.Version]
+		if !ok {
+			si = len(rs.ScopeSpans)
+			scopes[res][scope.Name+"@"+scope.Version] = si
+			rs.ScopeSpans = append(rs.ScopeSpans, ScopeSpans{Scope: Scope{Name: scope.Name, Version: scope.Version}})
+		}
+		rs.ScopeSpans[si].Spans = append(rs.ScopeSpans[si].Spans, encodeSpan(s))
+	}
+	return req
+}
+
+func encodeSpan(s sdktrace.ReadOnlySpan) Span {
+	span := Span{
+		TraceID:           s.SpanContext().TraceID().String(),
+		SpanID:            s.SpanContext().SpanID().String(),
+		Name:              s.Name(),
+		Kind:              int(s.SpanKind()), // the kinds of the API match the OTLP ones
+		StartTimeUnixNano: unixNano(s.StartTime()),
+		EndTimeUnixNano:   unixNano(s.EndTime()),
+		Attributes:        keyValues(s.Attributes()),
+	}
+	if s.Parent().IsValid() {
+		span.ParentSpanID = s.Parent().SpanID().String()
+	}
+	for _, e := range s.Events() {
+		span.Events = append(span.Events, Event{
+			TimeUnixNano: unixNano(e.Time),
+			Name:         e.Name,
+			Attributes:   keyValues(e.Attributes),
+		})
+	}
+	switch s.Status().Code {
+	case codes.Ok:
+		span.Status.Code = StatusCodeOK
+	case codes.Error:
+		span.Status = Status{Code: StatusCodeError, Message: s.Status().Description}
+	}
+	return span
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func keyValues(attrs []attribute.KeyValue) []KeyValue {
+	kvs := make([]KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		var v AnyValue
+		switch a.Value.Type() {
+		case attribute.BOOL:
+			b := a.Value.AsBool()
+			v.BoolValue = &b
+		case attribute.INT64:
+			i := strconv.FormatInt(a.Value.AsInt64(), 10)
+			v.IntValue = &i
+		case attribute.FLOAT64:
+			f := a.Value.AsFloat64()
+			v.DoubleValue = &f
+		default:
+			// slices are emitted as their string form
+			s := a.Value.Emit()
+			v.StringValue = &s
+		}
+		kvs = append(kvs, KeyValue{Key: string(a.Key), Value: v})
+	}
+	return kvs
+}
+
+// fileExporter writes every batch of spans as a line of OTLP/JSON.
+type fileExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewFileExporter returns an exporter writing the spans to w as OTLP/JSON lines.
+// w is closed on shutdown if it is an io.Closer.
+func NewFileExporter(w io.Writer) sdktrace.SpanExporter {
+	return &fileExporter{w: w}
+}
+
+func (e *fileExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	b, err := json.Marshal(NewExportRequest(spans))
+	if err != nil {
+		return fmt.Errorf("marshal spans: %w", err)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, err := e.w.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("write spans: %w", err)
+	}
+	return nil
+}
+
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	if c, ok := e.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// httpExporter posts the spans as OTLP/JSON to the traces endpoint of a collector.
+type httpExporter struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPExporter returns an exporter posting the spans to an OTLP/HTTP endpoint. The endpoint is either
+// an address or a URL; the /v1/traces path is added to it unless it has a path already.
+func NewHTTPExporter(endpoint string) (sdktrace.SpanExporter, error) {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parse trace endpoint: %w", err)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/traces"
+	}
+	return &httpExporter{url: u.String(), client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+func (e *httpExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	b, err := json.Marshal(NewExportRequest(spans))
+	if err != nil {
+		return fmt.Errorf("marshal spans: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("export spans: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("export spans: %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+func (e *httpExporter) Shutdown(ctx context.Context) error {
+	e.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,97 @@
+// Package tracing traces the steps of the workloads, from generating the messages to fetching the
+// committed block, as OpenTelemetry spans. The spans are exported as OTLP/JSON to a collector or a file.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name of the tracer of the tester.
+const InstrumentationName = "github.com/b-harvest/modules-test-tool"
+
+// The keys of the attributes of the spans.
+const (
+	ChainIDKey   = attribute.Key("chain.id")
+	ScenarioKey  = attribute.Key("scenario")
+	AccountKey   = attribute.Key("account")
+	SequenceKey  = attribute.Key("account.sequence")
+	MsgCountKey  = attribute.Key("msg.count")
+	PoolIDKey    = attribute.Key("pool.id")
+	DenomKey     = attribute.Key("denom")
+	TxHashKey    = attribute.Key("tx.hash")
+	TxCodeKey    = attribute.Key("tx.code")
+	CodespaceKey = attribute.Key("tx.codespace")
+	HeightKey    = attribute.Key("block.height")
+	NumTxsKey    = attribute.Key("block.num_txs")
+)
+
+// Tracer returns the tracer of the tester. Its spans are dropped until Setup installs an exporter.
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// Start starts a span of the tester.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends the span, marking it as failed if err is not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Options are where the spans are exported to.
+type Options struct {
+	// Endpoint is the OTLP/HTTP endpoint of a collector, e.g. localhost:4318.
+	Endpoint string
+	// File is the file the spans are appended to as OTLP/JSON lines.
+	File string
+	// ServiceName is the service.name of the resource of the spans.
+	ServiceName string
+}
+
+// Setup installs a global tracer provider exporting the spans as given, and returns the function
+// flushing and shutting it down. Nothing is installed if neither an endpoint nor a file is given.
+func Setup(opts Options) (func(context.Context) error, error) {
+	var exporters []sdktrace.SpanExporter
+	if opts.Endpoint != "" {
+		exp, err := NewHTTPExporter(opts.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		exporters = append(exporters, exp)
+	}
+	if opts.File != "" {
+		f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("open trace file: %w", err)
+		}
+		exporters = append(exporters, NewFileExporter(f))
+	}
+	if len(exporters) == 0 {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	tpOpts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(sdkresource.NewSchemaless(attribute.String("service.name", opts.ServiceName))),
+	}
+	for _, exp := range exporters {
+		tpOpts = append(tpOpts, sdktrace.WithBatcher(exp))
+	}
+	tp := sdktrace.NewTracerProvider(tpOpts...)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
@@ -0,0 +1,124 @@
+package tracing_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/tracing"
+)
+
+// collector is a stand-in of the OTLP/HTTP receiver of a collector.
+type collector struct {
+	mu    sync.Mutex
+	paths []string
+	spans []tracing.Span
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req tracing.ExportRequest
+	if r.Header.Get("Content-Type") != "application/json" || json.NewDecoder(r.Body).Decode(&req) != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paths = append(c.paths, r.URL.Path)
+	c.spans = append(c.spans, req.Spans()...)
+}
+
+func spanOf(t *testing.T, spans []tracing.Span, name string) tracing.Span {
+	for _, s := range spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("no span %s", name)
+	return tracing.Span{}
+}
+
+func TestSetup(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+	file := filepath.Join(t.TempDir(), "spans.jsonl")
+
+	shutdown, err := tracing.Setup(tracing.Options{Endpoint: srv.Listener.Addr().String(), File: file, ServiceName: "tester"})
+	require.NoError(t, err)
+
+	ctx, parent := tracing.Start(context.Background(), "Sign",
+		tracing.AccountKey.String("cosmos1abc"),
+		tracing.SequenceKey.Int64(7),
+		tracing.MsgCountKey.Int(3))
+	_, child := tracing.Start(ctx, "BroadcastTx")
+	child.SetAttributes(tracing.TxHashKey.String("ABCDEF"))
+	tracing.End(child, errors.New("mempool is full"))
+	tracing.End(parent, nil)
+	require.NoError(t, shutdown(context.Background()))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	require.Equal(t, []string{"/v1/traces"}, c.paths)
+	require.Len(t, c.spans, 2)
+	sign := spanOf(t, c.spans, "Sign")
+	broadcast := spanOf(t, c.spans, "BroadcastTx")
+	require.Equal(t, sign.TraceID, broadcast.TraceID)
+	require.Equal(t, sign.SpanID, broadcast.ParentSpanID)
+	require.Empty(t, sign.ParentSpanID)
+
+	v, ok := sign.Attribute(tracing.SequenceKey)
+	require.True(t, ok)
+	require.Equal(t, "7", *v.IntValue)
+	v, _ = sign.Attribute(tracing.AccountKey)
+	require.Equal(t, "cosmos1abc", v.String())
+	v, _ = sign.Attribute(tracing.MsgCountKey)
+	require.Equal(t, "3", v.String())
+	v, _ = broadcast.Attribute(tracing.TxHashKey)
+	require.Equal(t, "ABCDEF", v.String())
+	require.Equal(t, tracing.StatusCodeError, broadcast.Status.Code)
+	require.Equal(t, "mempool is full", broadcast.Status.Message)
+	require.Equal(t, "exception", broadcast.Events[0].Name)
+
+	f, err := os.Open(file)
+	require.NoError(t, err)
+	defer f.Close()
+	var lines []tracing.ExportRequest
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var req tracing.ExportRequest
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &req))
+		lines = append(lines, req)
+	}
+	require.Len(t, lines, 1)
+	require.Len(t, lines[0].Spans(), 2)
+	require.Equal(t, "service.name", lines[0].ResourceSpans[0].Resource.Attributes[0].Key)
+	require.Equal(t, tracing.InstrumentationName, lines[0].ResourceSpans[0].ScopeSpans[0].Scope.Name)
+}
+
+func TestSetupDisabled(t *testing.T) {
+	shutdown, err := tracing.Setup(tracing.Options{})
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+}
+
+func TestHTTPExporterError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	exp, err := tracing.NewHTTPExporter(srv.URL + "/custom")
+	require.NoError(t, err)
+	err = exp.ExportSpans(context.Background(), nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "503")
+}
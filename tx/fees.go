@@ -0,0 +1,101 @@
+package tx
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+)
+
+// The ways gas prices are picked from their levels.
+const (
+	GasPricePerAccount = "account"
+	GasPricePerTx      = "tx"
+)
+
+// GasPrices is a distribution of the gas prices of the txs over the levels, which lets the txs compete
+// by priority for the space of the blocks. A price is picked uniformly either once per account, or for every tx
+// by its account and sequence. The picks depend only on the seed, so that a run of the same seed prices
+// its txs the same.
+type GasPrices struct {
+	levels     []sdktypes.DecCoin
+	perAccount bool
+	seed       int64
+}
+
+// ParseGasPrices parses a comma separated list of gas prices, e.g. "0.01uatom,0.025uatom,0.1uatom".
+// Unlike sdk.ParseDecCoins, the prices of a denom are kept as separate levels.
+func ParseGasPrices(s string) ([]sdktypes.DecCoin, error) {
+	var levels []sdktypes.DecCoin
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		price, err := sdktypes.ParseDecCoin(field)
+		if err != nil {
+			return nil, fmt.Errorf("parse gas price %q: %w", field, err)
+		}
+		levels = append(levels, price)
+	}
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("no gas prices in %q", s)
+	}
+	return levels, nil
+}
+
+// NewGasPrices returns the distribution of the gas prices over the levels, picked per account or per tx.
+func NewGasPrices(levels []sdktypes.DecCoin, per string, seed int64) (*GasPrices, error) {
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("no gas prices")
+	}
+	switch per {
+	case GasPricePerAccount, GasPricePerTx:
+	default:
+		return nil, fmt.Errorf("unknown gas price distribution %q; must be either %s or %s", per, GasPricePerAccount, GasPricePerTx)
+	}
+	return &GasPrices{levels: levels, perAccount: per == GasPricePerAccount, seed: seed}, nil
+}
+
+// Price returns the gas price of the tx of the account at the sequence.
+func (g *GasPrices) Price(account string, sequence uint64) sdktypes.DecCoin {
+	if len(g.levels) == 1 {
+		return g.levels[0]
+	}
+	h := fnv.New64a()
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(g.seed))
+	h.Write(b[:])
+	h.Write([]byte(account))
+	if !g.perAccount {
+		binary.BigEndian.PutUint64(b[:], sequence)
+		h.Write(b[:])
+	}
+	return g.levels[h.Sum64()%uint64(len(g.levels))]
+}
+
+// Fee returns the fee of a tx of the gas limit at the gas price, rounded up.
+func Fee(price sdktypes.DecCoin, gasLimit uint64) sdktypes.Coin {
+	amount := price.Amount.MulInt64(int64(gasLimit)).Ceil().RoundInt()
+	return sdktypes.NewCoin(price.Denom, amount)
+}
+
+// GasPrice returns the gas price of the tx of the account at the sequence, e.g. "0.025uatom", or an empty
+// string if the fees are not derived from gas prices.
+func (t *Transaction) GasPrice(account string, sequence uint64) string {
+	if t.GasPrices == nil {
+		return ""
+	}
+	return FormatGasPrice(t.GasPrices.Price(account, sequence))
+}
+
+// FormatGasPrice formats a gas price without the trailing zeros of its amount.
+func FormatGasPrice(price sdktypes.DecCoin) string {
+	amount := price.Amount.String()
+	if strings.Contains(amount, ".") {
+		amount = strings.TrimRight(strings.TrimRight(amount, "0"), ".")
+	}
+	return amount + price.Denom
+}
@@ -0,0 +1,69 @@
+package tx_test
+
+import (
+	"fmt"
+	"testing"
+
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/test-go/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/tx"
+)
+
+func TestParseGasPrices(t *testing.T) {
+	levels, err := tx.ParseGasPrices("0.01uatom, 0.1uatom,0.025stake")
+	require.NoError(t, err)
+	require.Equal(t, []sdktypes.DecCoin{
+		sdktypes.NewDecCoinFromDec("uatom", sdktypes.NewDecWithPrec(1, 2)),
+		sdktypes.NewDecCoinFromDec("uatom", sdktypes.NewDecWithPrec(1, 1)),
+		sdktypes.NewDecCoinFromDec("stake", sdktypes.NewDecWithPrec(25, 3)),
+	}, levels)
+
+	_, err = tx.ParseGasPrices("")
+	require.Error(t, err)
+	_, err = tx.ParseGasPrices("0.01")
+	require.Error(t, err)
+}
+
+func TestFee(t *testing.T) {
+	price := sdktypes.NewDecCoinFromDec("uatom", sdktypes.NewDecWithPrec(25, 3))
+	require.Equal(t, sdktypes.NewInt64Coin("uatom", 5000), tx.Fee(price, 200000))
+	require.Equal(t, sdktypes.NewInt64Coin("uatom", 3751), tx.Fee(price, 150002), "the fee is rounded up")
+}
+
+func TestGasPrices(t *testing.T) {
+	levels, err := tx.ParseGasPrices("0.01uatom,0.025uatom,0.1uatom")
+	require.NoError(t, err)
+
+	_, err = tx.NewGasPrices(levels, "block", 1)
+	require.Error(t, err)
+
+	perAccount, err := tx.NewGasPrices(levels, tx.GasPricePerAccount, 1)
+	require.NoError(t, err)
+	perTx, err := tx.NewGasPrices(levels, tx.GasPricePerTx, 1)
+	require.NoError(t, err)
+	same, err := tx.NewGasPrices(levels, tx.GasPricePerTx, 1)
+	require.NoError(t, err)
+
+	txPrices := make(map[string]int)
+	for i := 0; i < 20; i++ {
+		account := fmt.Sprintf("account%d", i)
+		require.Equal(t, perAccount.Price(account, 0), perAccount.Price(account, 1), "an account keeps its price")
+		for seq := uint64(0); seq < 10; seq++ {
+			price := perTx.Price(account, seq)
+			require.Equal(t, price, same.Price(account, seq), "the prices depend only on the seed")
+			txPrices[price.String()]++
+		}
+	}
+	require.Len(t, txPrices, 3, "every level is picked")
+
+	single, err := tx.NewGasPrices(levels[:1], tx.GasPricePerTx, 1)
+	require.NoError(t, err)
+	require.Equal(t, levels[0], single.Price("account", 7))
+
+	txn := tx.NewTransaction(nil, "test-chain", 0, nil, "")
+	require.Equal(t, "", txn.GasPrice("account", 0))
+	txn.GasPrices = single
+	require.Equal(t, "0.01uatom", txn.GasPrice("account", 0))
+	require.Equal(t, "2stake", tx.FormatGasPrice(sdktypes.NewInt64DecCoin("stake", 2)))
+}
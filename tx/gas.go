@@ -0,0 +1,143 @@
+package tx
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	sdktx "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/rs/zerolog/log"
+
+	"github.com/b-harvest/modules-test-tool/load"
+	"github.com/b-harvest/modules-test-tool/tracing"
+)
+
+// MaxSimulationFailures is the number of failed simulations of a key after which its txs keep the fallback gas limit.
+const MaxSimulationFailures = 3
+
+// Simulator simulates txs, e.g. through the tx service of a node.
+type Simulator interface {
+	Simulate(ctx context.Context, txBytes []byte) (*sdktx.SimulateResponse, error)
+}
+
+// GasEstimate is the estimate of the gas of the txs of a key.
+type GasEstimate struct {
+	load.GasKey
+	Simulated uint64 // gas used by the simulation, zero if none succeeded
+	Limit     uint64 // gas limit of the txs, the simulated gas with the adjustment
+	Failures  int    // failed simulations
+}
+
+// GasEstimator estimates the gas limits of txs by simulating them with the adjustment factor. The estimates
+// are cached by the type and the number of the messages of the txs, and the txs keep the fallback gas limit
+// while their simulations fail. It is safe for concurrent use.
+type GasEstimator struct {
+	sim        Simulator
+	adjustment float64
+	msgType    func(sdktypes.Msg) string
+
+	mu        sync.Mutex
+	estimates map[load.GasKey]*GasEstimate
+}
+
+// NewGasEstimator returns a gas estimator simulating the txs with the simulator, grouping them by the type
+// of their first message given by msgType, or by its type URL if nil.
+func NewGasEstimator(sim Simulator, adjustment float64, msgType func(sdktypes.Msg) string) *GasEstimator {
+	if msgType == nil {
+		msgType = sdktypes.MsgTypeURL
+	}
+	return &GasEstimator{
+		sim:        sim,
+		adjustment: adjustment,
+		msgType:    msgType,
+		estimates:  make(map[load.GasKey]*GasEstimate),
+	}
+}
+
+// GasLimit returns the gas limit of a tx of the messages. The first tx of a key is simulated with the tx
+// returned by simTx, and the next ones reuse its estimate. The fallback is returned if the simulation fails.
+func (e *GasEstimator) GasLimit(ctx context.Context, msgs []sdktypes.Msg, fallback uint64, simTx func() ([]byte, error)) uint64 {
+	if len(msgs) == 0 {
+		return fallback
+	}
+	key := load.GasKey{MsgType: e.msgType(msgs[0]), MsgNum: len(msgs)}
+
+	e.mu.Lock()
+	est, ok := e.estimates[key]
+	if !ok {
+		est = &GasEstimate{GasKey: key}
+		e.estimates[key] = est
+	}
+	if est.Simulated > 0 {
+		limit := est.Limit
+		e.mu.Unlock()
+		return limit
+	}
+	if est.Failures >= MaxSimulationFailures {
+		est.Limit = fallback
+		e.mu.Unlock()
+		return fallback
+	}
+	e.mu.Unlock()
+
+	used, err := e.simulate(ctx, key, simTx)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err != nil {
+		est.Failures++
+		est.Limit = fallback
+		log.Warn().Err(err).Str("msg_type", key.MsgType).Int("msg_num", key.MsgNum).Uint64("gas_limit", fallback).
+			Msg("failed to simulate tx, falling back to the static gas limit")
+		return fallback
+	}
+	if est.Simulated == 0 {
+		est.Simulated = used
+		est.Limit = uint64(math.Ceil(float64(used) * e.adjustment))
+		log.Debug().Str("msg_type", key.MsgType).Int("msg_num", key.MsgNum).Uint64("gas_used", used).
+			Uint64("gas_limit", est.Limit).Msg("estimated gas")
+	}
+	return est.Limit
+}
+
+func (e *GasEstimator) simulate(ctx context.Context, key load.GasKey, simTx func() ([]byte, error)) (used uint64, err error) {
+	ctx, span := tracing.Start(ctx, "Simulate",
+		tracing.MsgCountKey.Int(key.MsgNum))
+	defer func() { tracing.End(span, err) }()
+
+	txBytes, err := simTx()
+	if err != nil {
+		return 0, err
+	}
+	resp, err := e.sim.Simulate(ctx, txBytes)
+	if err != nil {
+		return 0, err
+	}
+	if resp.GasInfo == nil {
+		return 0, errors.New("no gas info in the simulation response")
+	}
+	return resp.GasInfo.GasUsed, nil
+}
+
+// Estimates returns the estimates of every key in order.
+func (e *GasEstimator) Estimates() []GasEstimate {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	estimates := make([]GasEstimate, 0, len(e.estimates))
+	for _, est := range e.estimates {
+		estimates = append(estimates, *est)
+	}
+	sort.Slice(estimates, func(i, j int) bool {
+		if estimates[i].MsgType != estimates[j].MsgType {
+			return estimates[i].MsgType < estimates[j].MsgType
+		}
+		return estimates[i].MsgNum < estimates[j].MsgNum
+	})
+	return estimates
+}
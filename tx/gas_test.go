@@ -0,0 +1,105 @@
+package tx_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	sdktx "github.com/cosmos/cosmos-sdk/types/tx"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/test-go/testify/require"
+
+	"github.com/b-harvest/modules-test-tool/client"
+	"github.com/b-harvest/modules-test-tool/client/clictx"
+	"github.com/b-harvest/modules-test-tool/codec"
+	"github.com/b-harvest/modules-test-tool/load"
+	"github.com/b-harvest/modules-test-tool/signer"
+	"github.com/b-harvest/modules-test-tool/tx"
+)
+
+type fakeSimulator struct {
+	mu    sync.Mutex
+	calls int
+	gas   uint64
+	err   error
+}
+
+func (s *fakeSimulator) Simulate(ctx context.Context, txBytes []byte) (*sdktx.SimulateResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &sdktx.SimulateResponse{GasInfo: &sdktypes.GasInfo{GasUsed: s.gas}}, nil
+}
+
+func simTx() ([]byte, error) { return []byte("tx"), nil }
+
+func sendMsgs(n int) []sdktypes.Msg {
+	msgs := make([]sdktypes.Msg, n)
+	for i := range msgs {
+		msgs[i] = &banktypes.MsgSend{}
+	}
+	return msgs
+}
+
+func TestGasEstimator(t *testing.T) {
+	sim := &fakeSimulator{gas: 100_001}
+	est := tx.NewGasEstimator(sim, 1.5, nil)
+	ctx := context.Background()
+
+	require.Equal(t, uint64(150_002), est.GasLimit(ctx, sendMsgs(1), 1_000_000, simTx))
+	require.Equal(t, uint64(150_002), est.GasLimit(ctx, sendMsgs(1), 1_000_000, simTx))
+	require.Equal(t, 1, sim.calls, "the estimate of a key is cached")
+
+	sim.gas = 200_000
+	require.Equal(t, uint64(300_000), est.GasLimit(ctx, sendMsgs(2), 1_000_000, simTx))
+	require.Equal(t, 2, sim.calls)
+
+	msgType := sdktypes.MsgTypeURL(&banktypes.MsgSend{})
+	require.Equal(t, []tx.GasEstimate{
+		{GasKey: load.GasKey{MsgType: msgType, MsgNum: 1}, Simulated: 100_001, Limit: 150_002},
+		{GasKey: load.GasKey{MsgType: msgType, MsgNum: 2}, Simulated: 200_000, Limit: 300_000},
+	}, est.Estimates())
+}
+
+func TestGasEstimatorFallback(t *testing.T) {
+	sim := &fakeSimulator{err: errors.New("unavailable")}
+	est := tx.NewGasEstimator(sim, 1.3, func(sdktypes.Msg) string { return "send" })
+	ctx := context.Background()
+
+	for i := 0; i < tx.MaxSimulationFailures+2; i++ {
+		require.Equal(t, uint64(1_000_000), est.GasLimit(ctx, sendMsgs(1), 1_000_000, simTx))
+	}
+	require.Equal(t, tx.MaxSimulationFailures, sim.calls, "the simulations stop after the failures")
+	require.Equal(t, []tx.GasEstimate{
+		{GasKey: load.GasKey{MsgType: "send", MsgNum: 1}, Limit: 1_000_000, Failures: tx.MaxSimulationFailures},
+	}, est.Estimates())
+
+	buildErr := func() ([]byte, error) { return nil, errors.New("build") }
+	require.Equal(t, uint64(5), est.GasLimit(ctx, sendMsgs(3), 5, buildErr))
+	require.Equal(t, uint64(5), est.GasLimit(ctx, nil, 5, simTx))
+
+	var nilEst *tx.GasEstimator
+	require.Empty(t, nilEst.Estimates())
+}
+
+func TestSignWithGasEstimator(t *testing.T) {
+	codec.SetCodec()
+	txn := tx.NewTransaction(&client.Client{CliCtx: clictx.NewClient("", nil)}, "test-chain", 200000, nil, "")
+	sim := &fakeSimulator{gas: 1000}
+	txn.Gas = tx.NewGasEstimator(sim, 2, nil)
+
+	s, err := signer.FromMnemonic("test test test test test test test test test test test junk", "", "44'/118'/0'/0/0", signer.KeyTypeSecp256k1)
+	require.NoError(t, err)
+	txBytes, err := txn.SignWith(context.Background(), s, 0, 0, &banktypes.MsgSend{FromAddress: s.Address().String()})
+	require.NoError(t, err)
+
+	decoded, err := codec.EncodingConfig.TxConfig.TxDecoder()(txBytes)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2000), decoded.(sdktypes.FeeTx).GetGas())
+	require.Equal(t, 1, sim.calls)
+}
@@ -4,16 +4,24 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"time"
 
 	"github.com/b-harvest/modules-test-tool/client"
+	"github.com/b-harvest/modules-test-tool/load"
+	"github.com/b-harvest/modules-test-tool/metrics"
+	"github.com/b-harvest/modules-test-tool/signer"
+	"github.com/b-harvest/modules-test-tool/tracing"
 
 	liquiditytypes "github.com/gravity-devs/liquidity/x/liquidity/types"
 
-	sdkclienttx "github.com/cosmos/cosmos-sdk/client/tx"
+	sdkclient "github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
 	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	sdktx "github.com/cosmos/cosmos-sdk/types/tx"
 	"github.com/cosmos/cosmos-sdk/types/tx/signing"
 	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+	tmtypes "github.com/tendermint/tendermint/types"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Transaction is an object that has common fields when signing transaction.
@@ -23,6 +31,17 @@ type Transaction struct {
 	GasLimit uint64         `json:"gas_limit"`
 	Fees     sdktypes.Coins `json:"fees"`
 	Memo     string         `json:"memo"`
+
+	// Gas estimates the gas limits of the txs by simulating them, if set. GasLimit is the fallback.
+	Gas *GasEstimator `json:"-"`
+	// GasPrices prices the txs, whose fees are their gas limits at their gas prices instead of Fees, if set.
+	GasPrices *GasPrices `json:"-"`
+	// Metrics records the signed and broadcast txs, if set.
+	Metrics *metrics.Metrics `json:"-"`
+	// BroadcastMode is the mode the txs are broadcast in, sync if unspecified.
+	BroadcastMode sdktx.BroadcastMode `json:"-"`
+	// Confirmations follows the accepted txs until they are committed, if set.
+	Confirmations *load.ConfirmationTracker `json:"-"`
 }
 
 // NewTransaction returns new Transaction object.
@@ -101,17 +120,30 @@ func MsgSwap(poolCreator string, poolId uint64, swapTypeId uint32, offerCoin sdk
 	return msg, nil
 }
 
-// CreateSwapBot creates a bot that makes multiple swaps which increases and decreases
-func (t *Transaction) CreateSwapBot(ctx context.Context, poolCreator string,
-	poolId uint64, offerCoin sdktypes.Coin, demandCoinDenom string, msgNum int) ([]sdktypes.Msg, error) {
-	pool, err := t.Client.GRPC.GetPool(ctx, poolId)
+// CreateSwapBot creates a bot that makes multiple swaps which increases and decreases.
+// The order prices are randomized with the given random source.
+func (t *Transaction) CreateSwapBot(ctx context.Context, r *rand.Rand, poolCreator string,
+	poolId uint64, offerCoin sdktypes.Coin, demandCoinDenom string, msgNum int) (msgs []sdktypes.Msg, err error) {
+	ctx, span := tracing.Start(ctx, "CreateSwapBot",
+		tracing.AccountKey.String(poolCreator),
+		tracing.PoolIDKey.Int64(int64(poolId)),
+		tracing.MsgCountKey.Int(msgNum))
+	defer func() { tracing.End(span, err) }()
+
+	qctx, qspan := tracing.Start(ctx, "GetPool", tracing.PoolIDKey.Int64(int64(poolId)))
+	pool, err := t.Client.GRPC.GetPool(qctx, poolId)
+	tracing.End(qspan, err)
 	if err != nil {
 		return []sdktypes.Msg{}, err
 	}
 
 	reserveCoins := sdktypes.NewCoins()
 	for _, denom := range pool.ReserveCoinDenoms {
-		coin, err := t.Client.GRPC.GetBalance(ctx, pool.GetReserveAccount().String(), denom)
+		qctx, qspan := tracing.Start(ctx, "GetBalance",
+			tracing.AccountKey.String(pool.GetReserveAccount().String()),
+			tracing.DenomKey.String(denom))
+		coin, err := t.Client.GRPC.GetBalance(qctx, pool.GetReserveAccount().String(), denom)
+		tracing.End(qspan, err)
 		if err != nil {
 			return []sdktypes.Msg{}, err
 		}
@@ -120,11 +152,9 @@ func (t *Transaction) CreateSwapBot(ctx context.Context, poolCreator string,
 
 	orderPrice := reserveCoins.AmountOf(pool.ReserveCoinDenoms[0]).ToDec().Quo(reserveCoins.AmountOf(pool.ReserveCoinDenoms[1]).ToDec())
 
-	var msgs []sdktypes.Msg
-
 	// randomize order price
 	for i := 0; i < msgNum; i++ {
-		random := sdktypes.NewDec(int64(rand.Intn(2)))
+		random := sdktypes.NewDec(int64(r.Intn(2)))
 		orderPricePercentage := orderPrice.Mul(random.Quo(sdktypes.NewDec(100)))
 		orderPrice = orderPrice.Sub(orderPricePercentage)
 
@@ -138,52 +168,130 @@ func (t *Transaction) CreateSwapBot(ctx context.Context, poolCreator string,
 	return msgs, nil
 }
 
-// Sign signs message(s) with the account's private key and braodacasts the message(s).
+// Sign signs message(s) with the account's secp256k1 private key.
 func (t *Transaction) Sign(ctx context.Context, accSeq uint64, accNum uint64, privKey *secp256k1.PrivKey, msgs ...sdktypes.Msg) ([]byte, error) {
-	txBuilder := t.Client.CliCtx.TxConfig.NewTxBuilder()
-	if err := txBuilder.SetMsgs(msgs...); err != nil {
-		return nil, err
-	}
-	txBuilder.SetGasLimit(t.GasLimit)
-	txBuilder.SetFeeAmount(t.Fees)
-	txBuilder.SetMemo(t.Memo)
+	return t.SignWith(ctx, signer.NewSecp256k1(privKey), accSeq, accNum, msgs...)
+}
 
-	signMode := t.Client.CliCtx.TxConfig.SignModeHandler().DefaultMode()
+// SignWith builds the tx of the message(s) and signs it with the signer of the account.
+func (t *Transaction) SignWith(ctx context.Context, s signer.Signer, accSeq uint64, accNum uint64, msgs ...sdktypes.Msg) (txByte []byte, err error) {
+	started := time.Now()
+	_, span := t.startSignSpan(ctx, accSeq, s, msgs)
+	defer func() { endSignSpan(span, txByte, err) }()
+
+	gasLimit := t.GasLimit
+	if t.Gas != nil {
+		gasLimit = t.Gas.GasLimit(ctx, msgs, t.GasLimit, func() ([]byte, error) {
+			// the signatures are not verified by the simulation, so the tx is simulated unsigned
+			txBuilder, _, err := t.newTxBuilder(s, accSeq, 0, t.Fees, msgs)
+			if err != nil {
+				return nil, err
+			}
+			return t.Client.CliCtx.TxConfig.TxEncoder()(txBuilder.GetTx())
+		})
+	}
 
-	sigV2 := signing.SignatureV2{
-		PubKey: privKey.PubKey(),
-		Data: &signing.SingleSignatureData{
-			SignMode:  signMode,
-			Signature: nil,
-		},
-		Sequence: accSeq,
+	fees := t.Fees
+	if t.GasPrices != nil {
+		fees = sdktypes.NewCoins(Fee(t.GasPrices.Price(s.Address().String(), accSeq), gasLimit))
 	}
 
-	err := txBuilder.SetSignatures(sigV2)
+	txConfig := t.Client.CliCtx.TxConfig
+	txBuilder, sigV2, err := t.newTxBuilder(s, accSeq, gasLimit, fees, msgs)
 	if err != nil {
-		return nil, fmt.Errorf("failed to set signatures: %s", err)
+		return nil, err
 	}
+	signMode := sigV2.Data.(*signing.SingleSignatureData).SignMode
 
 	signerData := authsigning.SignerData{
 		ChainID:       t.ChainID,
 		AccountNumber: accNum,
 		Sequence:      accSeq,
 	}
-
-	sigV2, err = sdkclienttx.SignWithPrivKey(signMode, signerData, txBuilder, privKey, t.Client.CliCtx.TxConfig, accSeq)
+	signBytes, err := txConfig.SignModeHandler().GetSignBytes(signMode, signerData, txBuilder.GetTx())
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign with private key: %s", err)
+		return nil, fmt.Errorf("failed to get sign bytes: %w", err)
 	}
-
-	err = txBuilder.SetSignatures(sigV2)
+	sigV2.Data.(*signing.SingleSignatureData).Signature, err = s.Sign(signBytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to set signatures: %s", err)
+		return nil, fmt.Errorf("failed to sign: %w", err)
+	}
+	if err := txBuilder.SetSignatures(sigV2); err != nil {
+		return nil, fmt.Errorf("failed to set signatures: %w", err)
 	}
 
-	txByte, err := t.Client.CliCtx.TxConfig.TxEncoder()(txBuilder.GetTx())
+	txByte, err = txConfig.TxEncoder()(txBuilder.GetTx())
 	if err != nil {
-		return nil, fmt.Errorf("failed to encode tx and get raw tx data: %s", err)
+		return nil, fmt.Errorf("failed to encode tx and get raw tx data: %w", err)
 	}
+	t.Metrics.ObserveSign(t.ChainID, time.Since(started))
 
 	return txByte, nil
 }
+
+// newTxBuilder returns a builder of the tx of the message(s) with the gas limit and the fees, which is signed
+// by the signer with an empty signature, as the signer infos are a part of the sign bytes.
+func (t *Transaction) newTxBuilder(s signer.Signer, accSeq uint64, gasLimit uint64, fees sdktypes.Coins, msgs []sdktypes.Msg) (sdkclient.TxBuilder, signing.SignatureV2, error) {
+	txConfig := t.Client.CliCtx.TxConfig
+	txBuilder := txConfig.NewTxBuilder()
+	if err := txBuilder.SetMsgs(msgs...); err != nil {
+		return nil, signing.SignatureV2{}, err
+	}
+	txBuilder.SetGasLimit(gasLimit)
+	txBuilder.SetFeeAmount(fees)
+	txBuilder.SetMemo(t.Memo)
+
+	sigV2 := signing.SignatureV2{
+		PubKey:   s.PubKey(),
+		Data:     &signing.SingleSignatureData{SignMode: txConfig.SignModeHandler().DefaultMode()},
+		Sequence: accSeq,
+	}
+	if err := txBuilder.SetSignatures(sigV2); err != nil {
+		return nil, signing.SignatureV2{}, fmt.Errorf("failed to set signatures: %w", err)
+	}
+	return txBuilder, sigV2, nil
+}
+
+// Broadcast broadcasts the signed tx of the account.
+func (t *Transaction) Broadcast(ctx context.Context, account string, txBytes []byte) (*sdktx.BroadcastTxResponse, error) {
+	started := time.Now()
+	ctx, span := tracing.Start(ctx, "BroadcastTx",
+		tracing.ChainIDKey.String(t.ChainID),
+		tracing.TxHashKey.String(txHash(txBytes)))
+	resp, err := t.Client.GRPC.BroadcastTx(ctx, txBytes, t.BroadcastMode)
+	if err != nil {
+		tracing.End(span, err)
+		return nil, err
+	}
+	if resp.TxResponse.Code == 0 {
+		t.Confirmations.Track(resp.TxResponse.TxHash, account, started)
+	}
+	span.SetAttributes(
+		tracing.TxCodeKey.Int64(int64(resp.TxResponse.Code)),
+		tracing.CodespaceKey.String(resp.TxResponse.Codespace))
+	tracing.End(span, nil)
+	t.Metrics.ObserveBroadcast(t.ChainID, time.Since(started), resp.TxResponse.Code)
+	return resp, nil
+}
+
+// startSignSpan starts the span of signing the messages with the account of the signer.
+func (t *Transaction) startSignSpan(ctx context.Context, accSeq uint64, s signer.Signer, msgs []sdktypes.Msg) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "Sign",
+		tracing.ChainIDKey.String(t.ChainID),
+		tracing.AccountKey.String(s.Address().String()),
+		tracing.SequenceKey.Int64(int64(accSeq)),
+		tracing.MsgCountKey.Int(len(msgs)))
+}
+
+// endSignSpan ends the span of signing, adding the hash of the signed tx.
+func endSignSpan(span trace.Span, txByte []byte, err error) {
+	if err == nil {
+		span.SetAttributes(tracing.TxHashKey.String(txHash(txByte)))
+	}
+	tracing.End(span, err)
+}
+
+// txHash returns the hash of the tx as shown in the responses of the node.
+func txHash(txBytes []byte) string {
+	return fmt.Sprintf("%X", tmtypes.Tx(txBytes).Hash())
+}
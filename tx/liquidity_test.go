@@ -3,18 +3,25 @@ package tx_test
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/test-go/testify/require"
 
 	"github.com/b-harvest/modules-test-tool/client"
+	"github.com/b-harvest/modules-test-tool/client/clictx"
+	"github.com/b-harvest/modules-test-tool/codec"
 	"github.com/b-harvest/modules-test-tool/config"
+	"github.com/b-harvest/modules-test-tool/signer"
 	"github.com/b-harvest/modules-test-tool/tx"
 	"github.com/b-harvest/modules-test-tool/wallet"
 
 	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
 	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	sdktx "github.com/cosmos/cosmos-sdk/types/tx"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
 )
 
 var (
@@ -22,17 +29,42 @@ var (
 	cfg *config.Config
 
 	rpcAddress  = "http://localhost:26657"
-	grpcAddress = "localhost:9090"
+	grpcAddress = "http://localhost:9090"
 )
 
+// TestMain connects to the local node the live tests run against. The live tests are skipped
+// when the node is not reachable, and the other tests run regardless.
 func TestMain(m *testing.M) {
-	c, _ = client.NewClient(rpcAddress, grpcAddress)
+	if nodeReachable() {
+		if nc, err := client.NewClient(rpcAddress, grpcAddress); err == nil {
+			c = nc
+		}
+	}
 
 	cfg, _ = config.Read(config.DefaultConfigPath)
 
 	os.Exit(m.Run())
 }
 
+// nodeReachable returns whether the rpc and grpc ports of the local node accept connections.
+func nodeReachable() bool {
+	for _, addr := range []string{"localhost:26657", "localhost:9090"} {
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+	}
+	return true
+}
+
+// requireNode skips a live test when the local node is not reachable.
+func requireNode(t *testing.T) {
+	if c == nil {
+		t.Skip("local node is not reachable")
+	}
+}
+
 func TestFindAllPairs(t *testing.T) {
 	pairs := []struct {
 		pairs []string
@@ -78,6 +110,7 @@ func TestFindAllPairs(t *testing.T) {
 }
 
 func TestDepositWithinBatch(t *testing.T) {
+	requireNode(t)
 	mnemonic := "guard cream sadness conduct invite crumble clock pudding hole grit liar hotel maid produce squeeze return argue turtle know drive eight casino maze host"
 
 	accAddr, privKey, err := wallet.RecoverAccountFromMnemonic(mnemonic, "")
@@ -127,7 +160,7 @@ func TestDepositWithinBatch(t *testing.T) {
 			txByte, err := tx.Sign(ctx, accSeq, accNum, privKey, msgs...)
 			require.NoError(t, err)
 
-			resp, err := c.GRPC.BroadcastTx(ctx, txByte)
+			resp, err := c.GRPC.BroadcastTx(ctx, txByte, sdktx.BroadcastMode_BROADCAST_MODE_SYNC)
 			require.NoError(t, err)
 
 			fmt.Println("Code: ", resp.TxResponse.Code)
@@ -138,6 +171,7 @@ func TestDepositWithinBatch(t *testing.T) {
 }
 
 func TestWithdrawWithinBatch(t *testing.T) {
+	requireNode(t)
 	mnemonic := "guard cream sadness conduct invite crumble clock pudding hole grit liar hotel maid produce squeeze return argue turtle know drive eight casino maze host"
 
 	accAddr, privKey, err := wallet.RecoverAccountFromMnemonic(mnemonic, "")
@@ -187,7 +221,7 @@ func TestWithdrawWithinBatch(t *testing.T) {
 			txByte, err := tx.Sign(ctx, accSeq, accNum, privKey, msgs...)
 			require.NoError(t, err)
 
-			resp, err := c.GRPC.BroadcastTx(ctx, txByte)
+			resp, err := c.GRPC.BroadcastTx(ctx, txByte, sdktx.BroadcastMode_BROADCAST_MODE_SYNC)
 			require.NoError(t, err)
 
 			fmt.Println("Code: ", resp.TxResponse.Code)
@@ -196,3 +230,31 @@ func TestWithdrawWithinBatch(t *testing.T) {
 		})
 	}
 }
+
+func TestSignWith(t *testing.T) {
+	codec.SetCodec()
+	txConfig := codec.EncodingConfig.TxConfig
+	txn := tx.NewTransaction(&client.Client{CliCtx: clictx.NewClient("", nil)}, "test-chain", 200000, nil, "memo")
+
+	for _, keyType := range []string{signer.KeyTypeSecp256k1, signer.KeyTypeEthSecp256k1} {
+		s, err := signer.FromMnemonic("test test test test test test test test test test test junk", "", "44'/118'/0'/0/0", keyType)
+		require.NoError(t, err)
+
+		msg, err := tx.MsgDeposit(s.Address().String(), 1, sdktypes.NewCoins(sdktypes.NewInt64Coin("uatom", 1000), sdktypes.NewInt64Coin("uusd", 1000)))
+		require.NoError(t, err)
+		txBytes, err := txn.SignWith(context.Background(), s, 3, 7, msg)
+		require.NoError(t, err)
+
+		decoded, err := txConfig.TxDecoder()(txBytes)
+		require.NoError(t, err)
+		sigTx := decoded.(authsigning.SigVerifiableTx)
+		sigs, err := sigTx.GetSignaturesV2()
+		require.NoError(t, err)
+		require.Len(t, sigs, 1)
+		require.Equal(t, uint64(3), sigs[0].Sequence)
+		require.True(t, s.PubKey().Equals(sigs[0].PubKey))
+
+		signerData := authsigning.SignerData{ChainID: "test-chain", AccountNumber: 7, Sequence: 3}
+		require.NoError(t, authsigning.VerifySignature(sigs[0].PubKey, signerData, sigs[0].Data, txConfig.SignModeHandler(), decoded))
+	}
+}
@@ -0,0 +1,182 @@
+package tx
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/b-harvest/modules-test-tool/errclass"
+)
+
+// AccountQuerier queries the accounts of a chain, e.g. through the auth service of a node.
+type AccountQuerier interface {
+	GetBaseAccountInfo(ctx context.Context, address string) (authtypes.BaseAccount, error)
+}
+
+// sequenceMismatch matches the log of a tx rejected by the ante handler for its sequence.
+var sequenceMismatch = regexp.MustCompile(`account sequence mismatch, expected (\d+)`)
+
+// ParseExpectedSequence returns the sequence expected by the node from the log of a tx rejected for
+// an account sequence mismatch, and false if the log is of another error.
+func ParseExpectedSequence(rawLog string) (uint64, bool) {
+	m := sequenceMismatch.FindStringSubmatch(rawLog)
+	if m == nil {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(m[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return seq, true
+}
+
+// Sequences manages the sequences of the accounts signing txs. A sequence is reserved for every tx,
+// and given back when the tx is rejected by CheckTx so that the next tx of the account fills the gap.
+// The account is queried the first time it is used, and again after it has been invalidated. It is safe for concurrent use.
+type Sequences struct {
+	q AccountQuerier
+
+	mu       sync.Mutex
+	accounts map[string]*accountSequences
+}
+
+// accountSequences are the sequences of an account.
+type accountSequences struct {
+	mu       sync.Mutex
+	loaded   bool
+	num      uint64
+	synced   uint64   // sequence known from the node; the ones below have been used
+	next     uint64   // sequence reserved after the released ones
+	released []uint64 // released sequences below next in order
+}
+
+// NewSequences returns a sequence manager querying the unknown accounts with q.
+func NewSequences(q AccountQuerier) *Sequences {
+	return &Sequences{
+		q:        q,
+		accounts: make(map[string]*accountSequences),
+	}
+}
+
+func (s *Sequences) account(addr string) *accountSequences {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acc, ok := s.accounts[addr]
+	if !ok {
+		acc = &accountSequences{}
+		s.accounts[addr] = acc
+	}
+	return acc
+}
+
+// Reserve returns the account number of the account and reserves its next sequence, the lowest released
+// one if any.
+func (s *Sequences) Reserve(ctx context.Context, addr string) (accNum, seq uint64, err error) {
+	acc := s.account(addr)
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	if err := s.load(ctx, addr, acc); err != nil {
+		return 0, 0, err
+	}
+	if len(acc.released) > 0 {
+		seq = acc.released[0]
+		acc.released = acc.released[1:]
+		return acc.num, seq, nil
+	}
+	seq = acc.next
+	acc.next++
+	return acc.num, seq, nil
+}
+
+// Release gives back a reserved sequence of the account whose tx has not been accepted.
+func (s *Sequences) Release(addr string, seq uint64) {
+	acc := s.account(addr)
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	if !acc.loaded || seq < acc.synced || seq >= acc.next {
+		return // reserved before a resync
+	}
+	i := sort.Search(len(acc.released), func(i int) bool { return acc.released[i] >= seq })
+	if i < len(acc.released) && acc.released[i] == seq {
+		return
+	}
+	acc.released = append(acc.released, 0)
+	copy(acc.released[i+1:], acc.released[i:])
+	acc.released[i] = seq
+	// the released sequences at the end are reserved again in order
+	for n := len(acc.released); n > 0 && acc.released[n-1] == acc.next-1; n-- {
+		acc.next--
+		acc.released = acc.released[:n-1]
+	}
+}
+
+// Resync sets the next sequence of the account to the one expected by the node, dropping the released ones.
+func (s *Sequences) Resync(addr string, seq uint64) {
+	acc := s.account(addr)
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	if !acc.loaded {
+		return // the account number is still unknown; the account is queried on the next reservation
+	}
+	acc.synced, acc.next, acc.released = seq, seq, nil
+}
+
+// Invalidate has the account queried again on the next reservation.
+func (s *Sequences) Invalidate(addr string) {
+	acc := s.account(addr)
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	acc.loaded, acc.released = false, nil
+}
+
+// Load returns the account number of the account and the sequence its next reservation gets, querying
+// the account if it is not known.
+func (s *Sequences) Load(ctx context.Context, addr string) (accNum, seq uint64, err error) {
+	acc := s.account(addr)
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	if err := s.load(ctx, addr, acc); err != nil {
+		return 0, 0, err
+	}
+	if len(acc.released) > 0 {
+		return acc.num, acc.released[0], nil
+	}
+	return acc.num, acc.next, nil
+}
+
+// load queries the account if it is not known. acc must be locked.
+func (s *Sequences) load(ctx context.Context, addr string, acc *accountSequences) error {
+	if acc.loaded {
+		return nil
+	}
+	a, err := s.q.GetBaseAccountInfo(ctx, addr)
+	if err != nil {
+		return fmt.Errorf("get base account info: %w", err)
+	}
+	acc.loaded, acc.num, acc.synced, acc.next, acc.released = true, a.GetAccountNumber(), a.GetSequence(), a.GetSequence(), nil
+	return nil
+}
+
+// Reject handles a tx of the account signed with the reserved sequence and rejected by CheckTx. The account
+// is resynced to the sequence expected by the node on a sequence mismatch, queried again on another sequence
+// error, and the sequence is released otherwise. It returns whether the account has been resynced, in which
+// case the tx may be signed again with a newly reserved sequence.
+func (s *Sequences) Reject(addr string, seq uint64, codespace string, code uint32, rawLog string) bool {
+	if expected, ok := ParseExpectedSequence(rawLog); ok {
+		s.Resync(addr, expected)
+		log.Debug().Str("addr", addr).Uint64("seq", seq).Uint64("expected", expected).Msg("resynced account sequence")
+		return true
+	}
+	if errclass.Classify(codespace, code).Class == errclass.ClassSequence {
+		s.Invalidate(addr)
+		return false
+	}
+	s.Release(addr, seq)
+	return false
+}
@@ -0,0 +1,135 @@
+package tx_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/test-go/testify/require"
+
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+
+	"github.com/b-harvest/modules-test-tool/tx"
+)
+
+// fakeAccounts answers the queries of the accounts with fixed sequences, counting them.
+type fakeAccounts struct {
+	mu      sync.Mutex
+	seqs    map[string]uint64
+	queries int
+}
+
+func (f *fakeAccounts) GetBaseAccountInfo(ctx context.Context, address string) (authtypes.BaseAccount, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries++
+	seq, ok := f.seqs[address]
+	if !ok {
+		return authtypes.BaseAccount{}, errors.New("account not found")
+	}
+	return authtypes.BaseAccount{Address: address, AccountNumber: 7, Sequence: seq}, nil
+}
+
+func reserve(t *testing.T, seqs *tx.Sequences, addr string) uint64 {
+	accNum, seq, err := seqs.Reserve(context.Background(), addr)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), accNum)
+	return seq
+}
+
+func TestSequences(t *testing.T) {
+	q := &fakeAccounts{seqs: map[string]uint64{"addr": 10}}
+	seqs := tx.NewSequences(q)
+
+	for i := uint64(10); i < 15; i++ {
+		require.Equal(t, i, reserve(t, seqs, "addr"))
+	}
+	require.Equal(t, 1, q.queries)
+
+	// the gaps are filled in order before the next sequences
+	seqs.Release("addr", 12)
+	seqs.Release("addr", 11)
+	seqs.Release("addr", 11)
+	require.Equal(t, uint64(11), reserve(t, seqs, "addr"))
+	require.Equal(t, uint64(12), reserve(t, seqs, "addr"))
+	require.Equal(t, uint64(15), reserve(t, seqs, "addr"))
+
+	// the released sequences at the end are reserved again
+	seqs.Release("addr", 13)
+	seqs.Release("addr", 15)
+	seqs.Release("addr", 14)
+	_, next, err := seqs.Load(context.Background(), "addr")
+	require.NoError(t, err)
+	require.Equal(t, uint64(13), next)
+	require.Equal(t, uint64(13), reserve(t, seqs, "addr"))
+	require.Equal(t, uint64(14), reserve(t, seqs, "addr"))
+
+	seqs.Resync("addr", 20)
+	seqs.Release("addr", 14) // reserved before the resync
+	require.Equal(t, uint64(20), reserve(t, seqs, "addr"))
+
+	q.seqs["addr"] = 30
+	seqs.Invalidate("addr")
+	require.Equal(t, uint64(30), reserve(t, seqs, "addr"))
+	require.Equal(t, 2, q.queries)
+
+	_, _, err = seqs.Reserve(context.Background(), "unknown")
+	require.Error(t, err)
+}
+
+func TestSequencesReject(t *testing.T) {
+	q := &fakeAccounts{seqs: map[string]uint64{"addr": 5}}
+	seqs := tx.NewSequences(q)
+	for i := 0; i < 3; i++ {
+		reserve(t, seqs, "addr")
+	}
+
+	// the first tx is rejected, so the next ones mismatch the sequence expected by the node
+	require.False(t, seqs.Reject("addr", 5, "liquidity", 29, "insufficient funds"))
+	require.True(t, seqs.Reject("addr", 6, "sdk", 32, "account sequence mismatch, expected 5, got 6: incorrect account sequence"))
+	require.Equal(t, uint64(5), reserve(t, seqs, "addr"))
+	require.Equal(t, uint64(6), reserve(t, seqs, "addr"))
+
+	// a sequence error without the expected sequence has the account queried again
+	q.seqs["addr"] = 9
+	require.False(t, seqs.Reject("addr", 6, "sdk", 19, "tx already in mempool"))
+	require.Equal(t, uint64(9), reserve(t, seqs, "addr"))
+	require.Equal(t, 2, q.queries)
+}
+
+func TestParseExpectedSequence(t *testing.T) {
+	seq, ok := tx.ParseExpectedSequence("account sequence mismatch, expected 1234, got 1230: incorrect account sequence")
+	require.True(t, ok)
+	require.Equal(t, uint64(1234), seq)
+
+	_, ok = tx.ParseExpectedSequence("insufficient fees; got: 1stake required: 2stake: insufficient fee")
+	require.False(t, ok)
+}
+
+func TestSequencesConcurrent(t *testing.T) {
+	seqs := tx.NewSequences(&fakeAccounts{seqs: map[string]uint64{"addr": 0}})
+	var mu sync.Mutex
+	reserved := make(map[uint64]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_, seq, err := seqs.Reserve(context.Background(), "addr")
+				if err != nil {
+					panic(err)
+				}
+				mu.Lock()
+				reserved[seq] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	require.Len(t, reserved, 800)
+	_, next, err := seqs.Load(context.Background(), "addr")
+	require.NoError(t, err)
+	require.Equal(t, uint64(800), next)
+}
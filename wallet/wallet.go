@@ -8,6 +8,8 @@ import (
 	sdktypes "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/types/bech32"
 	bip39 "github.com/cosmos/go-bip39"
+
+	"github.com/b-harvest/modules-test-tool/signer"
 )
 
 // RecoverAccountFromMnemonic recovers private key from mnemonic and return account address after bech32 encoding.
@@ -50,3 +52,19 @@ func IBCRecoverAccountFromMnemonic(mnemonic string, password string, accounthd s
 
 	return accAddr, privKey, nil
 }
+
+// RecoverSigner recovers the key of the given type from mnemonic and returns its signer and account address
+// after bech32 encoding with the given prefix.
+func RecoverSigner(mnemonic string, password string, hdPath string, keyType string, accountAddrPrefix string) (string, signer.Signer, error) {
+	s, err := signer.FromMnemonic(mnemonic, password, hdPath, keyType)
+	if err != nil {
+		return "", nil, err
+	}
+
+	accAddr, err := bech32.ConvertAndEncode(accountAddrPrefix, s.Address())
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to convert and encode address: %w", err)
+	}
+
+	return accAddr, s, nil
+}